@@ -67,7 +67,7 @@ func main() {
 	changed := make(chan string, 1)
 	childStarted := make(chan struct{}, 1)
 	childStopped := make(chan struct{}, 1)
-	stopSignals := make(chan os.Signal)
+	stopSignals := make(chan os.Signal, 1)
 	done := make(chan struct{})
 
 	var child *exec.Cmd
@@ -10,7 +10,7 @@ import type {
   ProcessDescription,
 } from './process/types';
 import type { VolumeDescription, NetworkDescription } from './docker/types';
-import type { TaskDescription } from './tasks/types';
+import type { TaskDescription, TaskOutputResponse } from './tasks/types';
 
 interface IdleRequest {
   stage: 'idle';
@@ -194,6 +194,8 @@ export interface WorkspaceDescription {
   id: string;
   root: string;
   displayName: string;
+  watching: boolean;
+  driftPolicy: string;
 }
 
 export interface ComponentDescription {
@@ -250,6 +252,7 @@ export interface KernelApi {
   ping(): Promise<void>;
   authEsv(): Promise<AuthEsvResult>;
   describeTasks(input?: DescribeTasksInput): Promise<TaskDescription[]>;
+  getTaskOutput(jobId: string, cursor?: string): Promise<TaskOutputResponse>;
 }
 
 export interface VariableDescription {
@@ -364,6 +367,16 @@ export const api = (() => {
         return tasks as TaskDescription[];
       },
 
+      async getTaskOutput(
+        jobId: string,
+        cursor = '',
+      ): Promise<TaskOutputResponse> {
+        return (await invoke('get-task-output', {
+          jobId,
+          cursor,
+        })) as TaskOutputResponse;
+      },
+
       async authEsv(): Promise<AuthEsvResult> {
         return (await invoke('auth-esv', {})) as any;
       },
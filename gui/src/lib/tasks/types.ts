@@ -1,3 +1,5 @@
+import type { LogEvent } from '../logs/types';
+
 export interface TaskDescription {
   id: string;
   jobId: string;
@@ -17,4 +19,15 @@ export interface TaskProgress {
   total: number;
 }
 
-export type Status = 'pending' | 'running' | 'success' | 'failure';
+export type Status =
+  | 'pending'
+  | 'running'
+  | 'success'
+  | 'failure'
+  | 'skipped'
+  | 'canceled';
+
+export interface TaskOutputResponse {
+  items: LogEvent[]; // Streams are task IDs.
+  nextCursor: string;
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(adoptCmd)
+	adoptCmd.Flags().StringVar(&adoptFlags.Project, "project", "", "Docker Compose project whose resources to adopt")
+}
+
+var adoptFlags struct {
+	Project string
+}
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [--project <name>] [container...]",
+	Short: "Adopts existing Docker resources as components",
+	Long: `Creates components for Docker resources that already exist, such as those
+started with 'docker compose up' or 'docker run'.
+
+With --project, the containers, networks and volumes labeled as belonging to
+the named Docker Compose project are adopted. Components are named after their
+compose services, networks and volumes. Additional containers may be given by
+name or ID, and are named after their container names.
+
+Specs are reconstructed from Docker's inspection of each resource. Adopted
+resources are left running and are not recreated. Changing their specs, such
+as by applying a manifest, updates them like any other component.
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if adoptFlags.Project == "" && len(args) == 0 {
+			return errors.New("expected --project or containers to adopt")
+		}
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		output, err := workspace.AdoptComponents(ctx, &api.AdoptComponentsInput{
+			Project:    adoptFlags.Project,
+			Containers: args,
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range output.Names {
+			fmt.Println("adopted", name)
+		}
+		return nil
+	},
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/deref/exo/internal/core/api"
 	"github.com/spf13/cobra"
@@ -13,10 +14,27 @@ import (
 func init() {
 	rootCmd.AddCommand(applyCmd)
 	applyCmd.Flags().StringVar(&applyFlags.Format, "format", "", "exo, compose, procfile")
+	applyCmd.Flags().StringArrayVar(&applyFlags.Vars, "var", nil, "set a manifest variable, as NAME=VALUE")
+	applyCmd.Flags().StringSliceVar(&applyFlags.Profiles, "profile", nil, "activate a component profile")
+	applyCmd.Flags().StringArrayVarP(&applyFlags.Files, "file", "f", nil, "manifest file; may be repeated to merge compose files")
+	applyCmd.Flags().StringVarP(&applyFlags.Formation, "formation", "m", "", "procfile process counts, as all=1,web=2")
+	applyCmd.Flags().IntVarP(&applyFlags.Port, "port", "p", 0, "base port of procfile processes")
+	applyCmd.Flags().BoolVar(&applyFlags.DryRun, "dry-run", false, "print the planned changes without applying them")
+	applyCmd.Flags().BoolVar(&applyFlags.DryRun, "plan", false, "alias for --dry-run")
+	applyCmd.Flags().BoolVar(&applyFlags.Rollback, "rollback", false, "undo applied changes if applying fails")
+	applyCmd.Flags().BoolVar(&applyFlags.Watch, "watch", false, "re-apply whenever the manifest changes")
 }
 
 var applyFlags struct {
-	Format string
+	Format    string
+	Vars      []string
+	Profiles  []string
+	Files     []string
+	Formation string
+	Port      int
+	DryRun    bool
+	Rollback  bool
+	Watch     bool
 }
 
 var applyCmd = &cobra.Command{
@@ -40,10 +58,52 @@ var applyCmd = &cobra.Command{
 	  docker-compose.yaml
 	  docker-compose.yml
 	
-	The expected procfile name 'Procfile'.
+	The expected procfile name is 'Procfile', falling back to 'Procfile.dev'. As
+	with foreman, a '.foreman' file in the current directory may name a
+	different procfile.
 	
 	If a manifest format will be guessed from the manifest filename.  This can be
-	overidden explicitly with the --format flag.`,
+	overidden explicitly with the --format flag.
+
+	Variables declared by an exo manifest may be set with --var NAME=VALUE. If
+	not set explicitly, a variable takes its value from an entry with the same
+	name in the workspace's .env file, if any, and then from its default.
+
+	Components may be assigned to profiles in their "_" meta block. Such
+	components are only applied when one of their profiles is activated with
+	--profile, or when an applied component depends on them.
+
+	The manifest file may also be given with --file (-f). As with docker-compose,
+	--file may be repeated to merge multiple compose files, with later files
+	overriding earlier ones. When a single compose file is used, an override
+	file next to it, such as 'docker-compose.override.yml', is merged in
+	automatically.
+
+	Procfiles are run as by foreman. The number of instances of each process
+	type is set with --formation (-m), such as 'all=1,web=2'. Instances are
+	named 'web', 'web-2', and so on. Each process type is assigned ports 100
+	apart, starting from --port (-p), or 5000, with each instance of a type
+	incrementing the port by one. Defaults for these options, and additional
+	env files, are read from a '.foreman' file next to the Procfile.
+
+	Only components that changed are affected. Components whose spec or
+	dependencies changed are updated in place, keeping their IDs and logs; for
+	example, a process is restarted with its new spec. Components whose type
+	changed are replaced, along with the components that depend on them. With
+	--dry-run (or --plan), the planned changes and spec diffs are printed, but
+	nothing is applied.
+
+	Changes are applied in stages, following component dependencies. Applying
+	stops after the first stage in which a change fails; the changes of later
+	stages are reported as skipped. With --rollback, changes that were already
+	made are then undone, re-creating deleted and replaced components from
+	their prior specs.
+
+	With --watch, the daemon watches the manifest, the workspace's .env file and
+	env files used by the manifest, and re-applies the manifest with the same
+	flags whenever they change. If the changed manifest is invalid, its
+	diagnostics are reported as workspace events and nothing is changed.
+	Watching continues until 'exo workspace unwatch'.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := newContext()
@@ -58,21 +118,42 @@ var applyCmd = &cobra.Command{
 }
 
 func apply(ctx context.Context, kernel api.Kernel, workspace api.Workspace, args []string) error {
+	vars, err := parseVarFlags(applyFlags.Vars)
+	if err != nil {
+		return err
+	}
 	input := &api.ApplyInput{
-		Format: applyFlags.Format,
+		Format:    applyFlags.Format,
+		Variables: vars,
+		Profiles:  applyFlags.Profiles,
+		Formation: applyFlags.Formation,
+		DryRun:    applyFlags.DryRun,
+		Rollback:  applyFlags.Rollback,
+		Watch:     applyFlags.Watch,
 	}
-	if len(args) > 0 {
-		manifestPath := args[0]
-		input.ManifestPath = &manifestPath
-
+	if applyFlags.Port != 0 {
+		input.BasePort = &applyFlags.Port
+	}
+	files := append(append([]string{}, args...), applyFlags.Files...)
+	if len(files) > 0 {
 		// We're not necessarily in the workspace root here,
 		// so send the file contents too.
-		bs, err := ioutil.ReadFile(manifestPath)
+		manifest, err := readManifestFile(files[0])
 		if err != nil {
-			return fmt.Errorf("reading manifest file: %w", err)
+			return err
+		}
+		input.ManifestPath = &manifest.Path
+		input.Manifest = manifest.Content
+	}
+	if len(files) > 1 {
+		input.Overrides = make([]api.ManifestFile, len(files)-1)
+		for i, file := range files[1:] {
+			override, err := readManifestFile(file)
+			if err != nil {
+				return err
+			}
+			input.Overrides[i] = *override
 		}
-		s := string(bs)
-		input.Manifest = &s
 	}
 
 	output, err := workspace.Apply(ctx, input)
@@ -84,5 +165,24 @@ func apply(ctx context.Context, kernel api.Kernel, workspace api.Workspace, args
 	if err != nil {
 		return err
 	}
+	if applyFlags.DryRun {
+		return writePlan(os.Stdout, output.Plan)
+	}
 	return watchJob(ctx, kernel, output.JobID)
 }
+
+func readManifestFile(name string) (*api.ManifestFile, error) {
+	manifestPath, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest file: %w", err)
+	}
+	content := string(bs)
+	return &api.ManifestFile{
+		Path:    manifestPath,
+		Content: &content,
+	}, nil
+}
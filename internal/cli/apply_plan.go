@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+var planSymbols = map[string]string{
+	"create":  "+",
+	"update":  "~",
+	"replace": "-/+",
+	"delete":  "-",
+}
+
+// writePlan describes each planned change, followed by a diff of its spec.
+func writePlan(w io.Writer, plan []api.PlannedChange) error {
+	if len(plan) == 0 {
+		_, err := fmt.Fprintln(w, "No changes.")
+		return err
+	}
+	counts := make(map[string]int)
+	for _, change := range plan {
+		counts[change.Action]++
+		fmt.Fprintf(w, "%s %s %s %s", planSymbols[change.Action], change.Action, change.Type, change.Name)
+		if change.Reason != "" {
+			fmt.Fprintf(w, " (%s)", change.Reason)
+		}
+		fmt.Fprintln(w)
+
+		if change.Action == "update" {
+			oldDependsOn := strings.Join(change.OldDependsOn, ", ")
+			newDependsOn := strings.Join(change.NewDependsOn, ", ")
+			if oldDependsOn != newDependsOn {
+				fmt.Fprintf(w, "    depends_on: [%s] -> [%s]\n", oldDependsOn, newDependsOn)
+			}
+		}
+		newSpec := change.NewSpec
+		if newSpec == "" && change.Action != "delete" {
+			newSpec = "(known after apply)\n"
+		}
+		if err := writeSpecDiff(w, change.OldSpec, newSpec); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to replace, %d to delete.\n",
+		counts["create"], counts["update"], counts["replace"], counts["delete"])
+	return err
+}
+
+// writeSpecDiff writes an indented diff between two specs.
+func writeSpecDiff(w io.Writer, oldSpec, newSpec string) error {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:       specLines(oldSpec),
+		B:       specLines(newSpec),
+		Context: 3,
+	})
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" || strings.HasPrefix(line, "@@") {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "    %s", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// specLines splits a spec in to lines for diffing. JSON specs are indented
+// first, since they are otherwise on a single line.
+func specLines(spec string) []string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(spec), "", "  "); err == nil {
+		spec = buf.String()
+	}
+	if spec == "" {
+		return nil
+	}
+	if !strings.HasSuffix(spec, "\n") {
+		spec += "\n"
+	}
+	lines := strings.SplitAfter(spec, "\n")
+	return lines[:len(lines)-1]
+}
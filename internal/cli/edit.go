@@ -42,8 +42,9 @@ var editCmd = &cobra.Command{
 		newSpec, err := term.EditString("spec.*", oldSpec) // TODO: Correct file extension.
 
 		output, err := workspace.UpdateComponent(ctx, &api.UpdateComponentInput{
-			Ref:  component.ID,
-			Spec: newSpec,
+			Ref:    component.ID,
+			Spec:   newSpec,
+			Source: "edit",
 		})
 		// TODO: This should handle a job id for the update step.
 		return watchJob(ctx, kernel, output.JobID)
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history <component>",
+	Short: "Shows the history of a component's spec",
+	Long: `Shows each revision of a component's spec, oldest first, with the time and
+source of the change and a diff against the previous revision.
+
+Sources are apply, edit, api, adopt, import and rollback. Revisions recorded
+before spec history was tracked have no source.
+
+See 'exo rollback' to restore an earlier revision.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		output, err := workspace.DescribeSpecHistory(ctx, &api.DescribeSpecHistoryInput{
+			Ref: args[0],
+		})
+		if err != nil {
+			return err
+		}
+		return writeSpecHistory(os.Stdout, output.Revisions)
+	},
+}
+
+func writeSpecHistory(w io.Writer, revisions []api.SpecRevision) error {
+	prevSpec := ""
+	for i, revision := range revisions {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		source := revision.Source
+		if source == "" {
+			source = "unknown"
+		}
+		fmt.Fprintf(w, "revision %d  %s  %s\n", revision.Revision, revision.Timestamp, source)
+		if err := writeSpecDiff(w, prevSpec, revision.Spec); err != nil {
+			return err
+		}
+		prevSpec = revision.Spec
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	jobCmd.AddCommand(jobCancelCmd)
+}
+
+var jobCancelCmd = &cobra.Command{
+	Use:   "cancel <job-id>",
+	Short: "Cancels a running job",
+	Long: `Cancels a running job. Its tasks, including any in-progress builds, image
+pulls and graceful shutdowns, are stopped as soon as possible and reported as
+canceled.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		kernel := cl.Kernel()
+
+		if _, err := kernel.CancelJob(ctx, &api.CancelJobInput{
+			JobID: args[0],
+		}); err != nil {
+			return fmt.Errorf("canceling job: %w", err)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aybabtme/rgbterm"
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	jobCmd.AddCommand(jobLogsCmd)
+	jobLogsCmd.Flags().BoolVarP(&jobLogsFlags.Follow, "follow", "f", false, "keep printing output until the job finishes")
+	jobLogsCmd.Flags().StringArrayVar(&jobLogsFlags.Tasks, "task", nil, "only print output of the given task ID; may be repeated")
+}
+
+var jobLogsFlags struct {
+	Follow bool
+	Tasks  []string
+}
+
+var jobLogsCmd = &cobra.Command{
+	Use:   "logs <job-id>",
+	Short: "Prints the output of a job's tasks",
+	Long: `Prints the output of a job's tasks, such as Docker build logs and errors,
+with each line labeled by the task that wrote it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		kernel := cl.Kernel()
+
+		return printJobOutput(ctx, kernel, args[0], jobLogsFlags.Tasks, jobLogsFlags.Follow)
+	},
+}
+
+func printJobOutput(ctx context.Context, kernel api.Kernel, jobID string, taskIDs []string, follow bool) error {
+	colors := NewColorCache()
+	labels := make(map[string]string)
+	labelWidth := 0
+	finished := false
+	describeJob := func() error {
+		output, err := kernel.DescribeTasks(ctx, &api.DescribeTasksInput{
+			JobIDs: []string{jobID},
+		})
+		if err != nil {
+			return fmt.Errorf("describing tasks: %w", err)
+		}
+		for _, task := range output.Tasks {
+			labels[task.ID] = task.Name
+			if labelWidth < len(task.Name) {
+				labelWidth = len(task.Name)
+			}
+			if task.ID == jobID {
+				finished = task.Finished != nil
+			}
+		}
+		return nil
+	}
+	if err := describeJob(); err != nil {
+		return err
+	}
+
+	limit := 500
+	input := &api.GetTaskOutputInput{
+		JobID:   jobID,
+		TaskIDs: taskIDs,
+		Next:    &limit,
+	}
+	for {
+		output, err := kernel.GetTaskOutput(ctx, input)
+		if err != nil {
+			return fmt.Errorf("getting task output: %w", err)
+		}
+		for _, event := range output.Items {
+			if _, ok := labels[event.Stream]; !ok {
+				// Output of a task created since the job was last described.
+				if err := describeJob(); err != nil {
+					return err
+				}
+			}
+			r, g, b := colors.Color(event.Stream).RGB255()
+			label := rgbterm.FgString(fmt.Sprintf("%*s", labelWidth, labels[event.Stream]), r, g, b)
+			fmt.Printf("%s %s%s\n", label, event.Message, termReset)
+		}
+		input.Cursor = output.NextCursor
+		if len(output.Items) == limit {
+			continue
+		}
+
+		// Having caught up, stop unless following a job that has yet to finish.
+		// Output written before the job finished is read once more after it has.
+		if !follow || finished {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(250 * time.Millisecond):
+		}
+		if err := describeJob(); err != nil {
+			return err
+		}
+	}
+}
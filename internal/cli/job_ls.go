@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/deref/exo/internal/chrono"
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	jobCmd.AddCommand(jobLsCmd)
+	jobLsCmd.Flags().BoolVar(&jobLsFlags.All, "all", false, "list jobs of all workspaces")
+	jobLsCmd.Flags().StringArrayVar(&jobLsFlags.Statuses, "status", nil, "filter by status; may be repeated")
+	jobLsCmd.Flags().DurationVar(&jobLsFlags.Since, "since", 24*time.Hour, "list jobs created within this duration")
+	jobLsCmd.Flags().IntVar(&jobLsFlags.Limit, "limit", 20, "maximum number of jobs to list")
+}
+
+var jobLsFlags struct {
+	All      bool
+	Statuses []string
+	Since    time.Duration
+	Limit    int
+}
+
+var jobLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "Lists recent jobs",
+	Long: `Lists recent jobs, oldest first.
+
+By default, only jobs of the current workspace are listed. Use --all to list
+the jobs of every workspace, as well as jobs not associated with a workspace.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		kernel := cl.Kernel()
+
+		input := &api.DescribeTasksInput{
+			JobsOnly:     true,
+			Statuses:     jobLsFlags.Statuses,
+			CreatedAfter: chrono.IsoNano(chrono.Now(ctx).Add(-jobLsFlags.Since)),
+		}
+		if jobLsFlags.Limit > 0 {
+			input.Limit = &jobLsFlags.Limit
+		}
+		if !jobLsFlags.All {
+			workspace := requireCurrentWorkspace(ctx, cl)
+			input.WorkspaceID = workspace.ID()
+		}
+		output, err := kernel.DescribeTasks(ctx, input)
+		if err != nil {
+			return fmt.Errorf("describing tasks: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
+		for _, job := range output.Tasks {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", job.ID, job.Name, job.Status, job.Created, job.Message)
+		}
+		_ = w.Flush()
+		return nil
+	},
+}
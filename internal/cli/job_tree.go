@@ -137,6 +137,10 @@ func (jp *jobPrinter) printTree(w io.Writer, tasks []api.TaskDescription) {
 				prefix += rgbterm.FgString("✓", 28, 196, 22)
 			case taskapi.StatusFailure:
 				prefix += rgbterm.FgString("⨯", 215, 55, 30)
+			case taskapi.StatusSkipped:
+				prefix += rgbterm.FgString("-", 128, 128, 128)
+			case taskapi.StatusCanceled:
+				prefix += rgbterm.FgString("⊘", 215, 135, 30)
 			case taskapi.StatusRunning:
 				if len(jp.Spinner) > 0 {
 					offset := jp.Iteration + idx
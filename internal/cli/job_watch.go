@@ -96,6 +96,8 @@ loop:
 		} else {
 			return fmt.Errorf("job failure: %s", job.Message)
 		}
+	case taskapi.StatusCanceled:
+		return errors.New("job canceled")
 	case taskapi.StatusSuccess:
 		return nil
 	default:
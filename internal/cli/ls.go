@@ -12,10 +12,12 @@ import (
 func init() {
 	rootCmd.AddCommand(lsCmd)
 	lsCmd.Flags().StringArrayVar(&lsFlags.Types, "type", nil, "filter by type")
+	lsCmd.Flags().BoolVar(&lsFlags.IncludeDeleted, "include-deleted", false, "also list deleted components, with the time of deletion")
 }
 
 var lsFlags struct {
-	Types []string
+	Types          []string
+	IncludeDeleted bool
 }
 
 var lsCmd = &cobra.Command{
@@ -29,14 +31,19 @@ var lsCmd = &cobra.Command{
 		cl := newClient()
 		workspace := requireCurrentWorkspace(ctx, cl)
 		output, err := workspace.DescribeComponents(ctx, &api.DescribeComponentsInput{
-			Types: lsFlags.Types,
+			Types:          lsFlags.Types,
+			IncludeDeleted: lsFlags.IncludeDeleted,
 		})
 		if err != nil {
 			return err
 		}
 		w := tabwriter.NewWriter(os.Stdout, 4, 8, 3, ' ', 0)
 		for _, component := range output.Components {
-			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", component.Name, component.ID, component.Type)
+			if lsFlags.IncludeDeleted {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", component.Name, component.ID, component.Type, component.Deleted)
+			} else {
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", component.Name, component.ID, component.Type)
+			}
 		}
 		_ = w.Flush()
 		return nil
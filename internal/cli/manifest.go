@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"path/filepath"
+	"strings"
 
 	"github.com/deref/exo/internal/manifest"
 	"github.com/deref/exo/internal/manifest/exohcl"
@@ -17,10 +19,12 @@ func init() {
 	rootCmd.AddCommand(manifestCmd)
 	manifestCmd.AddCommand(makeHelpSubcmd())
 	manifestCmd.PersistentFlags().StringVar(&manifestFlags.Format, "format", "", "exo, compose, procfile")
+	manifestCmd.PersistentFlags().StringArrayVar(&manifestFlags.Vars, "var", nil, "set a manifest variable, as NAME=VALUE")
 }
 
 var manifestFlags struct {
 	Format string
+	Vars   []string
 }
 
 var manifestCmd = &cobra.Command{
@@ -37,15 +41,40 @@ func loadManifest(name string) (*exohcl.Manifest, error) {
 		return nil, fmt.Errorf("reading: %w", err)
 	}
 
+	vars, err := parseVarFlags(manifestFlags.Vars)
+	if err != nil {
+		return nil, err
+	}
+
 	loader := &manifest.Loader{
 		WorkspaceName: "unnamed",
 		Format:        manifestFlags.Format,
 		Filename:      name,
 		Bytes:         bs,
+		Variables:     vars,
+	}
+	if err := loader.LoadEnvironment(filepath.Dir(name)); err != nil {
+		return nil, err
 	}
 	return loader.Load()
 }
 
+// Parses repeated --var NAME=VALUE flags.
+func parseVarFlags(flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return nil, nil
+	}
+	vars := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		parts := strings.SplitN(flag, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("expected --var NAME=VALUE, got %q", flag)
+		}
+		vars[parts[0]] = parts[1]
+	}
+	return vars, nil
+}
+
 func writeManifestError(w io.Writer, err error) error {
 	var diags hcl.Diagnostics
 	if !errors.As(err, &diags) {
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	manifestCmd.AddCommand(manifestExportCmd)
+}
+
+var manifestExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports the current workspace's components as a manifest.",
+	Long: `Exports the components of the current workspace, their specs and their
+dependencies, as a manifest written to standard out.
+
+The format is selected with --format and defaults to exo. Components, or
+parts of components, that cannot be represented in the format are omitted
+with a warning.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		output, err := workspace.ExportManifest(ctx, &api.ExportManifestInput{
+			Format: manifestFlags.Format,
+		})
+		if err != nil {
+			return err
+		}
+		for _, warning := range output.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+		_, err = fmt.Fprint(os.Stdout, output.Manifest)
+		return err
+	},
+}
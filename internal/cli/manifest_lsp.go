@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"os"
+
+	"github.com/deref/exo/internal/manifest/lsp"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	manifestCmd.AddCommand(manifestLSPCmd)
+}
+
+var manifestLSPCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Runs a language server for exo.hcl manifests.",
+	Long: `Runs a Language Server Protocol server over stdin and stdout.
+
+The server publishes manifest diagnostics as documents are edited, completes
+block types and component spec attributes, shows documentation for attributes
+on hover, resolves depends_on names to their component declarations and
+formats documents.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		vars, err := parseVarFlags(manifestFlags.Vars)
+		if err != nil {
+			return err
+		}
+		srv := &lsp.Server{
+			Variables: vars,
+		}
+		return srv.Serve(newContext(), os.Stdin, os.Stdout)
+	},
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/deref/exo/internal/manifest"
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	manifestCmd.AddCommand(manifestUpgradeCmd)
+	manifestUpgradeCmd.Flags().BoolVar(&manifestUpgradeFlags.Check, "check", false, "fail if the manifest is not up to date, without rewriting it")
+}
+
+var manifestUpgradeFlags struct {
+	Check bool
+}
+
+var manifestUpgradeCmd = &cobra.Command{
+	Use:   "upgrade <manifest>",
+	Short: "Upgrades an exo manifest to the latest format version.",
+	Long: fmt.Sprintf(`Rewrites an exo manifest in place to the latest format version, %q.
+
+Long-form component blocks are converted to the equivalent shorthand blocks,
+which are the preferred style, and the manifest is formatted. Comments and the
+order of declarations are preserved.
+
+With --check, the manifest is not modified. Instead, the command fails if the
+manifest is not up to date, which is useful in CI.`, exohcl.Latest),
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		format := manifestFlags.Format
+		if format == "" {
+			format = manifest.GuessFormat(name)
+		}
+		if format != "" && format != "exo" {
+			return fmt.Errorf("only exo manifests can be upgraded, got %q", name)
+		}
+
+		bs, err := ioutil.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("reading: %w", err)
+		}
+		upgraded, diags := exohcl.Upgrade(name, bs)
+		if diags.HasErrors() {
+			if err := writeManifestError(os.Stderr, diags); err != nil {
+				return err
+			}
+			return errors.New("cannot upgrade invalid manifest")
+		}
+		if bytes.Equal(bs, upgraded) {
+			return nil
+		}
+		if manifestUpgradeFlags.Check {
+			return fmt.Errorf("%s is not up to date; run `exo manifest upgrade %s`", name, name)
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(name, upgraded, info.Mode()); err != nil {
+			return fmt.Errorf("writing: %w", err)
+		}
+		return nil
+	},
+}
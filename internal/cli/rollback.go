@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(rollbackCmd)
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <component> [revision]",
+	Short: "Restores an earlier spec of a component",
+	Long: `Updates a component to the spec of an earlier revision, as listed by
+'exo history'. If no revision is given, the revision before the current one
+is restored.
+
+The component is updated in place, as with 'exo edit', and the rollback is
+recorded as a new revision.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		input := &api.RollbackComponentInput{
+			Ref: args[0],
+		}
+		if len(args) > 1 {
+			revision, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid revision: %q", args[1])
+			}
+			input.Revision = &revision
+		}
+
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		kernel := cl.Kernel()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		output, err := workspace.RollbackComponent(ctx, input)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolling back to revision %d.\n", output.Revision)
+		return watchJob(ctx, kernel, output.JobID)
+	},
+}
@@ -15,6 +15,13 @@ import (
 func init() {
 	rootCmd.AddCommand(runCmd)
 	runCmd.Flags().StringVar(&applyFlags.Format, "format", "", "see `exo help apply`")
+	runCmd.Flags().StringArrayVar(&applyFlags.Vars, "var", nil, "see `exo help apply`")
+	runCmd.Flags().StringSliceVar(&applyFlags.Profiles, "profile", nil, "see `exo help apply`")
+	runCmd.Flags().StringArrayVarP(&applyFlags.Files, "file", "f", nil, "see `exo help apply`")
+	runCmd.Flags().StringVarP(&applyFlags.Formation, "formation", "m", "", "see `exo help apply`")
+	runCmd.Flags().IntVarP(&applyFlags.Port, "port", "p", 0, "see `exo help apply`")
+	runCmd.Flags().BoolVar(&applyFlags.Rollback, "rollback", false, "see `exo help apply`")
+	runCmd.Flags().BoolVar(&applyFlags.Watch, "watch", false, "see `exo help apply`")
 }
 
 var runFlags struct {
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(undeleteCmd)
+}
+
+var undeleteCmd = &cobra.Command{
+	Use:   "undelete <ref> [ref ...]",
+	Short: "Restore deleted components",
+	Long: `Restores components that were removed with 'exo rm', or deleted by
+'exo apply', and re-initializes them from their last specs. Components keep
+their IDs, and so their logs.
+
+Deleted components are kept for a while, as configured by tombstoneRetention
+in the [components] section of the exo config file. They are listed by
+'exo ls --include-deleted'. If several deleted components share a name, the
+most recently deleted is restored.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		kernel := cl.Kernel()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		output, err := workspace.UndeleteComponents(ctx, &api.UndeleteComponentsInput{
+			Refs: args,
+		})
+		if err != nil {
+			return err
+		}
+		return watchJob(ctx, kernel, output.JobID)
+	},
+}
@@ -43,6 +43,8 @@ If no subcommand is given, describes the current workspace.`,
 		_, _ = fmt.Fprintf(w, "id:\t%s\n", desc.ID)
 		_, _ = fmt.Fprintf(w, "path:\t%s\n", desc.Root)
 		_, _ = fmt.Fprintf(w, "display-name:\t%s\n", desc.DisplayName)
+		_, _ = fmt.Fprintf(w, "watching:\t%t\n", desc.Watching)
+		_, _ = fmt.Fprintf(w, "drift-policy:\t%s\n", desc.DriftPolicy)
 		_ = w.Flush()
 		return nil
 	},
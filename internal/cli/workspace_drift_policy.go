@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	workspaceCmd.AddCommand(workspaceDriftPolicyCmd)
+}
+
+var workspaceDriftPolicyCmd = &cobra.Command{
+	Use:   "drift-policy [report|repair|off]",
+	Short: "Shows or sets how drift of components is handled",
+	Long: `Shows or sets how drift of the current workspace's components is handled.
+
+The daemon periodically refreshes components in the background to detect
+drift, such as a container removed with 'docker rm' or replaced outside of
+exo. Containers and processes that merely exit are not drift. Policies are:
+
+  report  Record drift as workspace events. This is the default.
+  repair  Also dispose and re-initialize drifted components from their specs.
+  off     Do not refresh components in the background.
+
+Drift is also reported by 'exo refresh', regardless of policy.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"report", "repair", "off"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		if len(args) == 0 {
+			output, err := workspace.Describe(ctx, &api.DescribeInput{})
+			if err != nil {
+				return fmt.Errorf("describing workspace: %w", err)
+			}
+			fmt.Println(output.Description.DriftPolicy)
+			return nil
+		}
+		_, err := workspace.SetDriftPolicy(ctx, &api.SetDriftPolicyInput{
+			Policy: args[0],
+		})
+		return err
+	},
+}
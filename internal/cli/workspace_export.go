@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	workspaceCmd.AddCommand(workspaceExportCmd)
+	workspaceExportCmd.Flags().BoolVar(&workspaceExportFlags.Logs, "logs", false, "include recent logs")
+	workspaceExportCmd.Flags().IntVar(&workspaceExportFlags.LogLimit, "log-limit", 1000, "maximum number of log events to include")
+}
+
+var workspaceExportFlags struct {
+	Logs     bool
+	LogLimit int
+}
+
+var workspaceExportCmd = &cobra.Command{
+	Use:   "export [file]",
+	Short: "Exports the current workspace to an archive",
+	Long: `Exports what exo knows about the current workspace to a JSON archive, which
+can be restored on this or another machine with 'exo workspace import'.
+
+The archive includes each component's spec, dependencies and state, less
+references to resources that do not survive a move, such as process and
+container IDs. Vault configuration is included, as are the most recent
+workspace and component logs if --logs is given.
+
+The archive is written to the given file, or to standard out.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		output, err := workspace.Export(ctx, &api.ExportInput{
+			IncludeLogs: workspaceExportFlags.Logs,
+			LogLimit:    &workspaceExportFlags.LogLimit,
+		})
+		if err != nil {
+			return err
+		}
+
+		w := os.Stdout
+		if len(args) > 0 {
+			f, err := os.OpenFile(args[0], os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				return fmt.Errorf("creating archive file: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(output.Archive)
+	},
+}
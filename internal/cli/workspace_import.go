@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/util/cmdutil"
+	"github.com/deref/exo/internal/util/jsonutil"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	workspaceCmd.AddCommand(workspaceImportCmd)
+	workspaceImportCmd.Flags().StringVar(&workspaceImportFlags.Root, "root", "", "root of the workspace to import in to; defaults to the current directory")
+}
+
+var workspaceImportFlags struct {
+	Root string
+}
+
+var workspaceImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Recreates a workspace from an archive",
+	Long: `Recreates the components of a workspace exported with 'exo workspace export'.
+
+Components are imported in to the workspace of the current directory, or of
+--root. A workspace is created if there is none, and an existing workspace
+must not have any components. Paths under the exported workspace's root are remapped to the
+new root. Components are given new IDs and are initialized from their specs.
+
+The archive is read from the given file, or from standard in if the file
+is "-".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		kernel := cl.Kernel()
+
+		var archive api.WorkspaceArchive
+		var err error
+		if args[0] == "-" {
+			err = jsonutil.UnmarshalReader(os.Stdin, &archive)
+		} else {
+			if _, err := os.Stat(args[0]); err != nil {
+				return err
+			}
+			err = jsonutil.UnmarshalFile(args[0], &archive)
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+
+		root := workspaceImportFlags.Root
+		if root == "" {
+			root = cmdutil.MustGetwd()
+		}
+		root, err = filepath.Abs(root)
+		if err != nil {
+			return err
+		}
+		workspace, err := resolveWorkspace(ctx, cl, root)
+		if err != nil {
+			return fmt.Errorf("resolving workspace: %w", err)
+		}
+		if workspace == nil {
+			output, err := kernel.CreateWorkspace(ctx, &api.CreateWorkspaceInput{
+				Root: root,
+			})
+			if err != nil {
+				return fmt.Errorf("creating workspace: %w", err)
+			}
+			workspace = cl.GetWorkspace(output.ID)
+		}
+
+		output, err := workspace.Import(ctx, &api.ImportInput{
+			Archive: archive,
+		})
+		if err != nil {
+			return err
+		}
+		return watchJob(ctx, kernel, output.JobID)
+	},
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"github.com/deref/exo/internal/core/api"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	workspaceCmd.AddCommand(workspaceUnwatchCmd)
+}
+
+var workspaceUnwatchCmd = &cobra.Command{
+	Use:   "unwatch",
+	Short: "Stops re-applying the manifest when it changes",
+	Long: `Stops watching the manifest of the current workspace for changes, as
+started by 'exo apply --watch'. Components are left as they are.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := newContext()
+		checkOrEnsureServer()
+		cl := newClient()
+		workspace := requireCurrentWorkspace(ctx, cl)
+		_, err := workspace.Unwatch(ctx, &api.UnwatchInput{})
+		return err
+	},
+}
@@ -22,6 +22,24 @@ type LogConfig struct {
 	SyslogPort uint
 }
 
+type ComponentsConfig struct {
+	// How long deleted components are kept, such as "24h".
+	TombstoneRetention string `toml:"tombstoneRetention"`
+	// How often components are refreshed to detect drift, such as "30s".
+	DriftCheckInterval string `toml:"driftCheckInterval"`
+}
+
+type JobsConfig struct {
+	// How long finished jobs, and their tasks, are kept, such as "168h".
+	Retention string `toml:"retention"`
+	// Maximum number of components that a job operates on at once. Zero means
+	// no limit.
+	Concurrency int `toml:"concurrency"`
+	// Limits for particular operations, keyed by job name, such as "building".
+	// These override Concurrency.
+	OperationConcurrency map[string]int `toml:"operationConcurrency"`
+}
+
 type TelemetryConfig struct {
 	Disable bool
 }
@@ -38,10 +56,12 @@ type Config struct {
 	HTTPPort uint `toml:"httpPort"`
 	NoDaemon bool `toml:"noDaemon"`
 
-	Client    ClientConfig
-	GUI       GUIConfig `toml:"gui"`
-	Log       LogConfig
-	Telemetry TelemetryConfig
+	Client     ClientConfig
+	GUI        GUIConfig `toml:"gui"`
+	Log        LogConfig
+	Components ComponentsConfig
+	Jobs       JobsConfig
+	Telemetry  TelemetryConfig
 }
 
 func (c *Config) GetTokenClient() token.TokenClient {
@@ -123,4 +143,17 @@ func setDefaults(cfg *Config) {
 	if cfg.GUI.Port == 0 {
 		cfg.GUI.Port = 3000
 	}
+
+	// Components
+	if cfg.Components.TombstoneRetention == "" {
+		cfg.Components.TombstoneRetention = "24h"
+	}
+	if cfg.Components.DriftCheckInterval == "" {
+		cfg.Components.DriftCheckInterval = "30s"
+	}
+
+	// Jobs
+	if cfg.Jobs.Retention == "" {
+		cfg.Jobs.Retention = "168h"
+	}
 }
@@ -26,7 +26,12 @@ type Kernel interface {
 	Ping(context.Context, *PingInput) (*PingOutput, error)
 	// Gracefully shutdown the exo daemon.
 	Exit(context.Context, *ExitInput) (*ExitOutput, error)
+	// Returns matching tasks, ordered by creation time. Finished jobs are kept for the retention period set in the [jobs] section of the config file.
 	DescribeTasks(context.Context, *DescribeTasksInput) (*DescribeTasksOutput, error)
+	// Cancels an unfinished job. Its tasks stop as soon as possible and are reported as canceled.
+	CancelJob(context.Context, *CancelJobInput) (*CancelJobOutput, error)
+	// Returns lines of output written by the tasks of a job, in the order they were written.
+	GetTaskOutput(context.Context, *GetTaskOutputInput) (*GetTaskOutputOutput, error)
 	GetUserHomeDir(context.Context, *GetUserHomeDirInput) (*GetUserHomeDirOutput, error)
 	ReadDir(context.Context, *ReadDirInput) (*ReadDirOutput, error)
 }
@@ -116,12 +121,48 @@ type DescribeTasksInput struct {
 
 	// If supplied, filters tasks by job.
 	JobIDs []string `json:"jobIds"`
+	// If supplied, filters tasks by workspace.
+	WorkspaceID string `json:"workspaceId"`
+	// If supplied, filters tasks by status.
+	Statuses []string `json:"statuses"`
+	// If supplied, only tasks created at or after this time are returned.
+	CreatedAfter string `json:"createdAfter"`
+	// If supplied, only tasks created before this time are returned.
+	CreatedBefore string `json:"createdBefore"`
+	// If true, only root tasks are returned.
+	JobsOnly bool `json:"jobsOnly"`
+	// If supplied, only this many of the most recently created matching tasks are returned.
+	Limit *int `json:"limit"`
 }
 
 type DescribeTasksOutput struct {
 	Tasks []TaskDescription `json:"tasks"`
 }
 
+type CancelJobInput struct {
+	JobID string `json:"jobId"`
+}
+
+type CancelJobOutput struct {
+}
+
+type GetTaskOutputInput struct {
+	JobID string `json:"jobId"`
+	// If supplied, only output of these tasks is returned.
+	TaskIDs []string `json:"taskIds"`
+	// Output after this cursor is returned. If empty, output is returned from the start.
+	Cursor string `json:"cursor"`
+	// Maximum number of lines to return.
+	Next *int `json:"next"`
+}
+
+type GetTaskOutputOutput struct {
+
+	// Lines of output. The stream of each is the ID of the task that wrote it.
+	Items      []Event `json:"items"`
+	NextCursor string  `json:"nextCursor"`
+}
+
 type GetUserHomeDirInput struct {
 }
 
@@ -176,6 +217,12 @@ func BuildKernelMux(b *josh.MuxBuilder, factory func(req *http.Request) Kernel)
 	b.AddMethod("describe-tasks", func(req *http.Request) interface{} {
 		return factory(req).DescribeTasks
 	})
+	b.AddMethod("cancel-job", func(req *http.Request) interface{} {
+		return factory(req).CancelJob
+	})
+	b.AddMethod("get-task-output", func(req *http.Request) interface{} {
+		return factory(req).GetTaskOutput
+	})
 	b.AddMethod("get-user-home-dir", func(req *http.Request) interface{} {
 		return factory(req).GetUserHomeDir
 	})
@@ -202,8 +249,10 @@ type TaskDescription struct {
 	// ID of root task in this tree.
 	JobID    string  `json:"jobId"`
 	ParentID *string `json:"parentId"`
-	Name     string  `json:"name"`
-	Status   string  `json:"status"`
+	// Workspace that the job operates on. Empty for jobs not specific to a workspace.
+	WorkspaceID string `json:"workspaceId"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
 	// Most recent log message. Single-line of text.
 	Message  string        `json:"message"`
 	Created  string        `json:"created"`
@@ -59,13 +59,59 @@ interface "kernel" {
   }
 
   method "describe-tasks" {
+    doc = "Returns matching tasks, ordered by creation time. Finished jobs are kept for the retention period set in the [jobs] section of the config file."
+
     input "job-ids" "[]string" {
       doc = "If supplied, filters tasks by job."
     }
+    input "workspace-id" "string" {
+      doc = "If supplied, filters tasks by workspace."
+    }
+    input "statuses" "[]string" {
+      doc = "If supplied, filters tasks by status."
+    }
+    input "created-after" "string" {
+      doc = "If supplied, only tasks created at or after this time are returned."
+    }
+    input "created-before" "string" {
+      doc = "If supplied, only tasks created before this time are returned."
+    }
+    input "jobs-only" "bool" {
+      doc = "If true, only root tasks are returned."
+    }
+    input "limit" "*int" {
+      doc = "If supplied, only this many of the most recently created matching tasks are returned."
+    }
 
     output "tasks" "[]TaskDescription" {}
   }
 
+  method "cancel-job" {
+    doc = "Cancels an unfinished job. Its tasks stop as soon as possible and are reported as canceled."
+
+    input "job-id" "string" {}
+  }
+
+  method "get-task-output" {
+    doc = "Returns lines of output written by the tasks of a job, in the order they were written."
+
+    input "job-id" "string" {}
+    input "task-ids" "[]string" {
+      doc = "If supplied, only output of these tasks is returned."
+    }
+    input "cursor" "string" {
+      doc = "Output after this cursor is returned. If empty, output is returned from the start."
+    }
+    input "next" "*int" {
+      doc = "Maximum number of lines to return."
+    }
+
+    output "items" "[]Event" {
+      doc = "Lines of output. The stream of each is the ID of the task that wrote it."
+    }
+    output "next-cursor" "string" {}
+  }
+
   method "get-user-home-dir" {
     output "path" "string" {}
   }
@@ -96,6 +142,9 @@ struct "task-description" {
     doc = "ID of root task in this tree."
   }
   field "parent-id" "*string" {}
+  field "workspace-id" "string" {
+    doc = "Workspace that the job operates on. Empty for jobs not specific to a workspace."
+  }
   field "name" "string" {}
   field "status" "string" {}
   field "message" "string" {
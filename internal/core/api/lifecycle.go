@@ -11,6 +11,8 @@ import (
 
 type Lifecycle interface {
 	Initialize(context.Context, *InitializeInput) (*InitializeOutput, error)
+	// Applies a changed spec to an existing component, preserving its identity and as much of its state as possible.
+	Update(context.Context, *UpdateInput) (*UpdateOutput, error)
 	Refresh(context.Context, *RefreshInput) (*RefreshOutput, error)
 	Dispose(context.Context, *DisposeInput) (*DisposeOutput, error)
 }
@@ -22,11 +24,22 @@ type InitializeInput struct {
 type InitializeOutput struct {
 }
 
+type UpdateInput struct {
+	OldSpec string `json:"oldSpec"`
+	Spec    string `json:"spec"`
+}
+
+type UpdateOutput struct {
+}
+
 type RefreshInput struct {
 	Spec string `json:"spec"`
 }
 
 type RefreshOutput struct {
+
+	// Describes how the underlying resources differ from the component's spec or last known state, such as a container that was removed outside of exo. Empty if there is no drift.
+	Drift []string `json:"drift"`
 }
 
 type DisposeInput struct {
@@ -39,6 +52,9 @@ func BuildLifecycleMux(b *josh.MuxBuilder, factory func(req *http.Request) Lifec
 	b.AddMethod("initialize", func(req *http.Request) interface{} {
 		return factory(req).Initialize
 	})
+	b.AddMethod("update", func(req *http.Request) interface{} {
+		return factory(req).Update
+	})
 	b.AddMethod("refresh", func(req *http.Request) interface{} {
 		return factory(req).Refresh
 	})
@@ -4,8 +4,17 @@ interface "lifecycle" {
     input "spec" "string" {}
   }
 
+  method "update" {
+    doc = "Applies a changed spec to an existing component, preserving its identity and as much of its state as possible."
+    input "old-spec" "string" {}
+    input "spec" "string" {}
+  }
+
   method "refresh" {
     input "spec" "string" {}
+    output "drift" "[]string" {
+      doc = "Describes how the underlying resources differ from the component's spec or last known state, such as a container that was removed outside of exo. Empty if there is no drift."
+    }
   }
 
   method "dispose" {
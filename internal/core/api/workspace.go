@@ -90,6 +90,14 @@ type Workspace interface {
 	Destroy(context.Context, *DestroyInput) (*DestroyOutput, error)
 	// Performs creates, updates, refreshes, disposes, as needed.
 	Apply(context.Context, *ApplyInput) (*ApplyOutput, error)
+	// Stops watching the manifest for changes. See apply.
+	Unwatch(context.Context, *UnwatchInput) (*UnwatchOutput, error)
+	// Serializes this workspace's components, vault configuration and, optionally, recent logs, so that the workspace can be recreated elsewhere with import.
+	Export(context.Context, *ExportInput) (*ExportOutput, error)
+	// Recreates the components of an exported workspace in this workspace, which must not have any components. Components are given new IDs, paths under the exported workspace's root are remapped to this workspace's root, and the components are initialized from their specs.
+	Import(context.Context, *ImportInput) (*ImportOutput, error)
+	// Sets how drift, found when components are periodically refreshed in the background, is handled.
+	SetDriftPolicy(context.Context, *SetDriftPolicyInput) (*SetDriftPolicyOutput, error)
 	// Resolves a reference in to an ID.
 	Resolve(context.Context, *ResolveInput) (*ResolveOutput, error)
 	ResolveManifest(context.Context, *ResolveManifestInput) (*ResolveManifestOutput, error)
@@ -97,8 +105,14 @@ type Workspace interface {
 	DescribeComponents(context.Context, *DescribeComponentsInput) (*DescribeComponentsOutput, error)
 	// Creates a component and triggers an initialize lifecycle event.
 	CreateComponent(context.Context, *CreateComponentInput) (*CreateComponentOutput, error)
+	// Creates components for existing Docker resources, with specs reconstructed from them. The resources are managed from then on without being recreated.
+	AdoptComponents(context.Context, *AdoptComponentsInput) (*AdoptComponentsOutput, error)
 	// Replaces the spec on a component and triggers an update lifecycle event.
 	UpdateComponent(context.Context, *UpdateComponentInput) (*UpdateComponentOutput, error)
+	// Returns the revisions of a component's spec, oldest first. A revision is recorded whenever the spec changes.
+	DescribeSpecHistory(context.Context, *DescribeSpecHistoryInput) (*DescribeSpecHistoryOutput, error)
+	// Updates a component to the spec of an earlier revision. The rollback is recorded as a new revision.
+	RollbackComponent(context.Context, *RollbackComponentInput) (*RollbackComponentOutput, error)
 	RenameComponent(context.Context, *RenameComponentInput) (*RenameComponentOutput, error)
 	// Asycnhronously refreshes component state.
 	RefreshComponents(context.Context, *RefreshComponentsInput) (*RefreshComponentsOutput, error)
@@ -106,6 +120,8 @@ type Workspace interface {
 	DisposeComponents(context.Context, *DisposeComponentsInput) (*DisposeComponentsOutput, error)
 	// Asynchronously disposes components, then removes them from the manifest.
 	DeleteComponents(context.Context, *DeleteComponentsInput) (*DeleteComponentsOutput, error)
+	// Asynchronously restores deleted components from their tombstones and re-initializes them from their last specs. Refs may be IDs or names of tombstones. For names, the most recently deleted component is restored.
+	UndeleteComponents(context.Context, *UndeleteComponentsInput) (*UndeleteComponentsOutput, error)
 	GetComponentState(context.Context, *GetComponentStateInput) (*GetComponentStateOutput, error)
 	SetComponentState(context.Context, *SetComponentStateInput) (*SetComponentStateOutput, error)
 	// Returns pages of events for some set of streams. If `cursor` is specified, standard pagination behavior is used. Otherwise the cursor is assumed to represent the current tail of the stream.
@@ -118,6 +134,8 @@ type Workspace interface {
 	DescribeVolumes(context.Context, *DescribeVolumesInput) (*DescribeVolumesOutput, error)
 	DescribeNetworks(context.Context, *DescribeNetworksInput) (*DescribeNetworksOutput, error)
 	ExportProcfile(context.Context, *ExportProcfileInput) (*ExportProcfileOutput, error)
+	// Serializes the workspace's components as a manifest.
+	ExportManifest(context.Context, *ExportManifestInput) (*ExportManifestOutput, error)
 	// Read a file from disk.
 	ReadFile(context.Context, *ReadFileInput) (*ReadFileOutput, error)
 	// Writes a file to disk.
@@ -163,11 +181,63 @@ type ApplyInput struct {
 	ManifestPath *string `json:"manifestPath"`
 	// Contents of the manifest file. Not required if manifest-path is provided.
 	Manifest *string `json:"manifest"`
+	// Values for variables declared by the manifest. Take precedence over defaults and the workspace's .env file.
+	Variables map[string]string `json:"variables"`
+	// Components that declare profiles are only applied if one of their profiles is listed here, or if an applied component depends on them.
+	Profiles []string `json:"profiles"`
+	// Additional compose files merged in to the manifest, in order. If not provided, an override file next to the compose file is loaded automatically.
+	Overrides []ManifestFile `json:"overrides"`
+	// Number of instances of each Procfile process type, such as 'all=1,web=2'. Defaults to the formation in a .foreman file next to the Procfile.
+	Formation string `json:"formation"`
+	// PORT of the first Procfile process. Each process type is assigned ports 100 apart. Defaults to the port in a .foreman file, or 5000.
+	BasePort *int `json:"basePort"`
+	// If true, the plan is computed and returned, but not executed.
+	DryRun bool `json:"dryRun"`
+	// If true and applying fails, changes already made are undone. Deleted and replaced components are re-created from their prior specs.
+	Rollback bool `json:"rollback"`
+	// If true, the manifest, the workspace's .env file and env files referred to by the manifest are watched, and the manifest is re-applied with the same inputs whenever they change. The manifest is read from its path, so the manifest contents input is only used for this apply. Watching is a workspace setting that persists until unwatch is called.
+	Watch bool `json:"watch"`
 }
 
 type ApplyOutput struct {
 	Warnings []string `json:"warnings"`
-	JobID    string   `json:"jobId"`
+	// Changes to components needed to apply the manifest. Unchanged components are omitted.
+	Plan []PlannedChange `json:"plan"`
+	// Empty for dry runs.
+	JobID string `json:"jobId"`
+}
+
+type UnwatchInput struct {
+}
+
+type UnwatchOutput struct {
+}
+
+type ExportInput struct {
+	IncludeLogs bool `json:"includeLogs"`
+	// Maximum number of recent events to include if include-logs is true. Defaults to 1000.
+	LogLimit *int `json:"logLimit"`
+}
+
+type ExportOutput struct {
+	Archive WorkspaceArchive `json:"archive"`
+}
+
+type ImportInput struct {
+	Archive WorkspaceArchive `json:"archive"`
+}
+
+type ImportOutput struct {
+	JobID string `json:"jobId"`
+}
+
+type SetDriftPolicyInput struct {
+
+	// One of 'report', to record drift as workspace events, 'repair', to also re-initialize drifted components from their specs, or 'off', to not refresh components in the background.
+	Policy string `json:"policy"`
+}
+
+type SetDriftPolicyOutput struct {
 }
 
 type ResolveInput struct {
@@ -196,6 +266,8 @@ type DescribeComponentsInput struct {
 	IncludeDependencies bool `json:"includeDependencies"`
 	// If true, includes all components that depend on the filtered components.
 	IncludeDependents bool `json:"includeDependents"`
+	// If true, includes the tombstones of deleted components, which are kept for a while after deletion. See undelete-components.
+	IncludeDeleted bool `json:"includeDeleted"`
 }
 
 type DescribeComponentsOutput struct {
@@ -214,6 +286,20 @@ type CreateComponentOutput struct {
 	JobID string `json:"jobId"`
 }
 
+type AdoptComponentsInput struct {
+
+	// If provided, adopts the containers, networks and volumes labeled as belonging to this Docker Compose project.
+	Project string `json:"project"`
+	// Names or IDs of additional containers to adopt.
+	Containers []string `json:"containers"`
+}
+
+type AdoptComponentsOutput struct {
+
+	// Names of the created components.
+	Names []string `json:"names"`
+}
+
 type UpdateComponentInput struct {
 
 	// Refers to the component to be updated.
@@ -222,12 +308,35 @@ type UpdateComponentInput struct {
 	Name      string   `json:"name"`
 	Spec      string   `json:"spec"`
 	DependsOn []string `json:"dependsOn"`
+	// Recorded in the component's spec history if the spec changed. Defaults to 'api'.
+	Source string `json:"source"`
 }
 
 type UpdateComponentOutput struct {
 	JobID string `json:"jobId"`
 }
 
+type DescribeSpecHistoryInput struct {
+	Ref string `json:"ref"`
+}
+
+type DescribeSpecHistoryOutput struct {
+	Revisions []SpecRevision `json:"revisions"`
+}
+
+type RollbackComponentInput struct {
+	Ref string `json:"ref"`
+	// Defaults to the revision before the current one.
+	Revision *int `json:"revision"`
+}
+
+type RollbackComponentOutput struct {
+
+	// Revision whose spec was restored.
+	Revision int    `json:"revision"`
+	JobID    string `json:"jobId"`
+}
+
 type RenameComponentInput struct {
 
 	// Refers to the component to be renamed.
@@ -265,6 +374,14 @@ type DeleteComponentsOutput struct {
 	JobID string `json:"jobId"`
 }
 
+type UndeleteComponentsInput struct {
+	Refs []string `json:"refs"`
+}
+
+type UndeleteComponentsOutput struct {
+	JobID string `json:"jobId"`
+}
+
 type GetComponentStateInput struct {
 	Ref string `json:"ref"`
 }
@@ -358,6 +475,18 @@ type ExportProcfileOutput struct {
 	Procfile string `json:"procfile"`
 }
 
+type ExportManifestInput struct {
+
+	// One of exo, compose or procfile. Defaults to exo.
+	Format string `json:"format"`
+}
+
+type ExportManifestOutput struct {
+	Manifest string `json:"manifest"`
+	// Describes components, or parts of them, that could not be represented in the format.
+	Warnings []string `json:"warnings"`
+}
+
 type ReadFileInput struct {
 
 	// Relative to the workspace directory. May not traverse higher in the filesystem.
@@ -425,6 +554,18 @@ func BuildWorkspaceMux(b *josh.MuxBuilder, factory func(req *http.Request) Works
 	b.AddMethod("apply", func(req *http.Request) interface{} {
 		return factory(req).Apply
 	})
+	b.AddMethod("unwatch", func(req *http.Request) interface{} {
+		return factory(req).Unwatch
+	})
+	b.AddMethod("export", func(req *http.Request) interface{} {
+		return factory(req).Export
+	})
+	b.AddMethod("import", func(req *http.Request) interface{} {
+		return factory(req).Import
+	})
+	b.AddMethod("set-drift-policy", func(req *http.Request) interface{} {
+		return factory(req).SetDriftPolicy
+	})
 	b.AddMethod("resolve", func(req *http.Request) interface{} {
 		return factory(req).Resolve
 	})
@@ -437,9 +578,18 @@ func BuildWorkspaceMux(b *josh.MuxBuilder, factory func(req *http.Request) Works
 	b.AddMethod("create-component", func(req *http.Request) interface{} {
 		return factory(req).CreateComponent
 	})
+	b.AddMethod("adopt-components", func(req *http.Request) interface{} {
+		return factory(req).AdoptComponents
+	})
 	b.AddMethod("update-component", func(req *http.Request) interface{} {
 		return factory(req).UpdateComponent
 	})
+	b.AddMethod("describe-spec-history", func(req *http.Request) interface{} {
+		return factory(req).DescribeSpecHistory
+	})
+	b.AddMethod("rollback-component", func(req *http.Request) interface{} {
+		return factory(req).RollbackComponent
+	})
 	b.AddMethod("rename-component", func(req *http.Request) interface{} {
 		return factory(req).RenameComponent
 	})
@@ -452,6 +602,9 @@ func BuildWorkspaceMux(b *josh.MuxBuilder, factory func(req *http.Request) Works
 	b.AddMethod("delete-components", func(req *http.Request) interface{} {
 		return factory(req).DeleteComponents
 	})
+	b.AddMethod("undelete-components", func(req *http.Request) interface{} {
+		return factory(req).UndeleteComponents
+	})
 	b.AddMethod("get-component-state", func(req *http.Request) interface{} {
 		return factory(req).GetComponentState
 	})
@@ -485,6 +638,9 @@ func BuildWorkspaceMux(b *josh.MuxBuilder, factory func(req *http.Request) Works
 	b.AddMethod("export-procfile", func(req *http.Request) interface{} {
 		return factory(req).ExportProcfile
 	})
+	b.AddMethod("export-manifest", func(req *http.Request) interface{} {
+		return factory(req).ExportManifest
+	})
 	b.AddMethod("read-file", func(req *http.Request) interface{} {
 		return factory(req).ReadFile
 	})
@@ -503,6 +659,18 @@ type WorkspaceDescription struct {
 	ID          string `json:"id"`
 	Root        string `json:"root"`
 	DisplayName string `json:"displayName"`
+	// True if the manifest is re-applied when it changes. See apply.
+	Watching bool `json:"watching"`
+	// See set-drift-policy.
+	DriftPolicy string `json:"driftPolicy"`
+}
+
+type ManifestFile struct {
+
+	// May be relative to the workspace root.
+	Path string `json:"path"`
+	// Contents of the file. If not provided, the file is read from path.
+	Content *string `json:"content"`
 }
 
 type ComponentDescription struct {
@@ -513,6 +681,58 @@ type ComponentDescription struct {
 	State     string   `json:"state"`
 	Created   string   `json:"created"`
 	DependsOn []string `json:"dependsOn"`
+	// Time of deletion. Empty unless the component is a tombstone.
+	Deleted string `json:"deleted"`
+}
+
+type PlannedChange struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// One of create, update, replace or delete. Updated components keep their IDs and are changed in place by their provider. Replaced components are deleted and created anew.
+	Action  string `json:"action"`
+	Reason  string `json:"reason"`
+	OldSpec string `json:"oldSpec"`
+	// Empty if the spec refers to state of components that are yet to be created or updated.
+	NewSpec      string   `json:"newSpec"`
+	OldDependsOn []string `json:"oldDependsOn"`
+	NewDependsOn []string `json:"newDependsOn"`
+}
+
+type WorkspaceArchive struct {
+	Version     int                 `json:"version"`
+	WorkspaceID string              `json:"workspaceId"`
+	Root        string              `json:"root"`
+	Exported    string              `json:"exported"`
+	Components  []ArchivedComponent `json:"components"`
+	VaultUrls   []string            `json:"vaultUrls"`
+	Events      []ArchivedEvent     `json:"events"`
+}
+
+type ArchivedComponent struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Spec string `json:"spec"`
+	// State without references to resources that do not outlive the host, such as process or container IDs.
+	State     string   `json:"state"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+type ArchivedEvent struct {
+
+	// ID of the workspace or component that the event belongs to.
+	Stream    string            `json:"stream"`
+	Timestamp string            `json:"timestamp"`
+	Message   string            `json:"message"`
+	Tags      map[string]string `json:"tags"`
+}
+
+type SpecRevision struct {
+	Revision  int    `json:"revision"`
+	Timestamp string `json:"timestamp"`
+	// What changed the spec. One of 'apply', 'edit', 'api', 'adopt', 'import' or 'rollback'. Empty if unknown.
+	Source string `json:"source"`
+	Spec   string `json:"spec"`
 }
 
 type StreamDescription struct {
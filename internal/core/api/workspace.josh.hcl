@@ -63,11 +63,71 @@ interface "workspace" {
     input "manifest" "*string" {
       doc = "Contents of the manifest file. Not required if manifest-path is provided."
     }
+    input "variables" "map[string]string" {
+      doc = "Values for variables declared by the manifest. Take precedence over defaults and the workspace's .env file."
+    }
+    input "profiles" "[]string" {
+      doc = "Components that declare profiles are only applied if one of their profiles is listed here, or if an applied component depends on them."
+    }
+    input "overrides" "[]ManifestFile" {
+      doc = "Additional compose files merged in to the manifest, in order. If not provided, an override file next to the compose file is loaded automatically."
+    }
+    input "formation" "string" {
+      doc = "Number of instances of each Procfile process type, such as 'all=1,web=2'. Defaults to the formation in a .foreman file next to the Procfile."
+    }
+    input "base-port" "*int" {
+      doc = "PORT of the first Procfile process. Each process type is assigned ports 100 apart. Defaults to the port in a .foreman file, or 5000."
+    }
+    input "dry-run" "bool" {
+      doc = "If true, the plan is computed and returned, but not executed."
+    }
+    input "rollback" "bool" {
+      doc = "If true and applying fails, changes already made are undone. Deleted and replaced components are re-created from their prior specs."
+    }
+    input "watch" "bool" {
+      doc = "If true, the manifest, the workspace's .env file and env files referred to by the manifest are watched, and the manifest is re-applied with the same inputs whenever they change. The manifest is read from its path, so the manifest contents input is only used for this apply. Watching is a workspace setting that persists until unwatch is called."
+    }
 
     output "warnings" "[]string" {}
+    output "plan" "[]PlannedChange" {
+      doc = "Changes to components needed to apply the manifest. Unchanged components are omitted."
+    }
+    output "job-id" "string" {
+      doc = "Empty for dry runs."
+    }
+  }
+
+  method "unwatch" {
+    doc = "Stops watching the manifest for changes. See apply."
+  }
+
+  method "export" {
+    doc = "Serializes this workspace's components, vault configuration and, optionally, recent logs, so that the workspace can be recreated elsewhere with import."
+
+    input "include-logs" "bool" {}
+    input "log-limit" "*int" {
+      doc = "Maximum number of recent events to include if include-logs is true. Defaults to 1000."
+    }
+
+    output "archive" "WorkspaceArchive" {}
+  }
+
+  method "import" {
+    doc = "Recreates the components of an exported workspace in this workspace, which must not have any components. Components are given new IDs, paths under the exported workspace's root are remapped to this workspace's root, and the components are initialized from their specs."
+
+    input "archive" "WorkspaceArchive" {}
+
     output "job-id" "string" {}
   }
 
+  method "set-drift-policy" {
+    doc = "Sets how drift, found when components are periodically refreshed in the background, is handled."
+
+    input "policy" "string" {
+      doc = "One of 'report', to record drift as workspace events, 'repair', to also re-initialize drifted components from their specs, or 'off', to not refresh components in the background."
+    }
+  }
+
   method "resolve" {
     doc = "Resolves a reference in to an ID."
 
@@ -101,6 +161,10 @@ interface "workspace" {
       doc = "If true, includes all components that depend on the filtered components."
     }
 
+    input "include-deleted" "bool" {
+      doc = "If true, includes the tombstones of deleted components, which are kept for a while after deletion. See undelete-components."
+    }
+
     output "components" "[]ComponentDescription" {}
   }
 
@@ -116,6 +180,21 @@ interface "workspace" {
     output "job-id" "string" {}
   }
 
+  method "adopt-components" {
+    doc = "Creates components for existing Docker resources, with specs reconstructed from them. The resources are managed from then on without being recreated."
+
+    input "project" "string" {
+      doc = "If provided, adopts the containers, networks and volumes labeled as belonging to this Docker Compose project."
+    }
+    input "containers" "[]string" {
+      doc = "Names or IDs of additional containers to adopt."
+    }
+
+    output "names" "[]string" {
+      doc = "Names of the created components."
+    }
+  }
+
   method "update-component" {
     doc = "Replaces the spec on a component and triggers an update lifecycle event."
 
@@ -127,7 +206,32 @@ interface "workspace" {
     }
     input "spec" "string" {}
     input "depends-on" "[]string" {}
+    input "source" "string" {
+      doc = "Recorded in the component's spec history if the spec changed. Defaults to 'api'."
+    }
+
+    output "job-id" "string" {}
+  }
+
+  method "describe-spec-history" {
+    doc = "Returns the revisions of a component's spec, oldest first. A revision is recorded whenever the spec changes."
+
+    input "ref" "string" {}
+
+    output "revisions" "[]SpecRevision" {}
+  }
+
+  method "rollback-component" {
+    doc = "Updates a component to the spec of an earlier revision. The rollback is recorded as a new revision."
+
+    input "ref" "string" {}
+    input "revision" "*int" {
+      doc = "Defaults to the revision before the current one."
+    }
 
+    output "revision" "int" {
+      doc = "Revision whose spec was restored."
+    }
     output "job-id" "string" {}
   }
 
@@ -166,6 +270,14 @@ interface "workspace" {
     output "job-id" "string" {}
   }
 
+  method "undelete-components" {
+    doc = "Asynchronously restores deleted components from their tombstones and re-initializes them from their last specs. Refs may be IDs or names of tombstones. For names, the most recently deleted component is restored."
+
+    input "refs" "[]string" {}
+
+    output "job-id" "string" {}
+  }
+
   method "get-component-state" {
     input "ref" "string" {}
 
@@ -233,6 +345,18 @@ interface "workspace" {
     output "procfile" "string" {}
   }
 
+  method "export-manifest" {
+    doc = "Serializes the workspace's components as a manifest."
+
+    input "format" "string" {
+      doc = "One of exo, compose or procfile. Defaults to exo."
+    }
+    output "manifest" "string" {}
+    output "warnings" "[]string" {
+      doc = "Describes components, or parts of them, that could not be represented in the format."
+    }
+  }
+
   method "read-file" {
     doc = "Read a file from disk."
 
@@ -266,6 +390,21 @@ struct "workspace-description" {
   field "id" "string" {}
   field "root" "string" {}
   field "display-name" "string" {}
+  field "watching" "bool" {
+    doc = "True if the manifest is re-applied when it changes. See apply."
+  }
+  field "drift-policy" "string" {
+    doc = "See set-drift-policy."
+  }
+}
+
+struct "manifest-file" {
+  field "path" "string" {
+    doc = "May be relative to the workspace root."
+  }
+  field "content" "*string" {
+    doc = "Contents of the file. If not provided, the file is read from path."
+  }
 }
 
 struct "component-description" {
@@ -276,6 +415,63 @@ struct "component-description" {
   field "state" "string" {}
   field "created" "string" {}
   field "depends-on" "[]string" {}
+  field "deleted" "string" {
+    doc = "Time of deletion. Empty unless the component is a tombstone."
+  }
+}
+
+struct "planned-change" {
+  field "name" "string" {}
+  field "type" "string" {}
+  field "action" "string" {
+    doc = "One of create, update, replace or delete. Updated components keep their IDs and are changed in place by their provider. Replaced components are deleted and created anew."
+  }
+  field "reason" "string" {}
+  field "old-spec" "string" {}
+  field "new-spec" "string" {
+    doc = "Empty if the spec refers to state of components that are yet to be created or updated."
+  }
+  field "old-depends-on" "[]string" {}
+  field "new-depends-on" "[]string" {}
+}
+
+struct "workspace-archive" {
+  field "version" "int" {}
+  field "workspace-id" "string" {}
+  field "root" "string" {}
+  field "exported" "string" {}
+  field "components" "[]ArchivedComponent" {}
+  field "vault-urls" "[]string" {}
+  field "events" "[]ArchivedEvent" {}
+}
+
+struct "archived-component" {
+  field "id" "string" {}
+  field "name" "string" {}
+  field "type" "string" {}
+  field "spec" "string" {}
+  field "state" "string" {
+    doc = "State without references to resources that do not outlive the host, such as process or container IDs."
+  }
+  field "depends-on" "[]string" {}
+}
+
+struct "archived-event" {
+  field "stream" "string" {
+    doc = "ID of the workspace or component that the event belongs to."
+  }
+  field "timestamp" "string" {}
+  field "message" "string" {}
+  field "tags" "map[string]string" {}
+}
+
+struct "spec-revision" {
+  field "revision" "int" {}
+  field "timestamp" "string" {}
+  field "source" "string" {
+    doc = "What changed the spec. One of 'apply', 'edit', 'api', 'adopt', 'import' or 'rollback'. Empty if unknown."
+  }
+  field "spec" "string" {}
 }
 
 struct "stream-description" {
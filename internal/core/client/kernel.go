@@ -81,6 +81,16 @@ func (c *Kernel) DescribeTasks(ctx context.Context, input *api.DescribeTasksInpu
 	return
 }
 
+func (c *Kernel) CancelJob(ctx context.Context, input *api.CancelJobInput) (output *api.CancelJobOutput, err error) {
+	err = c.client.Invoke(ctx, "cancel-job", input, &output)
+	return
+}
+
+func (c *Kernel) GetTaskOutput(ctx context.Context, input *api.GetTaskOutputInput) (output *api.GetTaskOutputOutput, err error) {
+	err = c.client.Invoke(ctx, "get-task-output", input, &output)
+	return
+}
+
 func (c *Kernel) GetUserHomeDir(ctx context.Context, input *api.GetUserHomeDirInput) (output *api.GetUserHomeDirOutput, err error) {
 	err = c.client.Invoke(ctx, "get-user-home-dir", input, &output)
 	return
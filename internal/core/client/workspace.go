@@ -120,6 +120,26 @@ func (c *Workspace) Apply(ctx context.Context, input *api.ApplyInput) (output *a
 	return
 }
 
+func (c *Workspace) Unwatch(ctx context.Context, input *api.UnwatchInput) (output *api.UnwatchOutput, err error) {
+	err = c.client.Invoke(ctx, "unwatch", input, &output)
+	return
+}
+
+func (c *Workspace) Export(ctx context.Context, input *api.ExportInput) (output *api.ExportOutput, err error) {
+	err = c.client.Invoke(ctx, "export", input, &output)
+	return
+}
+
+func (c *Workspace) Import(ctx context.Context, input *api.ImportInput) (output *api.ImportOutput, err error) {
+	err = c.client.Invoke(ctx, "import", input, &output)
+	return
+}
+
+func (c *Workspace) SetDriftPolicy(ctx context.Context, input *api.SetDriftPolicyInput) (output *api.SetDriftPolicyOutput, err error) {
+	err = c.client.Invoke(ctx, "set-drift-policy", input, &output)
+	return
+}
+
 func (c *Workspace) Resolve(ctx context.Context, input *api.ResolveInput) (output *api.ResolveOutput, err error) {
 	err = c.client.Invoke(ctx, "resolve", input, &output)
 	return
@@ -140,11 +160,26 @@ func (c *Workspace) CreateComponent(ctx context.Context, input *api.CreateCompon
 	return
 }
 
+func (c *Workspace) AdoptComponents(ctx context.Context, input *api.AdoptComponentsInput) (output *api.AdoptComponentsOutput, err error) {
+	err = c.client.Invoke(ctx, "adopt-components", input, &output)
+	return
+}
+
 func (c *Workspace) UpdateComponent(ctx context.Context, input *api.UpdateComponentInput) (output *api.UpdateComponentOutput, err error) {
 	err = c.client.Invoke(ctx, "update-component", input, &output)
 	return
 }
 
+func (c *Workspace) DescribeSpecHistory(ctx context.Context, input *api.DescribeSpecHistoryInput) (output *api.DescribeSpecHistoryOutput, err error) {
+	err = c.client.Invoke(ctx, "describe-spec-history", input, &output)
+	return
+}
+
+func (c *Workspace) RollbackComponent(ctx context.Context, input *api.RollbackComponentInput) (output *api.RollbackComponentOutput, err error) {
+	err = c.client.Invoke(ctx, "rollback-component", input, &output)
+	return
+}
+
 func (c *Workspace) RenameComponent(ctx context.Context, input *api.RenameComponentInput) (output *api.RenameComponentOutput, err error) {
 	err = c.client.Invoke(ctx, "rename-component", input, &output)
 	return
@@ -165,6 +200,11 @@ func (c *Workspace) DeleteComponents(ctx context.Context, input *api.DeleteCompo
 	return
 }
 
+func (c *Workspace) UndeleteComponents(ctx context.Context, input *api.UndeleteComponentsInput) (output *api.UndeleteComponentsOutput, err error) {
+	err = c.client.Invoke(ctx, "undelete-components", input, &output)
+	return
+}
+
 func (c *Workspace) GetComponentState(ctx context.Context, input *api.GetComponentStateInput) (output *api.GetComponentStateOutput, err error) {
 	err = c.client.Invoke(ctx, "get-component-state", input, &output)
 	return
@@ -220,6 +260,11 @@ func (c *Workspace) ExportProcfile(ctx context.Context, input *api.ExportProcfil
 	return
 }
 
+func (c *Workspace) ExportManifest(ctx context.Context, input *api.ExportManifestInput) (output *api.ExportManifestOutput, err error) {
+	err = c.client.Invoke(ctx, "export-manifest", input, &output)
+	return
+}
+
 func (c *Workspace) ReadFile(ctx context.Context, input *api.ReadFileInput) (output *api.ReadFileOutput, err error) {
 	err = c.client.Invoke(ctx, "read-file", input, &output)
 	return
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/deref/exo/internal/chrono"
+	"github.com/deref/exo/internal/core/api"
+	state "github.com/deref/exo/internal/core/state/api"
+	"github.com/deref/exo/internal/gensym"
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/deref/exo/internal/providers/docker/components/container"
+	"github.com/deref/exo/internal/providers/docker/components/network"
+	"github.com/deref/exo/internal/providers/docker/components/volume"
+	"github.com/deref/exo/internal/util/errutil"
+	"github.com/deref/exo/internal/util/jsonutil"
+	"github.com/deref/exo/internal/util/yamlutil"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	dockerclient "github.com/docker/docker/client"
+)
+
+// adoption is a component to be created for an existing Docker resource.
+type adoption struct {
+	name      string
+	typ       string
+	spec      string
+	state     string
+	dependsOn []string
+	// Docker Compose services that an adopted container depends on.
+	dependsOnServices []string
+}
+
+// AdoptComponents creates components for existing Docker resources. Unlike
+// created components, adopted components are not initialized, since their
+// resources already exist. Instead, their state is taken from Docker.
+func (ws *Workspace) AdoptComponents(ctx context.Context, input *api.AdoptComponentsInput) (*api.AdoptComponentsOutput, error) {
+	if input.Project == "" && len(input.Containers) == 0 {
+		return nil, errutil.HTTPErrorf(http.StatusBadRequest, "a project or containers to adopt are required")
+	}
+
+	var adoptions []adoption
+	// Component names, keyed by Docker network and volume names.
+	networkComponents := make(map[string]string)
+	volumeComponents := make(map[string]string)
+	var containerIDs []string
+
+	if input.Project != "" {
+		projectFilter := filters.NewArgs(filters.KeyValuePair{
+			Key:   "label",
+			Value: docker.ComposeProjectLabel + "=" + input.Project,
+		})
+
+		networks, err := ws.Docker.NetworkList(ctx, types.NetworkListOptions{
+			Filters: projectFilter,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing networks: %w", err)
+		}
+		for _, inspection := range networks {
+			name := adoptedName(inspection.Labels[docker.ComposeNetworkLabel], inspection.Name)
+			spec, adoptedState := network.Adopt(inspection)
+			adoptions = append(adoptions, adoption{
+				name:  name,
+				typ:   "network",
+				spec:  yamlutil.MustMarshalString(spec),
+				state: jsonutil.MustMarshalString(adoptedState),
+			})
+			networkComponents[inspection.Name] = name
+		}
+
+		volumes, err := ws.Docker.VolumeList(ctx, projectFilter)
+		if err != nil {
+			return nil, fmt.Errorf("listing volumes: %w", err)
+		}
+		for _, inspection := range volumes.Volumes {
+			name := adoptedName(inspection.Labels[docker.ComposeVolumeLabel], inspection.Name)
+			spec, adoptedState := volume.Adopt(*inspection)
+			adoptions = append(adoptions, adoption{
+				name:  name,
+				typ:   "volume",
+				spec:  yamlutil.MustMarshalString(spec),
+				state: jsonutil.MustMarshalString(adoptedState),
+			})
+			volumeComponents[inspection.Name] = name
+		}
+
+		containers, err := ws.Docker.ContainerList(ctx, types.ContainerListOptions{
+			All:     true,
+			Filters: projectFilter,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing containers: %w", err)
+		}
+		for _, container := range containers {
+			containerIDs = append(containerIDs, container.ID)
+		}
+	}
+	containerIDs = append(containerIDs, input.Containers...)
+
+	var containerAdoptions []adoption
+	// Component names, keyed by Docker Compose service names.
+	serviceComponents := make(map[string]string)
+	seen := make(map[string]bool)
+	for _, ref := range containerIDs {
+		inspection, err := ws.Docker.ContainerInspect(ctx, ref)
+		if dockerclient.IsErrNotFound(err) {
+			return nil, errutil.HTTPErrorf(http.StatusNotFound, "container %q not found", ref)
+		} else if err != nil {
+			return nil, fmt.Errorf("inspecting container %q: %w", ref, err)
+		}
+		if seen[inspection.ID] {
+			continue
+		}
+		seen[inspection.ID] = true
+
+		imageInspection, _, err := ws.Docker.ImageInspectWithRaw(ctx, inspection.Image)
+		if err != nil && !dockerclient.IsErrNotFound(err) {
+			return nil, fmt.Errorf("inspecting image of container %q: %w", ref, err)
+		}
+
+		labels := inspection.Config.Labels
+		service := labels[docker.ComposeServiceLabel]
+		name := adoptedName(service, strings.TrimPrefix(inspection.Name, "/"))
+		if number := labels[docker.ComposeContainerNumberLabel]; service != "" && number != "" && number != "1" {
+			name = fmt.Sprintf("%s-%s", name, number)
+		} else if service != "" {
+			serviceComponents[service] = name
+		}
+
+		spec, adoptedState := container.Adopt(inspection, imageInspection)
+		var dependsOn []string
+		for _, item := range spec.Networks.Items {
+			if dependency, ok := networkComponents[item.Key]; ok {
+				dependsOn = append(dependsOn, dependency)
+			}
+		}
+		for _, mount := range spec.Volumes {
+			if dependency, ok := volumeComponents[mount.Source.Value]; ok && mount.Type.Value == "volume" {
+				dependsOn = append(dependsOn, dependency)
+			}
+		}
+		// Docker Compose records service dependencies in a label of the form
+		// "db:service_started:false,cache:service_healthy:false".
+		var dependsOnServices []string
+		if label := labels[docker.ComposeDependsOnLabel]; label != "" {
+			for _, dependency := range strings.Split(label, ",") {
+				dependsOnServices = append(dependsOnServices, strings.SplitN(dependency, ":", 2)[0])
+			}
+		}
+		containerAdoptions = append(containerAdoptions, adoption{
+			name:              name,
+			typ:               "container",
+			spec:              yamlutil.MustMarshalString(spec),
+			state:             jsonutil.MustMarshalString(adoptedState),
+			dependsOn:         dependsOn,
+			dependsOnServices: dependsOnServices,
+		})
+	}
+	// Services are resolved to components once all containers are known.
+	for i, adoption := range containerAdoptions {
+		for _, service := range adoption.dependsOnServices {
+			if dependency, ok := serviceComponents[service]; ok {
+				adoption.dependsOn = append(adoption.dependsOn, dependency)
+			}
+		}
+		sort.Strings(adoption.dependsOn)
+		containerAdoptions[i] = adoption
+	}
+	adoptions = append(adoptions, containerAdoptions...)
+
+	if len(adoptions) == 0 {
+		return nil, errutil.HTTPErrorf(http.StatusNotFound, "no resources of project %q found", input.Project)
+	}
+
+	// Check all names before creating any components.
+	existing, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("describing components: %w", err)
+	}
+	taken := make(map[string]bool, len(existing.Components)+len(adoptions))
+	for _, component := range existing.Components {
+		taken[component.Name] = true
+	}
+	for _, adoption := range adoptions {
+		if err := exohcl.ValidateName(adoption.name); err != nil {
+			return nil, errutil.HTTPErrorf(http.StatusBadRequest, "component name %q invalid: %w", adoption.name, err)
+		}
+		if taken[adoption.name] {
+			return nil, errutil.HTTPErrorf(http.StatusConflict, "component named %q already exists", adoption.name)
+		}
+		taken[adoption.name] = true
+	}
+
+	output := &api.AdoptComponentsOutput{
+		Names: make([]string, len(adoptions)),
+	}
+	for i, adoption := range adoptions {
+		id := gensym.RandomBase32()
+		if _, err := ws.Store.AddComponent(ctx, &state.AddComponentInput{
+			WorkspaceID: ws.ID,
+			ID:          id,
+			Name:        adoption.name,
+			Type:        adoption.typ,
+			Spec:        adoption.spec,
+			Created:     chrono.NowString(ctx),
+			DependsOn:   adoption.dependsOn,
+			SpecSource:  specSourceAdopt,
+		}); err != nil {
+			return nil, fmt.Errorf("adding component %q: %w", adoption.name, err)
+		}
+		if _, err := ws.Store.PatchComponent(ctx, &state.PatchComponentInput{
+			ID:    id,
+			State: adoption.state,
+		}); err != nil {
+			return nil, fmt.Errorf("recording state of %q: %w", adoption.name, err)
+		}
+		ws.logEventf(ctx, "adopted %s %s", adoption.typ, adoption.name)
+		output.Names[i] = adoption.name
+	}
+	return output, nil
+}
+
+// adoptedName returns the component name for an adopted resource, preferring
+// the key of the resource in its Docker Compose project, if any, over its
+// Docker name.
+func adoptedName(composeKey, dockerName string) string {
+	if composeKey != "" {
+		return exohcl.MangleName(composeKey)
+	}
+	return exohcl.MangleName(dockerName)
+}
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/deref/exo/internal/chrono"
+	"github.com/deref/exo/internal/core/api"
+	state "github.com/deref/exo/internal/core/state/api"
+	eventd "github.com/deref/exo/internal/eventd/api"
+	"github.com/deref/exo/internal/gensym"
+	"github.com/deref/exo/internal/providers/core/components/log"
+	"github.com/deref/exo/internal/util/errutil"
+)
+
+// workspaceArchiveVersion is incremented on incompatible changes to the
+// format of workspace archives.
+const workspaceArchiveVersion = 1
+
+const defaultExportLogLimit = 1000
+
+func (ws *Workspace) Export(ctx context.Context, input *api.ExportInput) (*api.ExportOutput, error) {
+	description, err := ws.describe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("describing components: %w", err)
+	}
+
+	archive := api.WorkspaceArchive{
+		Version:     workspaceArchiveVersion,
+		WorkspaceID: ws.ID,
+		Root:        description.Root,
+		Exported:    chrono.NowString(ctx),
+		Components:  make([]api.ArchivedComponent, len(describeOutput.Components)),
+		VaultUrls:   []string{},
+		Events:      []api.ArchivedEvent{},
+	}
+
+	for i, component := range describeOutput.Components {
+		componentState := component.State
+		ctrl := ws.newController(ctx, component)
+		if exporter, ok := ctrl.(StateExporter); ok {
+			if err := ctrl.InitResource(); err != nil {
+				return nil, fmt.Errorf("loading state of %q: %w", component.Name, err)
+			}
+			componentState, err = exporter.ExportState()
+			if err != nil {
+				return nil, fmt.Errorf("exporting state of %q: %w", component.Name, err)
+			}
+		}
+		archive.Components[i] = api.ArchivedComponent{
+			ID:        component.ID,
+			Name:      component.Name,
+			Type:      component.Type,
+			Spec:      component.Spec,
+			State:     componentState,
+			DependsOn: component.DependsOn,
+		}
+	}
+
+	vaultConfigs, err := ws.getVaultConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting vault configs: %w", err)
+	}
+	for _, vaultConfig := range vaultConfigs {
+		archive.VaultUrls = append(archive.VaultUrls, vaultConfig.url)
+	}
+
+	if input.IncludeLogs {
+		limit := defaultExportLogLimit
+		if input.LogLimit != nil {
+			limit = *input.LogLimit
+		}
+		eventsOutput, err := ws.GetEvents(ctx, &api.GetEventsInput{
+			Prev: &limit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting events: %w", err)
+		}
+		for _, event := range eventsOutput.Items {
+			archive.Events = append(archive.Events, api.ArchivedEvent{
+				Stream:    event.Stream,
+				Timestamp: event.Timestamp,
+				Message:   event.Message,
+				Tags:      event.Tags,
+			})
+		}
+	}
+
+	return &api.ExportOutput{
+		Archive: archive,
+	}, nil
+}
+
+func (ws *Workspace) Import(ctx context.Context, input *api.ImportInput) (*api.ImportOutput, error) {
+	archive := input.Archive
+	if archive.Version != workspaceArchiveVersion {
+		return nil, errutil.HTTPErrorf(http.StatusBadRequest, "unsupported workspace archive version: %d", archive.Version)
+	}
+
+	description, err := ws.describe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("describing components: %w", err)
+	}
+	if len(describeOutput.Components) > 0 {
+		return nil, errutil.NewHTTPError(http.StatusConflict, "cannot import in to a workspace that has components")
+	}
+
+	remap := &archiveRemapper{
+		oldRoot: archive.Root,
+		newRoot: description.Root,
+		ids: map[string]string{
+			archive.WorkspaceID: ws.ID,
+		},
+	}
+	for _, component := range archive.Components {
+		remap.ids[component.ID] = gensym.RandomBase32()
+	}
+
+	// Components are added before any are initialized, so if adding one fails,
+	// those already added are removed without disposing anything.
+	ids := make([]string, 0, len(archive.Components))
+	succeeded := false
+	defer func() {
+		if succeeded {
+			return
+		}
+		for _, id := range ids {
+			if _, err := ws.Store.RemoveComponent(ctx, &state.RemoveComponentInput{
+				ID: id,
+			}); err != nil {
+				ws.Logger.Infof("removing partially imported component %q: %v", id, err)
+			}
+		}
+	}()
+	for _, component := range archive.Components {
+		id := remap.ids[component.ID]
+		var dependsOn []string
+		if component.DependsOn != nil {
+			dependsOn = make([]string, len(component.DependsOn))
+			for j, dependency := range component.DependsOn {
+				dependsOn[j] = remap.id(dependency)
+			}
+		}
+		if _, err := ws.Store.AddComponent(ctx, &state.AddComponentInput{
+			WorkspaceID: ws.ID,
+			ID:          id,
+			Name:        component.Name,
+			Type:        component.Type,
+			Spec:        remap.text(component.Spec),
+			Created:     chrono.NowString(ctx),
+			DependsOn:   dependsOn,
+			SpecSource:  specSourceImport,
+		}); err != nil {
+			return nil, fmt.Errorf("adding component %q: %w", component.Name, err)
+		}
+		ids = append(ids, id)
+		if component.State != "" {
+			if _, err := ws.Store.PatchComponent(ctx, &state.PatchComponentInput{
+				ID:    id,
+				State: remap.text(component.State),
+			}); err != nil {
+				return nil, fmt.Errorf("restoring state of %q: %w", component.Name, err)
+			}
+		}
+	}
+
+	if len(archive.VaultUrls) > 0 {
+		// Vaults are configured by a file in the workspace root, which is
+		// restored if the import fails.
+		secretConfigPath, err := ws.resolveWorkspacePath(ctx, secretsUrlFile)
+		if err != nil {
+			return nil, fmt.Errorf("resolving secrets config file path: %w", err)
+		}
+		oldSecretConfig, err := ioutil.ReadFile(secretConfigPath)
+		oldSecretConfigExists := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("reading secrets config file: %w", err)
+		}
+		defer func() {
+			if succeeded {
+				return
+			}
+			var err error
+			if oldSecretConfigExists {
+				err = ioutil.WriteFile(secretConfigPath, oldSecretConfig, 0600)
+			} else {
+				err = os.Remove(secretConfigPath)
+			}
+			if err != nil && !os.IsNotExist(err) {
+				ws.Logger.Infof("restoring secrets config file: %v", err)
+			}
+		}()
+	}
+	for _, url := range archive.VaultUrls {
+		if _, err := ws.AddVault(ctx, &api.AddVaultInput{
+			Url: url,
+		}); err != nil {
+			return nil, fmt.Errorf("adding vault: %w", err)
+		}
+	}
+
+	succeeded = true
+
+	// Logs are restored on a best-effort basis, since the components have
+	// already been imported.
+	eventStore := log.CurrentEventStore(ctx)
+	for _, event := range archive.Events {
+		stream, ok := remap.ids[event.Stream]
+		if !ok {
+			continue
+		}
+		if _, err := eventStore.AddEvent(ctx, &eventd.AddEventInput{
+			Stream:    stream,
+			Timestamp: event.Timestamp,
+			Message:   event.Message,
+			Tags:      event.Tags,
+		}); err != nil {
+			ws.logEventf(ctx, "error restoring imported logs: %v", err)
+			break
+		}
+	}
+
+	ws.logEventf(ctx, "imported %d components from %s", len(archive.Components), archive.Root)
+	query := makeComponentQuery(withRefs(ids...))
+	jobID := ws.controlEachComponent(ctx, "importing", query, func(component *api.ComponentDescription) interface{} {
+		return &api.InitializeInput{
+			Spec: component.Spec,
+		}
+	}, func(component *api.ComponentDescription, err error) {
+		ws.logEventf(ctx, "error initializing %s: %v", component.Name, err)
+	})
+	return &api.ImportOutput{
+		JobID: jobID,
+	}, nil
+}
+
+// archiveRemapper rewrites references to the exported workspace in specs and
+// states, so that they refer to the importing workspace instead.
+type archiveRemapper struct {
+	oldRoot string
+	newRoot string
+	// Maps exported workspace and component IDs to their replacements.
+	ids map[string]string
+}
+
+func (remap *archiveRemapper) id(s string) string {
+	if id, ok := remap.ids[s]; ok {
+		return id
+	}
+	return s
+}
+
+// text replaces exported IDs and paths within the exported root. IDs are
+// random enough to be replaced wherever they occur. Root paths are only
+// replaced where they are not followed by more of a file name, so that an
+// old root of /app does not affect /apple.
+func (remap *archiveRemapper) text(s string) string {
+	for oldID, newID := range remap.ids {
+		s = strings.ReplaceAll(s, oldID, newID)
+	}
+	if remap.oldRoot == "" || remap.oldRoot == remap.newRoot {
+		return s
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, remap.oldRoot)
+		if i < 0 {
+			b.WriteString(s)
+			return b.String()
+		}
+		end := i + len(remap.oldRoot)
+		b.WriteString(s[:i])
+		if end == len(s) || !isFileNameByte(s[end]) {
+			b.WriteString(remap.newRoot)
+		} else {
+			b.WriteString(remap.oldRoot)
+		}
+		s = s[end:]
+	}
+}
+
+func isFileNameByte(c byte) bool {
+	return c == '.' || c == '-' || c == '_' ||
+		('0' <= c && c <= '9') ||
+		('a' <= c && c <= 'z') ||
+		('A' <= c && c <= 'Z')
+}
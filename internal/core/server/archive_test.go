@@ -0,0 +1,26 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveRemapper(t *testing.T) {
+	remap := &archiveRemapper{
+		oldRoot: "/home/alice/app",
+		newRoot: "/Users/bob/src/app",
+		ids: map[string]string{
+			"oldworkspace": "newworkspace",
+			"oldcomponent": "newcomponent",
+		},
+	}
+	assert.Equal(t,
+		`{"directory":"/Users/bob/src/app/web","volume":"newworkspace_data"}`,
+		remap.text(`{"directory":"/home/alice/app/web","volume":"oldworkspace_data"}`),
+	)
+	assert.Equal(t, "/Users/bob/src/app", remap.text("/home/alice/app"))
+	assert.Equal(t, "/home/alice/apple /Users/bob/src/app", remap.text("/home/alice/apple /home/alice/app"))
+	assert.Equal(t, "newcomponent", remap.id("oldcomponent"))
+	assert.Equal(t, "db", remap.id("db"))
+}
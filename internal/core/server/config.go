@@ -13,39 +13,33 @@ import (
 	"github.com/deref/exo/internal/manifest"
 	"github.com/deref/exo/internal/manifest/exohcl"
 	"github.com/deref/exo/internal/util/errutil"
-	"github.com/deref/exo/internal/util/osutil"
 	"github.com/deref/exo/internal/util/pathutil"
 )
 
-type manifestCandidate struct {
-	Format   string
-	Filename string
-}
-
-var manifestCandidates = []manifestCandidate{
-	{"exo", "exo.hcl"},
-	{"compose", "compose.yaml"},
-	{"compose", "compose.yml"},
-	{"compose", "docker-compose.yaml"},
-	{"compose", "docker-compose.yml"},
-	{"procfile", "Procfile"},
-}
-
 // XXX This is a hacky workaround until manifest handling is overhauled.
 func (ws *Workspace) tryLoadManifest(ctx context.Context) *exohcl.Manifest {
 	wsDesc, err := ws.describe(ctx)
 	if err != nil {
 		return nil
 	}
-	m, err := ws.loadManifest(wsDesc.Root, &api.ApplyInput{})
+	m, err := ws.loadManifest(ctx, wsDesc.Root, &api.ApplyInput{})
 	return m
 }
 
-func (ws *Workspace) loadManifest(rootDir string, input *api.ApplyInput) (*exohcl.Manifest, error) {
+func (ws *Workspace) loadManifest(ctx context.Context, rootDir string, input *api.ApplyInput) (*exohcl.Manifest, error) {
+	loader, err := ws.newManifestLoader(ctx, rootDir, input)
+	if err != nil {
+		return nil, err
+	}
+	return loader.Load()
+}
+
+// newManifestLoader configures a loader for the manifest of an apply.
+func (ws *Workspace) newManifestLoader(ctx context.Context, rootDir string, input *api.ApplyInput) (*manifest.Loader, error) {
 	manifestString := ""
 	manifestPath := ""
 	if input.ManifestPath != nil {
-		manifestPath = *input.ManifestPath
+		manifestPath = resolveWorkspacePath(rootDir, *input.ManifestPath)
 	}
 	if input.Manifest == nil {
 		if input.ManifestPath == nil {
@@ -59,19 +53,37 @@ func (ws *Workspace) loadManifest(rootDir string, input *api.ApplyInput) (*exohc
 			}
 		}
 
-		if !pathutil.HasFilePathPrefix(manifestPath, rootDir) {
-			return nil, errors.New("cannot read manifest outside of workspace root")
-		}
-
-		bs, err := ioutil.ReadFile(manifestPath)
+		bs, err := readWorkspaceFile(rootDir, manifestPath)
 		if err != nil {
-			return nil, fmt.Errorf("reading manifest file: %w", err)
+			return nil, err
 		}
 		manifestString = string(bs)
 	} else {
 		manifestString = *input.Manifest
 	}
 
+	var overrides []manifest.File
+	if input.Overrides != nil {
+		overrides = make([]manifest.File, len(input.Overrides))
+		for i, override := range input.Overrides {
+			overridePath := resolveWorkspacePath(rootDir, override.Path)
+			var bs []byte
+			if override.Content == nil {
+				var err error
+				bs, err = readWorkspaceFile(rootDir, overridePath)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				bs = []byte(*override.Content)
+			}
+			overrides[i] = manifest.File{
+				Filename: overridePath,
+				Bytes:    bs,
+			}
+		}
+	}
+
 	// TODO: Get official name from workspace description.
 	workspaceName := path.Base(rootDir)
 	workspaceName = exohcl.MangleName(workspaceName)
@@ -81,8 +93,41 @@ func (ws *Workspace) loadManifest(rootDir string, input *api.ApplyInput) (*exohc
 		Format:        input.Format,
 		Filename:      manifestPath,
 		Bytes:         []byte(manifestString),
+		Variables:     input.Variables,
+		Overrides:     overrides,
+		RootDir:       rootDir,
+		Formation:     input.Formation,
 	}
-	return loader.Load()
+	if input.BasePort != nil {
+		loader.BasePort = *input.BasePort
+	}
+	if err := loader.LoadDotenv(rootDir); err != nil {
+		return nil, err
+	}
+	env, err := ws.getManifestEnvironment(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting environment: %w", err)
+	}
+	loader.Environment = env
+	return loader, nil
+}
+
+func resolveWorkspacePath(rootDir, p string) string {
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(rootDir, p)
+}
+
+func readWorkspaceFile(rootDir, p string) ([]byte, error) {
+	if !pathutil.HasFilePathPrefix(p, rootDir) {
+		return nil, errors.New("cannot read manifest outside of workspace root")
+	}
+	bs, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest file: %w", err)
+	}
+	return bs, nil
 }
 
 func (ws *Workspace) ResolveManifest(ctx context.Context, input *api.ResolveManifestInput) (*api.ResolveManifestOutput, error) {
@@ -100,18 +145,5 @@ func (ws *Workspace) ResolveManifest(ctx context.Context, input *api.ResolveMani
 }
 
 func (ws *Workspace) resolveManifest(rootDir, format string) (string, error) {
-	for _, candidate := range manifestCandidates {
-		if format != "" && format != candidate.Format {
-			continue
-		}
-		candidatePath := filepath.Join(rootDir, candidate.Filename)
-		exist, err := osutil.Exists(candidatePath)
-		if err != nil {
-			return "", fmt.Errorf("searching for manifest: %w", err)
-		}
-		if exist {
-			return candidatePath, nil
-		}
-	}
-	return "", nil
+	return manifest.Resolve(rootDir, format)
 }
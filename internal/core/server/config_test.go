@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/deref/exo/internal/core/api"
+	state "github.com/deref/exo/internal/core/state/api"
+	statesqlite "github.com/deref/exo/internal/core/state/sqlite"
+	"github.com/deref/exo/internal/esv"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEsvClient struct {
+	secrets map[string]string
+}
+
+func (c *fakeEsvClient) StartAuthFlow(ctx context.Context) (esv.AuthResponse, error) {
+	return esv.AuthResponse{}, nil
+}
+
+func (c *fakeEsvClient) GetWorkspaceSecrets(vaultURL string) (map[string]string, error) {
+	return c.secrets, nil
+}
+
+func newTestWorkspace(t *testing.T, rootDir string) *Workspace {
+	ctx := context.Background()
+	db, err := sqlx.Open("sqlite3", filepath.Join(t.TempDir(), "exo.sqlite3")+"?_txlock=exclusive")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	store := &statesqlite.Store{DB: db}
+	require.NoError(t, store.Migrate(ctx))
+	_, err = store.AddWorkspace(ctx, &state.AddWorkspaceInput{ID: "ws", Root: rootDir})
+	require.NoError(t, err)
+	return &Workspace{
+		ID:        "ws",
+		Store:     store,
+		EsvClient: &fakeEsvClient{},
+	}
+}
+
+func TestLoadManifestEnvironment(t *testing.T) {
+	ctx := context.Background()
+	rootDir := t.TempDir()
+	writeFile := func(name, content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(rootDir, name), []byte(content), 0600))
+	}
+	writeFile(secretsUrlFile, "https://vault.example.com/")
+	writeFile(".env", "GREETING=hello\n")
+	manifestPath := filepath.Join(rootDir, "exo.hcl")
+	writeFile("exo.hcl", `
+exo = "0.1"
+
+components {
+  process "web" {
+    program   = "server"
+    arguments = [env("GREETING"), env("API_KEY", "none")]
+  }
+}
+`)
+
+	ws := newTestWorkspace(t, rootDir)
+	ws.EsvClient = &fakeEsvClient{
+		secrets: map[string]string{"API_KEY": "secret"},
+	}
+	m, err := ws.loadManifest(ctx, rootDir, &api.ApplyInput{
+		ManifestPath: &manifestPath,
+	})
+	require.NoError(t, err)
+	require.Empty(t, m.Diagnostics())
+	require.Equal(t, 1, m.Components().Len())
+	assert.Equal(t,
+		`{"arguments":["hello","secret"],"program":"server"}`,
+		m.Components().Index(0).Spec(),
+	)
+}
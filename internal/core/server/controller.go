@@ -4,3 +4,11 @@ type Controller interface {
 	InitResource() error
 	MarshalState() (state string, err error)
 }
+
+// StateExporter is implemented by controllers whose state refers to resources
+// that do not outlive the host, such as process IDs. ExportState returns the
+// remaining state, for inclusion in workspace exports. The state of other
+// controllers is exported as is.
+type StateExporter interface {
+	ExportState() (state string, err error)
+}
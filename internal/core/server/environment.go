@@ -12,6 +12,31 @@ import (
 // XXX This now does network requests and non-trivial parsing work. Therefore,
 // it is no longer appropriate to call deep in the call stack.
 func (ws *Workspace) getEnvironment(ctx context.Context) (map[string]api.VariableDescription, error) {
+	var manifestEnv environment.Source
+	if manifest := ws.tryLoadManifest(ctx); manifest != nil {
+		manifestEnv = manifest.Environment()
+	}
+	return ws.buildEnvironment(ctx, manifestEnv)
+}
+
+// getManifestEnvironment returns the environment available to manifest
+// expressions via env(). It is the environment of components, except for the
+// manifest's own environment block, which is yet to be evaluated.
+func (ws *Workspace) getManifestEnvironment(ctx context.Context) (map[string]string, error) {
+	env, err := ws.buildEnvironment(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(env))
+	for name, v := range env {
+		res[name] = v.Value
+	}
+	return res, nil
+}
+
+// buildEnvironment merges the workspace's sources of environment variables,
+// including the environment of its manifest, unless manifestEnv is nil.
+func (ws *Workspace) buildEnvironment(ctx context.Context, manifestEnv environment.Source) (map[string]api.VariableDescription, error) {
 	var sources []environment.Source
 
 	describeVaultsResult, err := ws.DescribeVaults(ctx, &api.DescribeVaultsInput{})
@@ -26,8 +51,8 @@ func (ws *Workspace) getEnvironment(ctx context.Context) (map[string]api.Variabl
 		})
 	}
 
-	if manifest := ws.tryLoadManifest(ctx); manifest != nil {
-		sources = append(sources, manifest.Environment())
+	if manifestEnv != nil {
+		sources = append(sources, manifestEnv)
 	}
 
 	sources = append(sources,
@@ -18,14 +18,29 @@ import (
 )
 
 type Config struct {
-	VarDir      string
-	Store       state.Store
-	SyslogPort  uint
-	Docker      *docker.Client
-	Logger      logging.Logger
-	TaskTracker *task.TaskTracker
-	TokenClient token.TokenClient
-	EsvClient   esv.EsvClient
+	VarDir         string
+	Store          state.Store
+	SyslogPort     uint
+	Docker         *docker.Client
+	Logger         logging.Logger
+	TaskTracker    *task.TaskTracker
+	TokenClient    token.TokenClient
+	EsvClient      esv.EsvClient
+	JobConcurrency JobConcurrency
+}
+
+func (cfg *Config) newWorkspace(id string) *Workspace {
+	return &Workspace{
+		ID:             id,
+		VarDir:         cfg.VarDir,
+		Logger:         cfg.Logger,
+		Store:          cfg.Store,
+		SyslogPort:     cfg.SyslogPort,
+		Docker:         cfg.Docker,
+		TaskTracker:    cfg.TaskTracker,
+		EsvClient:      cfg.EsvClient,
+		JobConcurrency: cfg.JobConcurrency,
+	}
 }
 
 func BuildRootMux(prefix string, cfg *Config) *http.ServeMux {
@@ -69,16 +84,7 @@ func BuildRootMux(prefix string, cfg *Config) *http.ServeMux {
 
 	endWorkspace := b.Begin("workspace")
 	api.BuildWorkspaceMux(b, func(req *http.Request) api.Workspace {
-		return &Workspace{
-			ID:          req.URL.Query().Get("id"),
-			VarDir:      cfg.VarDir,
-			Logger:      cfg.Logger,
-			Store:       cfg.Store,
-			SyslogPort:  cfg.SyslogPort,
-			Docker:      cfg.Docker,
-			TaskTracker: cfg.TaskTracker,
-			EsvClient:   cfg.EsvClient,
-		}
+		return cfg.newWorkspace(req.URL.Query().Get("id"))
 	})
 	endWorkspace()
 
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/deref/exo/internal/core/api"
+	state "github.com/deref/exo/internal/core/state/api"
+	"github.com/deref/exo/internal/util/errutil"
+)
+
+// Sources of spec changes, as recorded in spec histories.
+const (
+	specSourceApply    = "apply"
+	specSourceAPI      = "api"
+	specSourceAdopt    = "adopt"
+	specSourceImport   = "import"
+	specSourceRollback = "rollback"
+)
+
+func (ws *Workspace) resolveComponent(ctx context.Context, ref string) (*api.ComponentDescription, error) {
+	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{Refs: []string{ref}})
+	if err != nil {
+		return nil, fmt.Errorf("describing components: %w", err)
+	}
+	if len(describeOutput.Components) == 0 {
+		return nil, errutil.HTTPErrorf(http.StatusNotFound, "component not found: %q", ref)
+	}
+	return &describeOutput.Components[0], nil
+}
+
+func (ws *Workspace) describeSpecRevisions(ctx context.Context, id string) ([]api.SpecRevision, error) {
+	output, err := ws.Store.DescribeSpecRevisions(ctx, &state.DescribeSpecRevisionsInput{
+		ID: id,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing spec revisions: %w", err)
+	}
+	revisions := make([]api.SpecRevision, len(output.Revisions))
+	for i, revision := range output.Revisions {
+		revisions[i] = api.SpecRevision{
+			Revision:  revision.Revision,
+			Timestamp: revision.Timestamp,
+			Source:    revision.Source,
+			Spec:      revision.Spec,
+		}
+	}
+	return revisions, nil
+}
+
+func (ws *Workspace) DescribeSpecHistory(ctx context.Context, input *api.DescribeSpecHistoryInput) (*api.DescribeSpecHistoryOutput, error) {
+	component, err := ws.resolveComponent(ctx, input.Ref)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := ws.describeSpecRevisions(ctx, component.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &api.DescribeSpecHistoryOutput{
+		Revisions: revisions,
+	}, nil
+}
+
+func (ws *Workspace) RollbackComponent(ctx context.Context, input *api.RollbackComponentInput) (*api.RollbackComponentOutput, error) {
+	component, err := ws.resolveComponent(ctx, input.Ref)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := ws.describeSpecRevisions(ctx, component.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var target *api.SpecRevision
+	if input.Revision == nil {
+		if len(revisions) < 2 {
+			return nil, errutil.HTTPErrorf(http.StatusBadRequest, "component %q has no earlier revision", component.Name)
+		}
+		target = &revisions[len(revisions)-2]
+	} else {
+		for i, revision := range revisions {
+			if revision.Revision == *input.Revision {
+				target = &revisions[i]
+				break
+			}
+		}
+		if target == nil {
+			return nil, errutil.HTTPErrorf(http.StatusNotFound, "component %q has no revision %d", component.Name, *input.Revision)
+		}
+	}
+
+	ws.logEventf(ctx, "rolling back %s to revision %d", component.Name, target.Revision)
+	updateOutput, err := ws.UpdateComponent(ctx, &api.UpdateComponentInput{
+		Ref:    component.ID,
+		Spec:   target.Spec,
+		Source: specSourceRollback,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.RollbackComponentOutput{
+		Revision: target.Revision,
+		JobID:    updateOutput.JobID,
+	}, nil
+}
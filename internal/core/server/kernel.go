@@ -232,7 +232,13 @@ func restart(ctx context.Context) {
 
 func (kern *Kernel) DescribeTasks(ctx context.Context, input *api.DescribeTasksInput) (*api.DescribeTasksOutput, error) {
 	underlying, err := kern.TaskTracker.Store.DescribeTasks(ctx, &taskapi.DescribeTasksInput{
-		JobIDs: input.JobIDs,
+		JobIDs:        input.JobIDs,
+		WorkspaceID:   input.WorkspaceID,
+		Statuses:      input.Statuses,
+		CreatedAfter:  input.CreatedAfter,
+		CreatedBefore: input.CreatedBefore,
+		JobsOnly:      input.JobsOnly,
+		Limit:         input.Limit,
 	})
 	if err != nil {
 		return nil, err
@@ -241,16 +247,17 @@ func (kern *Kernel) DescribeTasks(ctx context.Context, input *api.DescribeTasksI
 	output.Tasks = make([]api.TaskDescription, len(underlying.Tasks))
 	for i, t1 := range underlying.Tasks {
 		t2 := api.TaskDescription{
-			ID:       t1.ID,
-			JobID:    t1.JobID,
-			ParentID: t1.ParentID,
-			Name:     t1.Name,
-			Status:   t1.Status,
-			Message:  t1.Message,
-			Created:  t1.Created,
-			Updated:  t1.Updated,
-			Started:  t1.Started,
-			Finished: t1.Finished,
+			ID:          t1.ID,
+			JobID:       t1.JobID,
+			ParentID:    t1.ParentID,
+			WorkspaceID: t1.WorkspaceID,
+			Name:        t1.Name,
+			Status:      t1.Status,
+			Message:     t1.Message,
+			Created:     t1.Created,
+			Updated:     t1.Updated,
+			Started:     t1.Started,
+			Finished:    t1.Finished,
 		}
 		if t1.Progress != nil {
 			t2.Progress = &api.TaskProgress{
@@ -263,6 +270,71 @@ func (kern *Kernel) DescribeTasks(ctx context.Context, input *api.DescribeTasksI
 	return &output, nil
 }
 
+func (kern *Kernel) CancelJob(ctx context.Context, input *api.CancelJobInput) (*api.CancelJobOutput, error) {
+	if kern.TaskTracker.CancelJob(input.JobID) {
+		return &api.CancelJobOutput{}, nil
+	}
+	described, err := kern.TaskTracker.Store.DescribeTasks(ctx, &taskapi.DescribeTasksInput{
+		JobIDs:   []string{input.JobID},
+		JobsOnly: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing job: %w", err)
+	}
+	if len(described.Tasks) == 0 {
+		return nil, errutil.HTTPErrorf(http.StatusNotFound, "no such job: %q", input.JobID)
+	}
+	return nil, errutil.HTTPErrorf(http.StatusConflict, "job %q already finished with status %s", input.JobID, described.Tasks[0].Status)
+}
+
+func (kern *Kernel) GetTaskOutput(ctx context.Context, input *api.GetTaskOutputInput) (*api.GetTaskOutputOutput, error) {
+	described, err := kern.TaskTracker.Store.DescribeTasks(ctx, &taskapi.DescribeTasksInput{
+		JobIDs: []string{input.JobID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing tasks: %w", err)
+	}
+	if len(described.Tasks) == 0 {
+		return nil, errutil.HTTPErrorf(http.StatusNotFound, "no such job: %q", input.JobID)
+	}
+	var wanted map[string]bool
+	if input.TaskIDs != nil {
+		wanted = make(map[string]bool, len(input.TaskIDs))
+		for _, id := range input.TaskIDs {
+			wanted[id] = true
+		}
+	}
+	taskIDs := []string{}
+	for _, task := range described.Tasks {
+		if wanted == nil || wanted[task.ID] {
+			taskIDs = append(taskIDs, task.ID)
+		}
+	}
+
+	lines, err := kern.TaskTracker.Store.GetTaskOutput(ctx, &taskapi.GetTaskOutputInput{
+		TaskIDs: taskIDs,
+		Cursor:  input.Cursor,
+		Next:    input.Next,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting task output: %w", err)
+	}
+	output := &api.GetTaskOutputOutput{
+		Items:      make([]api.Event, len(lines.Items)),
+		NextCursor: lines.NextCursor,
+	}
+	for i, line := range lines.Items {
+		output.Items[i] = api.Event{
+			ID:        line.ID,
+			Stream:    line.TaskID,
+			Timestamp: line.Timestamp,
+			Message:   line.Message,
+			Tags:      map[string]string{},
+		}
+	}
+	return output, nil
+}
+
 func (kern *Kernel) GetUserHomeDir(ctx context.Context, input *api.GetUserHomeDirInput) (*api.GetUserHomeDirOutput, error) {
 	path, err := os.UserHomeDir()
 	if err != nil {
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/deps"
+	"github.com/deref/exo/internal/manifest/exohcl"
+)
+
+// Actions of planned component changes. See api.PlannedChange.
+const (
+	planCreate  = "create"
+	planUpdate  = "update"
+	planReplace = "replace"
+	planDelete  = "delete"
+)
+
+type plannedChange struct {
+	api.PlannedChange
+	// Nil when creating.
+	oldComponent *api.ComponentDescription
+	// Nil when deleting.
+	newComponent *exohcl.Component
+}
+
+// planApply compares the active components of a manifest to the existing
+// components of the workspace, keyed by name. Components whose type changed
+// are replaced, as are all components that depend on them. Components whose
+// spec or dependencies changed are updated in place, keeping their IDs.
+// Existing components that are not in the manifest are deleted, unless they
+// are inactive. Unchanged components are omitted from the plan.
+func planApply(manifestComponents []*exohcl.Component, inactiveComponents map[string]struct{}, oldComponents map[string]api.ComponentDescription) []*plannedChange {
+	graph := deps.New()
+	for _, c := range manifestComponents {
+		graph.AddNode(&componentNode{
+			component: c,
+		})
+		for _, dependency := range c.DependsOn() {
+			graph.AddEdge(c.Name(), dependency)
+		}
+	}
+
+	// Instances of the existing components, used to evaluate specs that refer
+	// to the state of other components.
+	instances := make(map[string]exohcl.ComponentInstance, len(oldComponents))
+	for name, oldComponent := range oldComponents {
+		instances[name] = exohcl.ComponentInstance{
+			Spec:  oldComponent.Spec,
+			State: oldComponent.State,
+		}
+	}
+
+	var plan []*plannedChange
+	byName := make(map[string]*plannedChange, len(manifestComponents))
+	for _, newComponent := range manifestComponents {
+		name := newComponent.Name()
+		change := &plannedChange{
+			PlannedChange: api.PlannedChange{
+				Name:         name,
+				Type:         newComponent.Type(),
+				NewDependsOn: newComponent.DependsOn(),
+			},
+			newComponent: newComponent,
+		}
+		newSpec := newComponent.Spec()
+		specKnown := true
+		if newComponent.Deferred() {
+			spec, diags := newComponent.EvalSpec(instances)
+			specKnown = !diags.HasErrors()
+			if specKnown {
+				newSpec = spec
+			}
+		}
+		change.NewSpec = newSpec
+
+		oldComponent, exists := oldComponents[name]
+		if exists {
+			oldComponent := oldComponent
+			change.oldComponent = &oldComponent
+			change.OldSpec = oldComponent.Spec
+			change.OldDependsOn = oldComponent.DependsOn
+		}
+		switch {
+		case !exists:
+			change.Action = planCreate
+		case oldComponent.Type != newComponent.Type():
+			change.Action = planReplace
+			change.Reason = fmt.Sprintf("type changed from %s", oldComponent.Type)
+		case !specKnown:
+			change.Action = planReplace
+			change.Reason = "spec refers to state that is not yet known"
+		case oldComponent.Spec != newSpec:
+			change.Action = planUpdate
+			change.Reason = "spec changed"
+		case !sameNames(oldComponent.DependsOn, newComponent.DependsOn()):
+			change.Action = planUpdate
+			change.Reason = "dependencies changed"
+		default:
+			// Unchanged, unless a dependency is replaced or updated. See below.
+		}
+		plan = append(plan, change)
+		byName[name] = change
+	}
+
+	// Replacing a component replaces everything that depends on it.
+	for _, change := range plan {
+		if change.Action != planReplace {
+			continue
+		}
+		for dependentName := range graph.Dependents(change.Name) {
+			dependent := byName[dependentName]
+			if dependent == nil || dependent.Action == planCreate || dependent.Action == planReplace {
+				continue
+			}
+			dependent.Action = planReplace
+			dependent.Reason = fmt.Sprintf("depends on %s, which is replaced", change.Name)
+		}
+	}
+
+	// Updating the spec of a component may change its state, so specs that
+	// refer to the state of other components are re-evaluated when applying.
+	for _, change := range plan {
+		if change.Action != planUpdate || change.OldSpec == change.NewSpec {
+			continue
+		}
+		for dependentName := range graph.Dependents(change.Name) {
+			dependent := byName[dependentName]
+			if dependent == nil || dependent.Action != "" || !dependent.newComponent.Deferred() {
+				continue
+			}
+			dependent.Action = planUpdate
+			dependent.Reason = fmt.Sprintf("depends on %s, which is updated", change.Name)
+			dependent.NewSpec = ""
+		}
+	}
+
+	changed := make([]*plannedChange, 0, len(plan))
+	for _, change := range plan {
+		if change.Action != "" {
+			changed = append(changed, change)
+		}
+	}
+
+	var deleted []*plannedChange
+	for name, oldComponent := range oldComponents {
+		if _, exists := byName[name]; exists {
+			continue
+		}
+		// Like Docker Compose, leave components of inactive profiles alone.
+		if _, inactive := inactiveComponents[name]; inactive {
+			continue
+		}
+		oldComponent := oldComponent
+		deleted = append(deleted, &plannedChange{
+			PlannedChange: api.PlannedChange{
+				Name:         name,
+				Type:         oldComponent.Type,
+				Action:       planDelete,
+				OldSpec:      oldComponent.Spec,
+				OldDependsOn: oldComponent.DependsOn,
+			},
+			oldComponent: &oldComponent,
+		})
+	}
+	sort.Slice(deleted, func(i, j int) bool {
+		return deleted[i].Name < deleted[j].Name
+	})
+
+	return append(changed, deleted...)
+}
+
+// sameNames reports whether a and b contain the same names, in any order.
+func sameNames(a, b []string) bool {
+	return containsNames(a, b) && containsNames(b, a)
+}
+
+func containsNames(names []string, subset []string) bool {
+	for _, name := range subset {
+		found := false
+		for _, n := range names {
+			if n == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
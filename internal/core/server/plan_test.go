@@ -0,0 +1,118 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanApply(t *testing.T) {
+	src := `
+exo = "0.1"
+
+components {
+  process "db" {
+    program = "postgres"
+  }
+  process "api" {
+    program = "api"
+    _ {
+      depends_on = ["db"]
+    }
+  }
+  process "web" {
+    program = "web"
+    environment = {
+      API_PID = tostring(components.api.state.pid)
+    }
+  }
+  process "worker" {
+    program = "worker"
+    environment = {
+      MODE = "fast"
+    }
+  }
+  process "cache" {
+    program = "redis"
+  }
+  process "proxy" {
+    program = "proxy"
+    _ {
+      depends_on = ["cache"]
+    }
+  }
+  process "docs" {
+    program = "docs"
+  }
+}
+`
+	m := exohcl.Parse("exo.hcl", []byte(src), nil)
+	require.Empty(t, m.Diagnostics())
+	manifestComponents, inactive := activeManifestComponents(m, nil)
+
+	old := func(name, spec string, dependsOn ...string) api.ComponentDescription {
+		return api.ComponentDescription{
+			ID:        name + "-id",
+			Name:      name,
+			Type:      "process",
+			Spec:      spec,
+			State:     `{"pid":1}`,
+			DependsOn: dependsOn,
+		}
+	}
+	oldComponents := map[string]api.ComponentDescription{
+		"db":     old("db", `{"program":"postgres"}`),
+		"api":    old("api", `{"program":"api"}`, "db"),
+		"web":    old("web", `{"environment":{"API_PID":"1"},"program":"web"}`, "api"),
+		"worker": old("worker", `{"environment":{"MODE":"slow"},"program":"worker"}`),
+		"cache":  old("cache", `{"program":"redis"}`),
+		"proxy":  old("proxy", `{"program":"proxy"}`),
+		"legacy": old("legacy", `{"program":"legacy"}`),
+	}
+
+	actions := map[string]string{}
+	reasons := map[string]string{}
+	for _, change := range planApply(manifestComponents, inactive, oldComponents) {
+		actions[change.Name] = change.Action
+		reasons[change.Name] = change.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"worker": "update",
+		"proxy":  "update",
+		"docs":   "create",
+		"legacy": "delete",
+	}, actions)
+	assert.Equal(t, "spec changed", reasons["worker"])
+	assert.Equal(t, "dependencies changed", reasons["proxy"])
+
+	oldComponents["api"] = old("api", `{"program":"api-v1"}`, "db")
+	actions = map[string]string{}
+	for _, change := range planApply(manifestComponents, inactive, oldComponents) {
+		actions[change.Name] = change.Action
+		reasons[change.Name] = change.Reason
+	}
+	assert.Equal(t, "update", actions["api"])
+	assert.Equal(t, "update", actions["web"])
+	assert.Equal(t, "depends on api, which is updated", reasons["web"])
+	assert.NotContains(t, actions, "db")
+
+	oldComponents["db"] = api.ComponentDescription{
+		ID:   "db-id",
+		Name: "db",
+		Type: "container",
+		Spec: `{"image":"postgres"}`,
+	}
+	actions = map[string]string{}
+	for _, change := range planApply(manifestComponents, inactive, oldComponents) {
+		actions[change.Name] = change.Action
+		reasons[change.Name] = change.Reason
+	}
+	assert.Equal(t, "replace", actions["db"])
+	assert.Equal(t, "type changed from container", reasons["db"])
+	assert.Equal(t, "replace", actions["api"])
+	assert.Equal(t, "replace", actions["web"])
+	assert.Equal(t, "depends on db, which is replaced", reasons["web"])
+}
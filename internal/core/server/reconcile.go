@@ -0,0 +1,254 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deref/exo/internal/core/api"
+	state "github.com/deref/exo/internal/core/state/api"
+	josh "github.com/deref/exo/internal/josh/server"
+	taskapi "github.com/deref/exo/internal/task/api"
+	"github.com/deref/exo/internal/util/errutil"
+)
+
+// Policies for handling drift. See api.SetDriftPolicyInput.
+const (
+	driftPolicyReport = "report"
+	driftPolicyRepair = "repair"
+	driftPolicyOff    = "off"
+)
+
+// driftPolicy returns the effective drift policy of a workspace setting.
+func driftPolicy(setting string) string {
+	if setting == "" {
+		return driftPolicyReport
+	}
+	return setting
+}
+
+func (ws *Workspace) SetDriftPolicy(ctx context.Context, input *api.SetDriftPolicyInput) (*api.SetDriftPolicyOutput, error) {
+	switch input.Policy {
+	case driftPolicyReport, driftPolicyRepair, driftPolicyOff:
+	default:
+		return nil, errutil.HTTPErrorf(http.StatusBadRequest, "invalid drift policy: %q", input.Policy)
+	}
+	if _, err := ws.Store.PatchWorkspace(ctx, &state.PatchWorkspaceInput{
+		ID:          ws.ID,
+		DriftPolicy: &input.Policy,
+	}); err != nil {
+		return nil, fmt.Errorf("patching workspace: %w", err)
+	}
+	ws.logEventf(ctx, "drift policy set to %s", input.Policy)
+	return &api.SetDriftPolicyOutput{}, nil
+}
+
+func (ws *Workspace) logDrift(ctx context.Context, component api.ComponentDescription, drift []string) {
+	for _, d := range drift {
+		ws.logEventf(ctx, "drift detected in %s: %s", component.Name, d)
+	}
+}
+
+// reconcile refreshes each component of the workspace and records drift as
+// events. Drift that was already reported, according to the reported map of
+// component IDs to drift, is not reported again. With the report policy,
+// refreshed state is discarded, leaving stored state to jobs. With the repair
+// policy, changed state is saved and drifted components are then disposed and
+// re-initialized from their specs, keeping their IDs.
+//
+// Workspaces with unfinished jobs are skipped, since those jobs may be
+// changing the components and their state.
+func (ws *Workspace) reconcile(ctx context.Context, policy string, reported map[string]string) {
+	busy, err := ws.hasUnfinishedJobs(ctx)
+	if err != nil {
+		ws.Logger.Infof("describing jobs of workspace %q: %v", ws.ID, err)
+		return
+	}
+	if busy {
+		return
+	}
+	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{})
+	if err != nil {
+		ws.Logger.Infof("describing components of workspace %q: %v", ws.ID, err)
+		return
+	}
+	exists := make(map[string]bool, len(describeOutput.Components))
+	for _, component := range describeOutput.Components {
+		exists[component.ID] = true
+	}
+	for id := range reported {
+		if !exists[id] {
+			delete(reported, id)
+		}
+	}
+
+	for _, component := range describeOutput.Components {
+		refreshed, newState, err := ws.refresh(ctx, component)
+		if err != nil {
+			ws.Logger.Infof("refreshing component %q: %v", component.ID, err)
+			continue
+		}
+		if len(refreshed.Drift) == 0 {
+			delete(reported, component.ID)
+		} else {
+			drift := strings.Join(refreshed.Drift, "\n")
+			if reported[component.ID] != drift {
+				ws.logDrift(ctx, component, refreshed.Drift)
+				reported[component.ID] = drift
+			}
+		}
+		if policy != driftPolicyRepair {
+			continue
+		}
+		if newState != component.State {
+			saved, err := ws.saveRefreshedState(ctx, component, newState)
+			if err != nil {
+				ws.Logger.Infof("saving state of component %q: %v", component.ID, err)
+				continue
+			}
+			if !saved {
+				// Changed by a job since it was described; check again next time.
+				continue
+			}
+		}
+		if len(refreshed.Drift) == 0 {
+			continue
+		}
+		delete(reported, component.ID)
+		ws.logEventf(ctx, "repairing %s", component.Name)
+		if err := ws.repairComponent(ctx, component.ID); err != nil {
+			ws.logEventf(ctx, "repairing %s failed: %v", component.Name, err)
+		}
+	}
+}
+
+func (ws *Workspace) hasUnfinishedJobs(ctx context.Context) (bool, error) {
+	output, err := ws.TaskTracker.Store.DescribeTasks(ctx, &taskapi.DescribeTasksInput{
+		WorkspaceID: ws.ID,
+		JobsOnly:    true,
+		Statuses:    []string{taskapi.StatusPending, taskapi.StatusRunning},
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(output.Tasks) > 0, nil
+}
+
+// refresh refreshes a component without saving its state, returning the
+// refreshed state instead.
+func (ws *Workspace) refresh(ctx context.Context, desc api.ComponentDescription) (*api.RefreshOutput, string, error) {
+	ctrl := ws.newController(ctx, desc)
+	if err := ctrl.InitResource(); err != nil {
+		return nil, "", err
+	}
+	output, err := josh.Send(ctx, ctrl, &api.RefreshInput{
+		Spec: desc.Spec,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	refreshed, ok := output.(*api.RefreshOutput)
+	if !ok {
+		refreshed = &api.RefreshOutput{}
+	}
+	newState, err := ctrl.MarshalState()
+	if err != nil {
+		return nil, "", fmt.Errorf("marshalling state: %w", err)
+	}
+	return refreshed, newState, nil
+}
+
+// saveRefreshedState saves the state of a refreshed component, unless its
+// stored state no longer matches the state that it was refreshed from.
+// Returns whether the state was saved.
+func (ws *Workspace) saveRefreshedState(ctx context.Context, desc api.ComponentDescription, newState string) (bool, error) {
+	output, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{
+		Refs: []string{desc.ID},
+	})
+	if err != nil {
+		return false, fmt.Errorf("describing component: %w", err)
+	}
+	if len(output.Components) != 1 || output.Components[0].State != desc.State {
+		return false, nil
+	}
+	if _, err := ws.Store.PatchComponent(ctx, &state.PatchComponentInput{
+		ID:    desc.ID,
+		State: newState,
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (ws *Workspace) repairComponent(ctx context.Context, id string) error {
+	describe := func() (api.ComponentDescription, error) {
+		output, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{
+			Refs: []string{id},
+		})
+		if err != nil {
+			return api.ComponentDescription{}, fmt.Errorf("describing component: %w", err)
+		}
+		if len(output.Components) != 1 {
+			return api.ComponentDescription{}, fmt.Errorf("component %q not found", id)
+		}
+		return output.Components[0], nil
+	}
+
+	component, err := describe()
+	if err != nil {
+		return err
+	}
+	if err := ws.control(ctx, component, &api.DisposeInput{}); err != nil {
+		return fmt.Errorf("disposing: %w", err)
+	}
+	// Describe again, since disposing changes the state.
+	component, err = describe()
+	if err != nil {
+		return err
+	}
+	if err := ws.control(ctx, component, &api.InitializeInput{
+		Spec: component.Spec,
+	}); err != nil {
+		return fmt.Errorf("initializing: %w", err)
+	}
+	return nil
+}
+
+// Reconciler periodically refreshes the components of each workspace, in order
+// to detect drift between components and the resources that they manage, and
+// handles drift according to the workspace's drift policy.
+type Reconciler struct {
+	Config   *Config
+	Interval time.Duration
+}
+
+// Run reconciles workspaces until the context is done.
+func (r *Reconciler) Run(ctx context.Context) {
+	// Keyed by workspace ID. See Workspace.reconcile.
+	reported := make(map[string]map[string]string)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.Interval):
+		}
+		output, err := r.Config.Store.DescribeWorkspaces(ctx, &state.DescribeWorkspacesInput{})
+		if err != nil {
+			r.Config.Logger.Infof("describing workspaces to reconcile: %v", err)
+			continue
+		}
+		for _, workspace := range output.Workspaces {
+			policy := driftPolicy(workspace.DriftPolicy)
+			if policy == driftPolicyOff {
+				delete(reported, workspace.ID)
+				continue
+			}
+			if reported[workspace.ID] == nil {
+				reported[workspace.ID] = make(map[string]string)
+			}
+			r.Config.newWorkspace(workspace.ID).reconcile(ctx, policy, reported[workspace.ID])
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/deref/exo/internal/deps"
+)
+
+// JobConcurrency limits how many tasks of a job's graph of components are run
+// at once, such as to avoid building many images in parallel.
+type JobConcurrency struct {
+	// Limit for all operations without a limit of their own. Zero means no
+	// limit.
+	Default int
+	// Limits by operation, keyed by job name, such as "building" or "applying".
+	ByOperation map[string]int
+}
+
+func (jc JobConcurrency) limit(operation string) int {
+	if limit, ok := jc.ByOperation[operation]; ok {
+		return limit
+	}
+	return jc.Default
+}
+
+// graphRun executes the tasks of a graph of runTaskNodes. Tasks that depend
+// on a task that failed or was skipped are skipped too.
+type graphRun struct {
+	graph *deps.Graph
+	// If true, dependents run before their dependencies, such as when stopping
+	// components.
+	reverse bool
+	// Maximum number of tasks to run at once. Zero means no limit.
+	concurrency int
+	// If true, the graph is run one layer at a time, and no further layers are
+	// run once any task fails. Otherwise, each task is started as soon as the
+	// tasks it depends on have succeeded.
+	stopOnFailure bool
+}
+
+type graphRunResult struct {
+	done chan struct{}
+	// Set before done is closed.
+	failed      bool
+	skipMessage string
+}
+
+// execute runs the graph's tasks and returns the error of the first task to
+// fail, if any.
+func (r *graphRun) execute() error {
+	layers := r.graph.TopoSortedLayers()
+	if r.reverse {
+		for i, j := 0, len(layers)-1; i < j; i, j = i+1, j-1 {
+			layers[i], layers[j] = layers[j], layers[i]
+		}
+	}
+	var order []deps.Node
+	for _, layer := range layers {
+		order = append(order, layer...)
+	}
+	results := make(map[string]*graphRunResult, len(order))
+	for _, node := range order {
+		results[node.ID()] = &graphRunResult{
+			done: make(chan struct{}),
+		}
+	}
+	relation := "dependency"
+	if r.reverse {
+		relation = "dependent"
+	}
+
+	var sem chan struct{}
+	if r.concurrency > 0 {
+		sem = make(chan struct{}, r.concurrency)
+	}
+
+	var mx sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	var earlier []deps.Node
+	for _, layer := range layers {
+		for _, node := range layer {
+			runTask := node.(*runTaskNode)
+			result := results[runTask.name]
+			related := r.related(runTask.name)
+			// Tasks wait for those they depend on or, when stopping on failure, for
+			// all tasks of earlier layers.
+			awaited := earlier
+			if !r.stopOnFailure {
+				awaited = nil
+				for _, other := range order {
+					if _, ok := related[other.ID()]; ok {
+						awaited = append(awaited, other)
+					}
+				}
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer close(result.done)
+
+				for _, other := range awaited {
+					<-results[other.ID()].done
+				}
+				// Blame the earliest failed dependency, or else pass on why an earlier
+				// dependency was skipped.
+				skipMessage := ""
+				earlierFailed := false
+				for _, other := range awaited {
+					otherResult := results[other.ID()]
+					_, isRelated := related[other.ID()]
+					if otherResult.failed {
+						if isRelated {
+							skipMessage = fmt.Sprintf("skipped: %s %s failed", relation, other.ID())
+							break
+						}
+						earlierFailed = true
+					}
+					if isRelated && skipMessage == "" {
+						skipMessage = otherResult.skipMessage
+					}
+				}
+				if skipMessage == "" && earlierFailed {
+					skipMessage = "not run, since an earlier step failed"
+				}
+
+				if sem != nil && skipMessage == "" {
+					sem <- struct{}{}
+					defer func() { <-sem }()
+				}
+				if skipMessage == "" && runTask.task.Canceled() {
+					skipMessage = "not run, since the job was canceled"
+				}
+				if skipMessage != "" {
+					runTask.task.Skip(skipMessage)
+					result.skipMessage = skipMessage
+					return
+				}
+
+				runTask.task.Start()
+				defer runTask.task.Finish()
+				if err := runTask.run(runTask.task); err != nil {
+					runTask.task.Fail(err)
+					result.failed = true
+					mx.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("%s: %w", runTask.name, err)
+					}
+					mx.Unlock()
+					return
+				}
+				runTask.done = true
+			}()
+		}
+		// Copied, since the tasks of this layer hold on to the current slice.
+		earlier = append(append([]deps.Node{}, earlier...), layer...)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// related returns the nodes that the identified node must run after, directly
+// or transitively.
+func (r *graphRun) related(id string) map[string]deps.Node {
+	if r.reverse {
+		return r.graph.Dependents(id)
+	}
+	return r.graph.Dependencies(id)
+}
+
+// executeRunTasks runs the tasks of a graph of runTaskNodes, one layer at a
+// time, with at most concurrency tasks running at once unless concurrency is
+// zero. If any task of a layer fails, the tasks of subsequent layers are
+// skipped and the first error is returned.
+func executeRunTasks(g *deps.Graph, concurrency int) error {
+	r := &graphRun{
+		graph:         g,
+		concurrency:   concurrency,
+		stopOnFailure: true,
+	}
+	return r.execute()
+}
+
+// skipRunTasks reports all tasks of a graph of runTaskNodes as skipped.
+func skipRunTasks(g *deps.Graph, message string) {
+	for _, node := range g.Nodes() {
+		node.(*runTaskNode).task.Skip(message)
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/deref/exo/internal/deps"
+	"github.com/deref/exo/internal/task"
+	taskapi "github.com/deref/exo/internal/task/api"
+	taskserver "github.com/deref/exo/internal/task/server"
+	"github.com/deref/exo/internal/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphRunSkipsDependentsOfFailures(t *testing.T) {
+	ctx := context.Background()
+	store := taskserver.NewTaskStore()
+	tt := &task.TaskTracker{
+		Store:  store,
+		Logger: logging.Default(),
+	}
+	job := tt.StartTask(ctx, "job")
+
+	g := deps.New()
+	addNode := func(name string, err error) {
+		g.AddNode(&runTaskNode{
+			name: name,
+			task: job.CreateChild(name),
+			run: func(*task.Task) error {
+				return err
+			},
+		})
+	}
+	addNode("db", errors.New("boom"))
+	addNode("api", nil)
+	addNode("web", nil)
+	addNode("cache", nil)
+	addNode("worker", nil)
+	g.AddEdge("api", "db")
+	g.AddEdge("web", "api")
+	g.AddEdge("worker", "cache")
+
+	run := &graphRun{graph: g}
+	assert.EqualError(t, run.execute(), "db: boom")
+	require.NoError(t, job.Finish())
+
+	output, err := store.DescribeTasks(ctx, &taskapi.DescribeTasksInput{
+		JobIDs: []string{job.JobID()},
+	})
+	require.NoError(t, err)
+	results := make(map[string]string)
+	for _, description := range output.Tasks {
+		results[description.Name] = description.Status + " " + description.Message
+	}
+	assert.Equal(t, map[string]string{
+		"job":    "success ",
+		"db":     "failure boom",
+		"api":    "skipped skipped: dependency db failed",
+		"web":    "skipped skipped: dependency db failed",
+		"cache":  "success ",
+		"worker": "success ",
+	}, results)
+}
+
+func TestGraphRunConcurrency(t *testing.T) {
+	ctx := context.Background()
+	tt := &task.TaskTracker{
+		Store:  taskserver.NewTaskStore(),
+		Logger: logging.Default(),
+	}
+	job := tt.StartTask(ctx, "job")
+
+	var mx sync.Mutex
+	running, maxRunning := 0, 0
+	g := deps.New()
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		g.AddNode(&runTaskNode{
+			name: name,
+			task: job.CreateChild(name),
+			run: func(*task.Task) error {
+				mx.Lock()
+				running++
+				if maxRunning < running {
+					maxRunning = running
+				}
+				mx.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				mx.Lock()
+				running--
+				mx.Unlock()
+				return nil
+			},
+		})
+	}
+
+	run := &graphRun{graph: g, concurrency: 2}
+	require.NoError(t, run.execute())
+	assert.Equal(t, 2, maxRunning)
+	for _, node := range g.Nodes() {
+		assert.True(t, node.(*runTaskNode).done)
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/deref/exo/internal/chrono"
+	"github.com/deref/exo/internal/core/api"
+	state "github.com/deref/exo/internal/core/state/api"
+	eventd "github.com/deref/exo/internal/eventd/api"
+	"github.com/deref/exo/internal/providers/core/components/log"
+	"github.com/deref/exo/internal/util/errutil"
+)
+
+func (ws *Workspace) UndeleteComponents(ctx context.Context, input *api.UndeleteComponentsInput) (*api.UndeleteComponentsOutput, error) {
+	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{
+		Refs:           input.Refs,
+		IncludeDeleted: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describing components: %w", err)
+	}
+
+	// Resolve refs to tombstones, checking that their names are free before
+	// restoring any of them.
+	taken := make(map[string]bool)
+	for _, component := range describeOutput.Components {
+		if component.Deleted == "" {
+			taken[component.Name] = true
+		}
+	}
+	ids := make([]string, len(input.Refs))
+	for i, ref := range input.Refs {
+		tombstone := latestTombstone(describeOutput.Components, ref)
+		if tombstone == nil {
+			return nil, errutil.HTTPErrorf(http.StatusNotFound, "no deleted component %q", ref)
+		}
+		if taken[tombstone.Name] {
+			return nil, errutil.HTTPErrorf(http.StatusConflict, "component named %q already exists", tombstone.Name)
+		}
+		taken[tombstone.Name] = true
+		ids[i] = tombstone.ID
+	}
+
+	for _, id := range ids {
+		if _, err := ws.Store.UndeleteComponent(ctx, &state.UndeleteComponentInput{
+			ID: id,
+		}); err != nil {
+			return nil, fmt.Errorf("undeleting component %q: %w", id, err)
+		}
+	}
+
+	ws.logEventf(ctx, "undeleting components: %s", input.Refs)
+	query := makeComponentQuery(withRefs(ids...))
+	jobID := ws.controlEachComponent(ctx, "undeleting", query, func(component *api.ComponentDescription) interface{} {
+		return &api.InitializeInput{
+			Spec: component.Spec,
+		}
+	})
+	return &api.UndeleteComponentsOutput{
+		JobID: jobID,
+	}, nil
+}
+
+// latestTombstone returns the most recently deleted of the components with
+// the given ID or name, or nil if there is no such deleted component.
+func latestTombstone(components []api.ComponentDescription, ref string) *api.ComponentDescription {
+	var latest *api.ComponentDescription
+	var latestDeleted time.Time
+	for i, component := range components {
+		if component.Deleted == "" || (component.ID != ref && component.Name != ref) {
+			continue
+		}
+		deleted, _ := chrono.ParseIsoNano(component.Deleted)
+		if latest == nil || deleted.After(latestDeleted) {
+			latest = &components[i]
+			latestDeleted = deleted
+		}
+	}
+	return latest
+}
+
+// collectTombstones permanently removes components that were deleted before
+// the cutoff, along with their logs.
+func (ws *Workspace) collectTombstones(ctx context.Context, cutoff time.Time) {
+	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{
+		IncludeDeleted: true,
+	})
+	if err != nil {
+		ws.Logger.Infof("describing components of workspace %q: %v", ws.ID, err)
+		return
+	}
+	eventStore := log.CurrentEventStore(ctx)
+	for _, component := range describeOutput.Components {
+		if component.Deleted == "" {
+			continue
+		}
+		deleted, err := chrono.ParseIsoNano(component.Deleted)
+		if err != nil || deleted.After(cutoff) {
+			continue
+		}
+		if _, err := eventStore.ClearEvents(ctx, &eventd.ClearEventsInput{
+			Streams: []string{component.ID},
+		}); err != nil {
+			ws.Logger.Infof("clearing logs of deleted component %q: %v", component.ID, err)
+			continue
+		}
+		if _, err := ws.Store.RemoveComponent(ctx, &state.RemoveComponentInput{
+			ID: component.ID,
+		}); err != nil {
+			ws.Logger.Infof("removing deleted component %q: %v", component.ID, err)
+		}
+	}
+}
+
+// TombstoneCollector periodically removes the tombstones of components that
+// were deleted longer ago than the retention period.
+type TombstoneCollector struct {
+	Config    *Config
+	Retention time.Duration
+	Interval  time.Duration
+}
+
+// Run collects tombstones until the context is done.
+func (tc *TombstoneCollector) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(tc.Interval):
+		}
+		output, err := tc.Config.Store.DescribeWorkspaces(ctx, &state.DescribeWorkspacesInput{})
+		if err != nil {
+			tc.Config.Logger.Infof("describing workspaces to collect tombstones: %v", err)
+			continue
+		}
+		cutoff := chrono.Now(ctx).Add(-tc.Retention)
+		for _, workspace := range output.Workspaces {
+			tc.Config.newWorkspace(workspace.ID).collectTombstones(ctx, cutoff)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/deref/exo/internal/core/api"
+	state "github.com/deref/exo/internal/core/state/api"
+	"github.com/deref/exo/internal/manifest"
+	"github.com/deref/exo/internal/manifest/procfile"
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/fsnotify/fsnotify"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// How long to wait for a burst of file changes, such as those of an editor
+// saving a file, to settle before re-applying.
+const watchDebounce = 250 * time.Millisecond
+
+// setApplyWatch records the inputs of an apply as the workspace's apply-watch
+// setting. Manifest paths are resolved, so that the manifest can be re-read
+// from disk when it changes.
+func (ws *Workspace) setApplyWatch(ctx context.Context, rootDir string, input *api.ApplyInput) error {
+	watch := *input
+	watch.Manifest = nil
+	watch.DryRun = false
+	watch.Watch = false
+	if watch.ManifestPath == nil {
+		manifestPath, err := ws.resolveManifest(rootDir, watch.Format)
+		if err != nil {
+			return err
+		}
+		watch.ManifestPath = &manifestPath
+	} else {
+		manifestPath := resolveWorkspacePath(rootDir, *watch.ManifestPath)
+		watch.ManifestPath = &manifestPath
+	}
+	watch.Overrides = make([]api.ManifestFile, len(input.Overrides))
+	for i, override := range input.Overrides {
+		watch.Overrides[i] = api.ManifestFile{
+			Path: resolveWorkspacePath(rootDir, override.Path),
+		}
+	}
+	bs, err := json.Marshal(watch)
+	if err != nil {
+		return err
+	}
+	return ws.patchApplyWatch(ctx, string(bs))
+}
+
+func (ws *Workspace) patchApplyWatch(ctx context.Context, applyWatch string) error {
+	if _, err := ws.Store.PatchWorkspace(ctx, &state.PatchWorkspaceInput{
+		ID:         ws.ID,
+		ApplyWatch: &applyWatch,
+	}); err != nil {
+		return fmt.Errorf("patching workspace: %w", err)
+	}
+	return nil
+}
+
+func (ws *Workspace) Unwatch(ctx context.Context, input *api.UnwatchInput) (*api.UnwatchOutput, error) {
+	if err := ws.patchApplyWatch(ctx, ""); err != nil {
+		return nil, err
+	}
+	ws.logEventf(ctx, "stopped watching manifest")
+	return &api.UnwatchOutput{}, nil
+}
+
+// applyWatchFiles returns the paths of the files that affect the result of
+// applying a manifest: the manifest and its overrides, the workspace's .env
+// file, the files that the manifest includes, env files of containers and,
+// for Procfiles, the .foreman file and the env files it lists. Files need not
+// exist.
+func (ws *Workspace) applyWatchFiles(ctx context.Context, rootDir string, input *api.ApplyInput) []string {
+	files := map[string]bool{
+		filepath.Join(rootDir, ".env"): true,
+	}
+	manifestPath := ""
+	if input.ManifestPath != nil {
+		manifestPath = resolveWorkspacePath(rootDir, *input.ManifestPath)
+		files[manifestPath] = true
+	}
+	for _, override := range input.Overrides {
+		files[resolveWorkspacePath(rootDir, override.Path)] = true
+	}
+
+	format := input.Format
+	if format == "" {
+		format = manifest.GuessFormat(manifestPath)
+	}
+	switch format {
+	case "compose":
+		if len(input.Overrides) == 0 {
+			if overridePath, _ := manifest.ResolveOverride(manifestPath); overridePath != "" {
+				files[overridePath] = true
+			}
+		}
+	case "procfile":
+		dir := filepath.Dir(manifestPath)
+		files[filepath.Join(dir, procfile.ForemanOptionsFilename)] = true
+		if opts, err := procfile.ReadForemanOptions(dir); err == nil {
+			for _, envFile := range opts.EnvFiles() {
+				files[resolveWorkspacePath(dir, envFile)] = true
+			}
+		}
+	}
+
+	// Files included by the manifest, such as modules, extended compose files
+	// and files read by file(), are recorded as the manifest is loaded, even
+	// if it turns out to be invalid.
+	if loader, err := ws.newManifestLoader(ctx, rootDir, input); err == nil {
+		loader.ReadFile = func(path string) ([]byte, error) {
+			files[path] = true
+			return ioutil.ReadFile(path)
+		}
+		// Env files are read when containers are created, so are found in the
+		// container specs, relative to the workspace root.
+		if m, err := loader.Load(); err == nil {
+			components := m.Components()
+			for i := 0; i < components.Len(); i++ {
+				component := components.Index(i)
+				if component.Type() != "container" || component.Deferred() {
+					continue
+				}
+				var spec compose.Service
+				if err := docker.LoadSpec(component.Spec(), &spec, nil); err != nil {
+					continue
+				}
+				for _, envFile := range spec.EnvFile.Items {
+					files[resolveWorkspacePath(rootDir, envFile.Value)] = true
+				}
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, filepath.Clean(path))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// reapply applies the manifest of an apply-watch setting. Since this is not
+// in response to a request, problems are reported as workspace events. An
+// invalid manifest is reported without changing any components.
+func (ws *Workspace) reapply(ctx context.Context, input api.ApplyInput) {
+	output, err := ws.Apply(ctx, &input)
+	if err != nil {
+		var diags hcl.Diagnostics
+		if errors.As(err, &diags) {
+			for _, diag := range diags {
+				ws.logEventf(ctx, "manifest changed, but is invalid: %s", diag.Error())
+			}
+		} else {
+			ws.logEventf(ctx, "manifest changed, but could not be applied: %v", err)
+		}
+		return
+	}
+	for _, warning := range output.Warnings {
+		ws.logEventf(ctx, "manifest warning: %s", warning)
+	}
+}
+
+// ManifestWatcher re-applies the manifests of workspaces that have an
+// apply-watch setting whenever their files change.
+type ManifestWatcher struct {
+	Config *Config
+	// Interval at which apply-watch settings are checked for changes.
+	PollInterval time.Duration
+}
+
+type runningWatch struct {
+	setting string
+	cancel  func()
+}
+
+// Run watches manifests until the context is done.
+func (mw *ManifestWatcher) Run(ctx context.Context) {
+	running := make(map[string]*runningWatch)
+	defer func() {
+		for _, watch := range running {
+			watch.cancel()
+		}
+	}()
+	for {
+		output, err := mw.Config.Store.DescribeWorkspaces(ctx, &state.DescribeWorkspacesInput{})
+		if err != nil {
+			mw.Config.Logger.Infof("describing workspaces to watch: %v", err)
+		} else {
+			settings := make(map[string]string, len(output.Workspaces))
+			for _, workspace := range output.Workspaces {
+				if workspace.ApplyWatch != "" {
+					settings[workspace.ID] = workspace.ApplyWatch
+				}
+			}
+			for id, watch := range running {
+				if settings[id] != watch.setting {
+					watch.cancel()
+					delete(running, id)
+				}
+			}
+			for id, setting := range settings {
+				if running[id] != nil {
+					continue
+				}
+				var input api.ApplyInput
+				if err := json.Unmarshal([]byte(setting), &input); err != nil {
+					mw.Config.Logger.Infof("invalid apply-watch setting of workspace %q: %v", id, err)
+					continue
+				}
+				ctx, cancel := context.WithCancel(ctx)
+				running[id] = &runningWatch{
+					setting: setting,
+					cancel:  cancel,
+				}
+				go mw.watchWorkspace(ctx, id, input)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(mw.PollInterval):
+		}
+	}
+}
+
+func (mw *ManifestWatcher) watchWorkspace(ctx context.Context, id string, input api.ApplyInput) {
+	cfg := mw.Config
+	ws := cfg.newWorkspace(id)
+	description, err := ws.describe(ctx)
+	if err != nil {
+		cfg.Logger.Infof("describing watched workspace %q: %v", id, err)
+		return
+	}
+	rootDir := description.Root
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		ws.logEventf(ctx, "cannot watch manifest: %v", err)
+		return
+	}
+	defer watcher.Close()
+
+	// Directories are watched, rather than files, since editors often save
+	// by replacing files, and so that files that do not yet exist are seen
+	// once they are created.
+	dirs := make(map[string]bool)
+	files := make(map[string]bool)
+	updateWatches := func() {
+		files = make(map[string]bool)
+		wantDirs := make(map[string]bool)
+		for _, file := range ws.applyWatchFiles(ctx, rootDir, &input) {
+			files[file] = true
+			wantDirs[filepath.Dir(file)] = true
+		}
+		for dir := range dirs {
+			if !wantDirs[dir] {
+				_ = watcher.Remove(dir)
+				delete(dirs, dir)
+			}
+		}
+		for dir := range wantDirs {
+			if dirs[dir] {
+				continue
+			}
+			if err := watcher.Add(dir); err != nil {
+				cfg.Logger.Infof("watching %q: %v", dir, err)
+				continue
+			}
+			dirs[dir] = true
+		}
+	}
+	updateWatches()
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-watcher.Errors:
+			cfg.Logger.Infof("watching manifest of workspace %q: %v", id, err)
+		case event := <-watcher.Events:
+			if files[filepath.Clean(event.Name)] && debounce == nil {
+				debounce = time.After(watchDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			ws.logEventf(ctx, "manifest changed, re-applying")
+			ws.reapply(ctx, input)
+			// The set of env files may have changed.
+			updateWatches()
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deref/exo/internal/core/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyWatchFiles(t *testing.T) {
+	rootDir := t.TempDir()
+	writeFile := func(name, content string) {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(rootDir, name), []byte(content), 0600))
+	}
+	writeFile("docker-compose.yml", `
+services:
+  web:
+    image: nginx
+    env_file: web.env
+`)
+	writeFile("docker-compose.override.yml", `
+services:
+  web:
+    environment:
+      DEBUG: "1"
+`)
+
+	ws := newTestWorkspace(t, rootDir)
+	manifestPath := filepath.Join(rootDir, "docker-compose.yml")
+	files := ws.applyWatchFiles(context.Background(), rootDir, &api.ApplyInput{
+		ManifestPath: &manifestPath,
+	})
+	assert.Equal(t, []string{
+		filepath.Join(rootDir, ".env"),
+		filepath.Join(rootDir, "docker-compose.override.yml"),
+		filepath.Join(rootDir, "docker-compose.yml"),
+		filepath.Join(rootDir, "web.env"),
+	}, files)
+}
+
+func TestApplyWatchFilesIncludes(t *testing.T) {
+	rootDir := t.TempDir()
+	writeFile := func(name, content string) {
+		path := filepath.Join(rootDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	}
+	writeFile("exo.hcl", `
+exo = "0.1"
+
+module "db" {
+  source = "db/docker-compose.yml"
+}
+
+module "api" {
+  source = "api"
+}
+
+components {
+  process "web" {
+    program   = "server"
+    arguments = [file("web.conf"), templatefile("web.tmpl", {})]
+  }
+}
+`)
+	writeFile("web.conf", "port = 80")
+	writeFile("web.tmpl", "debug")
+	writeFile("db/docker-compose.yml", `
+services:
+  db:
+    extends:
+      file: base.yml
+      service: db
+`)
+	writeFile("api/Procfile", "api: ./api\n")
+	writeFile("db/base.yml", `
+services:
+  db:
+    image: postgres
+`)
+
+	ws := newTestWorkspace(t, rootDir)
+	manifestPath := filepath.Join(rootDir, "exo.hcl")
+	files := ws.applyWatchFiles(context.Background(), rootDir, &api.ApplyInput{
+		ManifestPath: &manifestPath,
+	})
+	assert.Equal(t, []string{
+		filepath.Join(rootDir, ".env"),
+		// Manifests that would take precedence are watched for.
+		filepath.Join(rootDir, "api/.foreman"),
+		filepath.Join(rootDir, "api/Procfile"),
+		filepath.Join(rootDir, "api/compose.yaml"),
+		filepath.Join(rootDir, "api/compose.yml"),
+		filepath.Join(rootDir, "api/docker-compose.yaml"),
+		filepath.Join(rootDir, "api/docker-compose.yml"),
+		filepath.Join(rootDir, "api/exo.hcl"),
+		filepath.Join(rootDir, "db/base.yml"),
+		filepath.Join(rootDir, "db/docker-compose.yml"),
+		filepath.Join(rootDir, "exo.hcl"),
+		filepath.Join(rootDir, "web.conf"),
+		filepath.Join(rootDir, "web.tmpl"),
+	}, files)
+}
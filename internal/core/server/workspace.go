@@ -10,7 +10,6 @@ import (
 	"os"
 	"path"
 	"strings"
-	"sync"
 
 	"github.com/deref/exo/internal/chrono"
 	"github.com/deref/exo/internal/core/api"
@@ -20,6 +19,7 @@ import (
 	eventd "github.com/deref/exo/internal/eventd/api"
 	"github.com/deref/exo/internal/gensym"
 	josh "github.com/deref/exo/internal/josh/server"
+	"github.com/deref/exo/internal/manifest"
 	"github.com/deref/exo/internal/manifest/exohcl"
 	"github.com/deref/exo/internal/manifest/procfile"
 	"github.com/deref/exo/internal/providers/core"
@@ -31,6 +31,7 @@ import (
 	"github.com/deref/exo/internal/providers/docker/components/volume"
 	"github.com/deref/exo/internal/providers/unix/components/process"
 	"github.com/deref/exo/internal/task"
+	"github.com/deref/exo/internal/util/contextutil"
 	"github.com/deref/exo/internal/util/errutil"
 	"github.com/deref/exo/internal/util/jsonutil"
 	"github.com/deref/exo/internal/util/logging"
@@ -41,14 +42,15 @@ import (
 )
 
 type Workspace struct {
-	ID          string
-	VarDir      string
-	Store       state.Store
-	SyslogPort  uint
-	Logger      logging.Logger // TODO: Embed in context, so it can be annotated with request info.
-	Docker      *dockerclient.Client
-	TaskTracker *task.TaskTracker
-	EsvClient   esv.EsvClient
+	ID             string
+	VarDir         string
+	Store          state.Store
+	SyslogPort     uint
+	Logger         logging.Logger // TODO: Embed in context, so it can be annotated with request info.
+	Docker         *dockerclient.Client
+	TaskTracker    *task.TaskTracker
+	EsvClient      esv.EsvClient
+	JobConcurrency JobConcurrency
 }
 
 var _ api.Workspace = &Workspace{}
@@ -156,17 +158,19 @@ func (ws *Workspace) describe(ctx context.Context) (*api.WorkspaceDescription, e
 		ID:          ws.ID,
 		Root:        desc.Root,
 		DisplayName: desc.DisplayName,
+		Watching:    desc.ApplyWatch != "",
+		DriftPolicy: driftPolicy(desc.DriftPolicy),
 	}, nil
 }
 
 func (ws *Workspace) Destroy(ctx context.Context, input *api.DestroyInput) (*api.DestroyOutput, error) {
-	job := ws.TaskTracker.StartTask(ctx, "destroying")
+	job := ws.TaskTracker.StartWorkspaceTask(ctx, ws.ID, "destroying")
 	ws.logEventf(ctx, "destroying workspace... %s", job.JobID())
 	query := makeComponentQuery(withReversedDependencies)
 
 	go func() {
 		defer job.Finish()
-		ws.goControlComponents(job, query, func(*api.ComponentDescription) interface{} {
+		ws.goControlComponents(job, "destroying", query, func(*api.ComponentDescription) interface{} {
 			return &api.DestroyInput{}
 		})
 		if err := job.Wait(); err != nil {
@@ -189,7 +193,7 @@ func (ws *Workspace) Apply(ctx context.Context, input *api.ApplyInput) (*api.App
 	if err != nil {
 		return nil, fmt.Errorf("describing workspace: %w", err)
 	}
-	m, err := ws.loadManifest(description.Root, input)
+	m, err := ws.loadManifest(ctx, description.Root, input)
 
 	var diags hcl.Diagnostics
 	invalidManifest := false
@@ -201,8 +205,7 @@ func (ws *Workspace) Apply(ctx context.Context, input *api.ApplyInput) (*api.App
 	if invalidManifest {
 		return nil, errutil.WithHTTPStatus(http.StatusBadRequest, err)
 	}
-	manifestComponents := m.Components()
-	numComponents := manifestComponents.Len()
+	manifestComponents, inactiveComponents := activeManifestComponents(m, input.Profiles)
 
 	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{})
 	if err != nil {
@@ -214,132 +217,122 @@ func (ws *Workspace) Apply(ctx context.Context, input *api.ApplyInput) (*api.App
 		oldComponents[oldComponent.Name] = oldComponent
 	}
 
-	// TODO: Handle partial failures.
-	job := ws.TaskTracker.StartTask(ctx, "applying")
-	ws.logEventf(ctx, "applying manifest... %s", job.JobID())
+	plan := planApply(manifestComponents, inactiveComponents, oldComponents)
 
-	// The algorithm for applying a new manifest is as follows:
-	// 1. Build dependency graph for the new manifest, allComponents.
-	// 2. Create empty dependency graphs for deletions, deleteGraph, and for creations, createGraph.
-	// 3. Iterate all components in the old graph, and for each component that does not exist in the new graph:
-	//   3.1. Add a node to deleteGraph
-	//   3.2. For each dependency of node, add an _inverted_ edge for each dependency of that node. This
-	//        allows us to check whether deleting these nodes would leave the graph with unmet dependencies.
-	// 4. Check whether deleteGraph has unmet dependencies. If so, return an error because applying deletions would
-	//    leave the graph with unmet dependencies.
-	// 5. Create a set to track components to update, updateSet.
-	// 6. Walk the new components.
-	//   6.1. If the component already exists, get this component and all components that depend on it. For each,
-	//     6.1.1. Check if the component is in updateSet. If not, add a delete task to deleteGraph and a create task
-	//            to createGraph, and add the component to updateSet.
-	//   6.2. Otherwise, add a task to create the component to createGraph.
-	// 7. Apply all deletes in topographic order.
-	// 8. Apply all creates in topographic order.
-
-	// 1.
-	allComponents := deps.New()
-	for i := 0; i < numComponents; i++ {
-		c := manifestComponents.Index(i)
-		allComponents.AddNode(&componentNode{
-			component: c,
-		})
-		for _, dependency := range c.DependsOn() {
-			allComponents.AddEdge(c.Name(), dependency)
-		}
+	output := api.ApplyOutput{
+		Warnings: make([]string, len(diags)),
+		Plan:     make([]api.PlannedChange, len(plan)),
+	}
+	for i, diag := range diags {
+		output.Warnings[i] = diag.Error()
+	}
+	for i, change := range plan {
+		output.Plan[i] = change.PlannedChange
 	}
 
-	// 2.
-	createGraph := deps.New()
-	deleteGraph := deps.New()
-
-	// 3.
-	for name, oldComponent := range oldComponents {
-		if allComponents.HasNode(name) {
+	// Check whether deleting would leave the graph with unmet dependencies. This
+	// is also reported by dry runs, since the plan would fail.
+	deleteCheck := deps.New()
+	for _, change := range plan {
+		if change.Action != planDelete {
 			continue
 		}
-
-		deleteGraph.AddNode(&runTaskNode{
-			name: name,
-			task: job.CreateChild("deleting " + name),
-			run: func(t *task.Task) error {
-				return ws.control(job.Context, oldComponent, &api.DestroyInput{})
-			},
-		})
-		// Invert the dependencies for deletions so that we can check whether there is anything
-		// left in the graph that still depends on something that we want to delete.
-		for _, dependency := range oldComponent.DependsOn {
-			deleteGraph.AddEdge(dependency, name)
+		deleteCheck.AddNode(deps.StringNode(change.Name))
+		for _, dependency := range change.oldComponent.DependsOn {
+			deleteCheck.AddEdge(dependency, change.Name)
 		}
 	}
-
-	// 4.
-	unmetDeps := deleteGraph.UnmetDependencies()
-	if len(unmetDeps) > 0 {
+	if unmetDeps := deleteCheck.UnmetDependencies(); len(unmetDeps) > 0 {
 		return nil, fmt.Errorf("would remove components that are still depended on: %s", strings.Join(unmetDeps, ", "))
 	}
 
-	// 5.
-	updateSet := make(map[string]struct{})
+	if input.DryRun {
+		return &output, nil
+	}
 
-	recreateComponentOnce := func(name string, oldComponent api.ComponentDescription, newComponent *exohcl.Component) {
-		// 6.1.1.
-		if _, alreadyUpdated := updateSet[name]; alreadyUpdated {
-			return
+	if input.Watch {
+		if err := ws.setApplyWatch(ctx, description.Root, input); err != nil {
+			return nil, fmt.Errorf("watching manifest: %w", err)
 		}
+	}
+
+	job := ws.TaskTracker.StartWorkspaceTask(ctx, ws.ID, "applying")
+	ws.logEventf(ctx, "applying manifest... %s", job.JobID())
 
-		deleteGraph.AddNode(&runTaskNode{
+	// Deletions, including those of replaced components, are performed in
+	// reverse dependency order, then creations in dependency order. Updated
+	// components are patched along with the creations. Each graph is executed
+	// one layer at a time, stopping after the first layer with a failure.
+	createGraph := deps.New()
+	deleteGraph := deps.New()
+	createNodes := make(map[string]*runTaskNode)
+	deleteNodes := make(map[string]*runTaskNode)
+
+	addDelete := func(change *plannedChange) {
+		name := change.Name
+		oldComponent := *change.oldComponent
+		node := &runTaskNode{
 			name: name,
 			task: job.CreateChild("deleting " + name),
 			run: func(t *task.Task) error {
 				return ws.control(job.Context, oldComponent, &api.DestroyInput{})
 			},
-		})
+		}
+		deleteGraph.AddNode(node)
+		deleteNodes[name] = node
+		// Invert the dependencies for deletions so that dependents are deleted
+		// before their dependencies.
 		for _, dependency := range oldComponent.DependsOn {
 			deleteGraph.AddEdge(dependency, name)
 		}
+	}
 
-		createGraph.AddNode(&runTaskNode{
+	addCreate := func(change *plannedChange, label string, run func(t *task.Task) error) {
+		name := change.Name
+		node := &runTaskNode{
 			name: name,
-			task: job.CreateChild("re-creating " + name),
-			run: func(t *task.Task) error {
-				// Should the replacement component get the old component's ID?
-				return ws.createComponent(t, manifestComponentToCreate(newComponent), gensym.RandomBase32())
-			},
-		})
-		for _, dependency := range newComponent.DependsOn() {
+			task: job.CreateChild(label + " " + name),
+			run:  run,
+		}
+		createGraph.AddNode(node)
+		createNodes[name] = node
+		for _, dependency := range change.newComponent.DependsOn() {
 			createGraph.AddEdge(name, dependency)
 		}
-		updateSet[name] = struct{}{}
 	}
 
-	// 6.
-	for i := 0; i < numComponents; i++ {
-		newComponent := manifestComponents.Index(i)
-		name := newComponent.Name()
-
-		if oldComponent, exists := oldComponents[name]; exists {
-			name := name
-			// 6.1.
-			recreateComponentOnce(name, oldComponent, newComponent)
-
-			componentsForUpdate := allComponents.Dependents(name)
-			for updatedComponentName, componentForUpdate := range componentsForUpdate {
-				forDelete := oldComponents[updatedComponentName]
-				forCreate := componentForUpdate.(*componentNode).component
-				recreateComponentOnce(updatedComponentName, forDelete, forCreate)
+	// IDs of created components, so that a rollback can find and delete them.
+	newIDs := make(map[string]string)
+	createComponent := func(change *plannedChange) func(t *task.Task) error {
+		id := gensym.RandomBase32()
+		newIDs[change.Name] = id
+		return func(t *task.Task) error {
+			input, err := ws.manifestComponentToCreate(t, change.newComponent)
+			if err != nil {
+				return err
 			}
-		} else {
-			// 6.2.
-			createGraph.AddNode(&runTaskNode{
-				name: name,
-				task: job.CreateChild("adding " + name),
-				run: func(t *task.Task) error {
-					return ws.createComponent(t, manifestComponentToCreate(newComponent), gensym.RandomBase32())
-				},
+			return ws.createComponent(t, input, id, specSourceApply)
+		}
+	}
+
+	for _, change := range plan {
+		change := change
+		switch change.Action {
+		case planCreate:
+			addCreate(change, "adding", createComponent(change))
+		case planReplace:
+			addDelete(change)
+			addCreate(change, "re-creating", createComponent(change))
+		case planUpdate:
+			addCreate(change, "updating", func(t *task.Task) error {
+				input, err := ws.manifestComponentToCreate(t, change.newComponent)
+				if err != nil {
+					return err
+				}
+				return ws.updateComponent(t, *change.oldComponent, input.Spec, input.DependsOn, specSourceApply)
 			})
-			for _, dependency := range newComponent.DependsOn() {
-				createGraph.AddEdge(name, dependency)
-			}
+		case planDelete:
+			addDelete(change)
 		}
 	}
 
@@ -347,38 +340,179 @@ func (ws *Workspace) Apply(ctx context.Context, input *api.ApplyInput) (*api.App
 	go func() {
 		defer job.Finish()
 
-		executeRunTasks(deleteGraph)
-		executeRunTasks(createGraph)
+		concurrency := ws.JobConcurrency.limit("applying")
+		err := executeRunTasks(deleteGraph, concurrency)
+		if err == nil {
+			err = executeRunTasks(createGraph, concurrency)
+		} else {
+			skipRunTasks(createGraph, "not applied, since deleting failed")
+		}
+		if err == nil {
+			return
+		}
+		job.Fail(fmt.Errorf("applying: %w", err))
+		// A canceled apply is left as is, rather than rolled back.
+		if input.Rollback && !job.Canceled() {
+			ws.rollbackApply(job, plan, createNodes, deleteNodes, newIDs)
+		}
 	}()
 
-	output := api.ApplyOutput{
-		Warnings: make([]string, len(diags)),
-		JobID:    job.ID(),
-	}
-	for i, diag := range diags {
-		output.Warnings[i] = diag.Error()
-	}
+	output.JobID = job.ID()
 	return &output, nil
 }
 
-func executeRunTasks(g *deps.Graph) {
-	layers := g.TopoSortedLayers()
-	for _, layer := range layers {
-		var wg sync.WaitGroup
-		for _, node := range layer {
-			runTask := node.(*runTaskNode)
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				runTask.task.Start()
-				defer runTask.task.Finish()
-				if err := runTask.run(runTask.task); err != nil {
-					runTask.task.Fail(err)
+// rollbackApply restores the components changed by a failed apply. Created
+// components are deleted and updated components are updated back to their
+// prior specs and dependencies, then deleted components are re-created from
+// their prior specs, keeping their IDs. Since a failed step may have been
+// partially performed, the store is consulted for the current components.
+func (ws *Workspace) rollbackApply(job *task.Task, plan []*plannedChange, createNodes, deleteNodes map[string]*runTaskNode, newIDs map[string]string) {
+	t := job.StartChild("rolling back")
+	defer t.Finish()
+
+	describeOutput, err := ws.DescribeComponents(t, &api.DescribeComponentsInput{})
+	if err != nil {
+		t.Fail(fmt.Errorf("describing components: %w", err))
+		return
+	}
+	current := make(map[string]api.ComponentDescription, len(describeOutput.Components))
+	for _, component := range describeOutput.Components {
+		current[component.ID] = component
+	}
+
+	undoCreates := deps.New()
+	restoreDeletes := deps.New()
+	for _, change := range plan {
+		change := change
+		name := change.Name
+		if createNodes[name] != nil {
+			var undo *runTaskNode
+			if change.Action == planUpdate {
+				oldComponent := *change.oldComponent
+				component, exists := current[oldComponent.ID]
+				if exists && (component.Spec != oldComponent.Spec || !sameNames(component.DependsOn, oldComponent.DependsOn)) {
+					undo = &runTaskNode{
+						name: name,
+						task: t.CreateChild("restoring " + name),
+						run: func(t *task.Task) error {
+							return ws.updateComponent(t, component, oldComponent.Spec, oldComponent.DependsOn, specSourceApply)
+						},
+					}
+				}
+			} else if component, exists := current[newIDs[name]]; exists {
+				undo = &runTaskNode{
+					name: name,
+					task: t.CreateChild("deleting " + name),
+					run: func(t *task.Task) error {
+						return ws.control(t, component, &api.DestroyInput{})
+					},
 				}
-			}()
+			}
+			if undo != nil {
+				undoCreates.AddNode(undo)
+				for _, dependency := range change.newComponent.DependsOn() {
+					undoCreates.AddEdge(dependency, name)
+				}
+			}
+		}
+		if deleteNodes[name] != nil {
+			oldComponent := *change.oldComponent
+			if _, exists := current[oldComponent.ID]; !exists {
+				restoreDeletes.AddNode(&runTaskNode{
+					name: name,
+					task: t.CreateChild("restoring " + name),
+					run: func(t *task.Task) error {
+						return ws.createComponent(t, &api.CreateComponentInput{
+							Name:      oldComponent.Name,
+							Type:      oldComponent.Type,
+							Spec:      oldComponent.Spec,
+							DependsOn: oldComponent.DependsOn,
+						}, oldComponent.ID, specSourceApply)
+					},
+				})
+				for _, dependency := range oldComponent.DependsOn {
+					restoreDeletes.AddEdge(name, dependency)
+				}
+			}
 		}
-		wg.Wait()
 	}
+
+	concurrency := ws.JobConcurrency.limit("applying")
+	if err := executeRunTasks(undoCreates, concurrency); err != nil {
+		skipRunTasks(restoreDeletes, "not restored, since undoing changes failed")
+		t.Fail(err)
+		return
+	}
+	if err := executeRunTasks(restoreDeletes, concurrency); err != nil {
+		t.Fail(err)
+	}
+}
+
+// updateComponent records a component's new spec and dependencies, then, if
+// the spec changed, has its provider update it in place.
+func (ws *Workspace) updateComponent(ctx context.Context, component api.ComponentDescription, spec string, dependsOn []string, source string) error {
+	patch := &state.PatchComponentInput{
+		ID:         component.ID,
+		DependsOn:  &dependsOn,
+		SpecSource: source,
+	}
+	specChanged := spec != component.Spec
+	if specChanged {
+		patch.Spec = spec
+	}
+	if _, err := ws.Store.PatchComponent(ctx, patch); err != nil {
+		return fmt.Errorf("patching component: %w", err)
+	}
+	if !specChanged {
+		return nil
+	}
+	oldSpec := component.Spec
+	component.Spec = spec
+	component.DependsOn = dependsOn
+	return ws.control(ctx, component, &api.UpdateInput{
+		OldSpec: oldSpec,
+		Spec:    spec,
+	})
+}
+
+// activeManifestComponents partitions the manifest's components in to those
+// that are active given the selected profiles, plus their dependencies, and
+// the names of those that are inactive.
+func activeManifestComponents(m *exohcl.Manifest, profiles []string) (active []*exohcl.Component, inactive map[string]struct{}) {
+	components := m.Components()
+	byName := make(map[string]*exohcl.Component, components.Len())
+	var pending []string
+	for i := 0; i < components.Len(); i++ {
+		c := components.Index(i)
+		byName[c.Name()] = c
+		if c.InProfiles(profiles) {
+			pending = append(pending, c.Name())
+		}
+	}
+
+	activeNames := make(map[string]struct{}, len(byName))
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		if _, ok := activeNames[name]; ok {
+			continue
+		}
+		activeNames[name] = struct{}{}
+		if c, ok := byName[name]; ok {
+			pending = append(pending, c.DependsOn()...)
+		}
+	}
+
+	inactive = make(map[string]struct{})
+	for i := 0; i < components.Len(); i++ {
+		c := components.Index(i)
+		if _, ok := activeNames[c.Name()]; ok {
+			active = append(active, c)
+		} else {
+			inactive[c.Name()] = struct{}{}
+		}
+	}
+	return active, inactive
 }
 
 func (ws *Workspace) Resolve(ctx context.Context, input *api.ResolveInput) (*api.ResolveOutput, error) {
@@ -404,6 +538,7 @@ func (ws *Workspace) DescribeComponents(ctx context.Context, input *api.Describe
 		Types:               input.Types,
 		IncludeDependencies: input.IncludeDependencies,
 		IncludeDependents:   input.IncludeDependents,
+		IncludeDeleted:      input.IncludeDeleted,
 	})
 	if err != nil {
 		return nil, err
@@ -421,6 +556,7 @@ func (ws *Workspace) DescribeComponents(ctx context.Context, input *api.Describe
 			State:     component.State,
 			Created:   component.Created,
 			DependsOn: component.DependsOn,
+			Deleted:   component.Deleted,
 		}
 	}
 	return output, nil
@@ -505,11 +641,11 @@ func (ws *Workspace) DescribeEnvironment(ctx context.Context, input *api.Describ
 func (ws *Workspace) CreateComponent(ctx context.Context, input *api.CreateComponentInput) (*api.CreateComponentOutput, error) {
 	id := gensym.RandomBase32()
 
-	job := ws.TaskTracker.StartTask(ctx, "creating "+input.Name)
+	job := ws.TaskTracker.StartWorkspaceTask(ctx, ws.ID, "creating "+input.Name)
 	go func() {
 		defer job.Finish()
 
-		err := ws.createComponent(ctx, input, id)
+		err := ws.createComponent(ctx, input, id, specSourceAPI)
 		if err != nil {
 			ws.logEventf(ctx, "error creating %s: %v", input.Name, err)
 			job.Fail(err)
@@ -524,7 +660,7 @@ func (ws *Workspace) CreateComponent(ctx context.Context, input *api.CreateCompo
 	}, nil
 }
 
-func (ws *Workspace) createComponent(ctx context.Context, input *api.CreateComponentInput, id string) error {
+func (ws *Workspace) createComponent(ctx context.Context, input *api.CreateComponentInput, id string, source string) error {
 	if err := exohcl.ValidateName(input.Name); err != nil {
 		return errutil.HTTPErrorf(http.StatusBadRequest, "component name %q invalid: %w", input.Name, err)
 	}
@@ -537,6 +673,7 @@ func (ws *Workspace) createComponent(ctx context.Context, input *api.CreateCompo
 		Spec:        input.Spec,
 		Created:     chrono.NowString(ctx),
 		DependsOn:   input.DependsOn,
+		SpecSource:  source,
 	}); err != nil {
 		return fmt.Errorf("adding component: %w", err)
 	}
@@ -556,13 +693,36 @@ func (ws *Workspace) createComponent(ctx context.Context, input *api.CreateCompo
 	})
 }
 
-func manifestComponentToCreate(c *exohcl.Component) *api.CreateComponentInput {
+func (ws *Workspace) manifestComponentToCreate(ctx context.Context, c *exohcl.Component) (*api.CreateComponentInput, error) {
+	spec := c.Spec()
+	if c.Deferred() {
+		// The spec refers to the state of its dependencies, which are
+		// guaranteed to have been created by now.
+		describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{
+			Refs: c.DependsOn(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("describing dependencies: %w", err)
+		}
+		instances := make(map[string]exohcl.ComponentInstance, len(describeOutput.Components))
+		for _, dependency := range describeOutput.Components {
+			instances[dependency.Name] = exohcl.ComponentInstance{
+				Spec:  dependency.Spec,
+				State: dependency.State,
+			}
+		}
+		var diags hcl.Diagnostics
+		spec, diags = c.EvalSpec(instances)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("evaluating spec: %w", diags)
+		}
+	}
 	return &api.CreateComponentInput{
 		Type:      c.Type(),
 		Name:      c.Name(),
-		Spec:      c.Spec(),
+		Spec:      spec,
 		DependsOn: c.DependsOn(),
-	}
+	}, nil
 }
 
 func (ws *Workspace) UpdateComponent(ctx context.Context, input *api.UpdateComponentInput) (*api.UpdateComponentOutput, error) {
@@ -577,7 +737,11 @@ func (ws *Workspace) UpdateComponent(ctx context.Context, input *api.UpdateCompo
 	oldComponent := describeOutput.Components[0]
 	newComponent := oldComponent
 	patch := state.PatchComponentInput{
-		ID: oldComponent.ID,
+		ID:         oldComponent.ID,
+		SpecSource: input.Source,
+	}
+	if patch.SpecSource == "" {
+		patch.SpecSource = specSourceAPI
 	}
 
 	if input.Name != "" {
@@ -602,22 +766,18 @@ func (ws *Workspace) UpdateComponent(ctx context.Context, input *api.UpdateCompo
 	}
 
 	ws.logEventf(ctx, "updating %s", oldComponent.Name)
-	job := ws.TaskTracker.StartTask(ctx, "updating")
+	job := ws.TaskTracker.StartWorkspaceTask(ctx, ws.ID, "updating")
 	go func() {
 		defer job.Finish()
-		// TODO: Most updates should be accomplished without a full replacement; especially when there are no spec changes!
-		job.Go("dispose old", func(disposeTask *task.Task) error {
-			// TODO: Fix this really awkward way of encoding a waterfall of async steps.
-			job.Go("initialize new", func(initializeTask *task.Task) error {
-				if err := disposeTask.Wait(); err != nil {
-					return context.Canceled
-				}
-				return ws.control(ctx, newComponent, &api.InitializeInput{
-					Spec: newComponent.Spec,
-				})
-			})
-			return ws.control(ctx, oldComponent, &api.DisposeInput{})
-		})
+		if newComponent.Spec == oldComponent.Spec {
+			return
+		}
+		if err := ws.control(job, newComponent, &api.UpdateInput{
+			OldSpec: oldComponent.Spec,
+			Spec:    newComponent.Spec,
+		}); err != nil {
+			job.Fail(err)
+		}
 	}()
 
 	return &api.UpdateComponentOutput{
@@ -1036,6 +1196,34 @@ func (ws *Workspace) ExportProcfile(ctx context.Context, input *api.ExportProcfi
 	}, nil
 }
 
+func (ws *Workspace) ExportManifest(ctx context.Context, input *api.ExportManifestInput) (*api.ExportManifestOutput, error) {
+	describeOutput, err := ws.DescribeComponents(ctx, &api.DescribeComponentsInput{})
+	if err != nil {
+		return nil, fmt.Errorf("describing components: %w", err)
+	}
+	components := make([]manifest.Component, len(describeOutput.Components))
+	for i, component := range describeOutput.Components {
+		components[i] = manifest.Component{
+			Name:      component.Name,
+			Type:      component.Type,
+			Spec:      component.Spec,
+			DependsOn: component.DependsOn,
+		}
+	}
+	bs, diags := manifest.Export(input.Format, components)
+	if diags.HasErrors() {
+		return nil, errutil.WithHTTPStatus(http.StatusBadRequest, diags)
+	}
+	output := &api.ExportManifestOutput{
+		Manifest: string(bs),
+		Warnings: make([]string, len(diags)),
+	}
+	for i, diag := range diags {
+		output.Warnings[i] = diag.Error()
+	}
+	return output, nil
+}
+
 func (ws *Workspace) ReadFile(ctx context.Context, input *api.ReadFileInput) (*api.ReadFileOutput, error) {
 	resolvedPath, err := ws.resolveWorkspacePath(ctx, input.Path)
 	if err != nil {
@@ -1089,15 +1277,15 @@ func (ws *Workspace) resolveWorkspacePath(ctx context.Context, relativePath stri
 }
 
 func (ws *Workspace) controlEachComponent(ctx context.Context, label string, query componentQuery, makeMessage func(*api.ComponentDescription) interface{}, onErr ...func(*api.ComponentDescription, error)) (jobID string) {
-	job := ws.TaskTracker.StartTask(ctx, label)
+	job := ws.TaskTracker.StartWorkspaceTask(ctx, ws.ID, label)
 	go func() {
 		defer job.Finish()
-		ws.goControlComponents(job, query, makeMessage, onErr...)
+		ws.goControlComponents(job, label, query, makeMessage, onErr...)
 	}()
 	return job.ID()
 }
 
-func (ws *Workspace) goControlComponents(t *task.Task, query componentQuery, makeMessage func(*api.ComponentDescription) interface{}, onErr ...func(*api.ComponentDescription, error)) {
+func (ws *Workspace) goControlComponents(t *task.Task, operation string, query componentQuery, makeMessage func(*api.ComponentDescription) interface{}, onErr ...func(*api.ComponentDescription, error)) {
 	describe := query.describeComponentsInput(ws)
 	components, err := ws.DescribeComponents(t, describe)
 	if err != nil {
@@ -1117,7 +1305,10 @@ func (ws *Workspace) goControlComponents(t *task.Task, query componentQuery, mak
 				if msg == nil {
 					return nil
 				}
-				err := ws.control(t, component, msg)
+				output, err := ws.send(t, component, msg)
+				if refreshed, ok := output.(*api.RefreshOutput); ok {
+					ws.logDrift(t, component, refreshed.Drift)
+				}
 				if err != nil {
 					for _, f := range onErr {
 						f(&component, err)
@@ -1132,33 +1323,26 @@ func (ws *Workspace) goControlComponents(t *task.Task, query componentQuery, mak
 	}
 
 	// Run tasks.
-	layers := runGraph.TopoSortedLayers()
-	for i, layer := range layers {
-		if query.DependencyOrder == dependencyOrderReverse {
-			layer = layers[len(layers)-1-i]
-		}
-
-		var wg sync.WaitGroup
-		for _, node := range layer {
-			runTask := node.(*runTaskNode)
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				runTask.task.Start()
-				defer runTask.task.Finish()
-				if err := runTask.run(runTask.task); err != nil {
-					runTask.task.Fail(err)
-				}
-			}()
-		}
-		wg.Wait()
+	run := &graphRun{
+		graph:       runGraph,
+		reverse:     query.DependencyOrder == dependencyOrderReverse,
+		concurrency: ws.JobConcurrency.limit(operation),
+	}
+	if err := run.execute(); err != nil {
+		t.Fail(err)
 	}
 }
 
 func (ws *Workspace) control(ctx context.Context, desc api.ComponentDescription, input interface{}) error {
+	_, err := ws.send(ctx, desc, input)
+	return err
+}
+
+// send is like control, but also returns the output of the controller method.
+func (ws *Workspace) send(ctx context.Context, desc api.ComponentDescription, input interface{}) (interface{}, error) {
 	ctrl := ws.newController(ctx, desc)
 	if err := ctrl.InitResource(); err != nil {
-		return err
+		return nil, err
 	}
 	// TODO: Figure out how to avoid special-casing destroy.
 	destroying := false
@@ -1167,27 +1351,28 @@ func (ws *Workspace) control(ctx context.Context, desc api.ComponentDescription,
 		destroying = true
 		input = &api.DisposeInput{}
 	}
-	_, fErr := josh.Send(ctx, ctrl, input)
-	// Try to save state even if f fails.
+	output, fErr := josh.Send(ctx, ctrl, input)
+	// Try to save state even if f fails, including when its job was canceled.
+	saveCtx := contextutil.WithoutCancel(ctx)
 	newState, err := ctrl.MarshalState()
 	if err == nil {
-		if destroying {
-			// TODO: Do this asynchronously as a garbage collection pass, so
-			// that we can inspect deleted components and debug them.
-			_, err = ws.Store.RemoveComponent(ctx, &state.RemoveComponentInput{
-				ID: desc.ID,
-			})
-		} else {
-			_, err = ws.Store.PatchComponent(ctx, &state.PatchComponentInput{
-				ID:    desc.ID,
-				State: newState,
-			})
-		}
+		_, err = ws.Store.PatchComponent(saveCtx, &state.PatchComponentInput{
+			ID:    desc.ID,
+			State: newState,
+		})
+	}
+	if err == nil && destroying {
+		// Deleted components are kept as tombstones, so that they can be
+		// inspected and undeleted, until collected by a TombstoneCollector.
+		_, err = ws.Store.DeleteComponent(saveCtx, &state.DeleteComponentInput{
+			ID:      desc.ID,
+			Deleted: chrono.NowString(ctx),
+		})
 	}
 	if fErr != nil {
-		return fErr
+		return nil, fErr
 	}
-	return err
+	return output, err
 }
 
 func (ws *Workspace) Build(ctx context.Context, input *api.BuildInput) (*api.BuildOutput, error) {
@@ -1215,6 +1400,8 @@ type runTaskNode struct {
 	name string
 	task *task.Task
 	run  func(task *task.Task) error
+	// Set after run succeeds.
+	done bool
 }
 
 func (n *runTaskNode) ID() string {
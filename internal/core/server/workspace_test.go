@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/deref/exo/internal/deps"
+	"github.com/deref/exo/internal/task"
+	taskapi "github.com/deref/exo/internal/task/api"
+	taskserver "github.com/deref/exo/internal/task/server"
+	"github.com/deref/exo/internal/util/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteRunTasksStopsAtFailedLayer(t *testing.T) {
+	ctx := context.Background()
+	store := taskserver.NewTaskStore()
+	tt := &task.TaskTracker{
+		Store:  store,
+		Logger: logging.Default(),
+	}
+	job := tt.StartTask(ctx, "job")
+
+	g := deps.New()
+	nodes := make(map[string]*runTaskNode)
+	addNode := func(name string, err error) {
+		nodes[name] = &runTaskNode{
+			name: name,
+			task: job.CreateChild(name),
+			run: func(*task.Task) error {
+				return err
+			},
+		}
+		g.AddNode(nodes[name])
+	}
+	addNode("a", nil)
+	addNode("b", errors.New("boom"))
+	addNode("c", nil)
+	addNode("d", nil)
+	g.AddEdge("c", "a")
+	g.AddEdge("c", "b")
+	g.AddEdge("d", "c")
+
+	err := executeRunTasks(g, 0)
+	assert.EqualError(t, err, "b: boom")
+	assert.True(t, nodes["a"].done)
+	assert.False(t, nodes["b"].done)
+	assert.False(t, nodes["c"].done)
+	assert.False(t, nodes["d"].done)
+
+	job.Finish()
+	output, err := store.DescribeTasks(ctx, &taskapi.DescribeTasksInput{
+		JobIDs: []string{job.JobID()},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	statuses := make(map[string]string)
+	for _, description := range output.Tasks {
+		statuses[description.Name] = description.Status
+	}
+	assert.Equal(t, map[string]string{
+		"job": taskapi.StatusSuccess,
+		"a":   taskapi.StatusSuccess,
+		"b":   taskapi.StatusFailure,
+		"c":   taskapi.StatusSkipped,
+		"d":   taskapi.StatusSkipped,
+	}, statuses)
+}
@@ -14,11 +14,19 @@ type Store interface {
 	DescribeWorkspaces(context.Context, *DescribeWorkspacesInput) (*DescribeWorkspacesOutput, error)
 	AddWorkspace(context.Context, *AddWorkspaceInput) (*AddWorkspaceOutput, error)
 	RemoveWorkspace(context.Context, *RemoveWorkspaceInput) (*RemoveWorkspaceOutput, error)
+	PatchWorkspace(context.Context, *PatchWorkspaceInput) (*PatchWorkspaceOutput, error)
 	ResolveWorkspace(context.Context, *ResolveWorkspaceInput) (*ResolveWorkspaceOutput, error)
 	Resolve(context.Context, *ResolveInput) (*ResolveOutput, error)
 	DescribeComponents(context.Context, *DescribeComponentsInput) (*DescribeComponentsOutput, error)
 	AddComponent(context.Context, *AddComponentInput) (*AddComponentOutput, error)
 	PatchComponent(context.Context, *PatchComponentInput) (*PatchComponentOutput, error)
+	// Returns the history of a component's spec, oldest first.
+	DescribeSpecRevisions(context.Context, *DescribeSpecRevisionsInput) (*DescribeSpecRevisionsOutput, error)
+	// Marks a component as deleted, keeping it as a tombstone. Its name may be reused by other components.
+	DeleteComponent(context.Context, *DeleteComponentInput) (*DeleteComponentOutput, error)
+	// Restores the tombstone of a deleted component.
+	UndeleteComponent(context.Context, *UndeleteComponentInput) (*UndeleteComponentOutput, error)
+	// Permanently removes a component or its tombstone.
 	RemoveComponent(context.Context, *RemoveComponentInput) (*RemoveComponentOutput, error)
 }
 
@@ -45,6 +53,19 @@ type RemoveWorkspaceInput struct {
 type RemoveWorkspaceOutput struct {
 }
 
+type PatchWorkspaceInput struct {
+
+	// ID of workspace to be patched.
+	ID string `json:"id"`
+	// If provided, replaces the workspace's apply-watch setting.
+	ApplyWatch *string `json:"applyWatch"`
+	// If provided, replaces the workspace's drift-policy setting.
+	DriftPolicy *string `json:"driftPolicy"`
+}
+
+type PatchWorkspaceOutput struct {
+}
+
 type ResolveWorkspaceInput struct {
 	Ref string `json:"ref"`
 }
@@ -68,6 +89,8 @@ type DescribeComponentsInput struct {
 	Types               []string `json:"types"`
 	IncludeDependencies bool     `json:"includeDependencies"`
 	IncludeDependents   bool     `json:"includeDependents"`
+	// If true, includes tombstones of deleted components.
+	IncludeDeleted bool `json:"includeDeleted"`
 }
 
 type DescribeComponentsOutput struct {
@@ -82,6 +105,8 @@ type AddComponentInput struct {
 	Spec        string   `json:"spec"`
 	Created     string   `json:"created"`
 	DependsOn   []string `json:"dependsOn"`
+	// What created the component, such as apply or api. Recorded with the first revision in the component's spec history.
+	SpecSource string `json:"specSource"`
 }
 
 type AddComponentOutput struct {
@@ -94,14 +119,40 @@ type PatchComponentInput struct {
 	// If provided, renames component.
 	Name string `json:"name"`
 	// If provided, replaces component spec.
-	Spec      string    `json:"spec"`
-	State     string    `json:"state"`
-	DependsOn *[]string `json:"dependsOn"`
+	Spec string `json:"spec"`
+	// What changed the spec, such as apply, edit or api. If the spec changed, recorded with a new revision in the component's spec history.
+	SpecSource string    `json:"specSource"`
+	State      string    `json:"state"`
+	DependsOn  *[]string `json:"dependsOn"`
 }
 
 type PatchComponentOutput struct {
 }
 
+type DescribeSpecRevisionsInput struct {
+	ID string `json:"id"`
+}
+
+type DescribeSpecRevisionsOutput struct {
+	Revisions []SpecRevision `json:"revisions"`
+}
+
+type DeleteComponentInput struct {
+	ID string `json:"id"`
+	// Time of deletion.
+	Deleted string `json:"deleted"`
+}
+
+type DeleteComponentOutput struct {
+}
+
+type UndeleteComponentInput struct {
+	ID string `json:"id"`
+}
+
+type UndeleteComponentOutput struct {
+}
+
 type RemoveComponentInput struct {
 	ID string `json:"id"`
 }
@@ -119,6 +170,9 @@ func BuildStoreMux(b *josh.MuxBuilder, factory func(req *http.Request) Store) {
 	b.AddMethod("remove-workspace", func(req *http.Request) interface{} {
 		return factory(req).RemoveWorkspace
 	})
+	b.AddMethod("patch-workspace", func(req *http.Request) interface{} {
+		return factory(req).PatchWorkspace
+	})
 	b.AddMethod("resolve-workspace", func(req *http.Request) interface{} {
 		return factory(req).ResolveWorkspace
 	})
@@ -134,6 +188,15 @@ func BuildStoreMux(b *josh.MuxBuilder, factory func(req *http.Request) Store) {
 	b.AddMethod("patch-component", func(req *http.Request) interface{} {
 		return factory(req).PatchComponent
 	})
+	b.AddMethod("describe-spec-revisions", func(req *http.Request) interface{} {
+		return factory(req).DescribeSpecRevisions
+	})
+	b.AddMethod("delete-component", func(req *http.Request) interface{} {
+		return factory(req).DeleteComponent
+	})
+	b.AddMethod("undelete-component", func(req *http.Request) interface{} {
+		return factory(req).UndeleteComponent
+	})
 	b.AddMethod("remove-component", func(req *http.Request) interface{} {
 		return factory(req).RemoveComponent
 	})
@@ -143,6 +206,9 @@ type WorkspaceDescription struct {
 	ID          string `json:"id"`
 	Root        string `json:"root"`
 	DisplayName string `json:"displayName"`
+	// Opaque, JSON-encoded settings with which the workspace's manifest is re-applied when it changes. Empty if the manifest is not watched.
+	ApplyWatch  string `json:"applyWatch"`
+	DriftPolicy string `json:"driftPolicy"`
 }
 
 type ComponentDescription struct {
@@ -154,4 +220,15 @@ type ComponentDescription struct {
 	State       string   `json:"state"`
 	Created     string   `json:"created"`
 	DependsOn   []string `json:"dependsOn"`
+	// Time of deletion. Empty unless the component is a tombstone.
+	Deleted string `json:"deleted"`
+}
+
+type SpecRevision struct {
+
+	// Numbered from 1 for each component.
+	Revision  int    `json:"revision"`
+	Timestamp string `json:"timestamp"`
+	Source    string `json:"source"`
+	Spec      string `json:"spec"`
 }
@@ -16,6 +16,18 @@ interface "store" {
   method "remove-workspace" {
     input "id" "string" {}
   }
+
+  method "patch-workspace" {
+    input "id" "string" {
+      doc = "ID of workspace to be patched."
+    }
+    input "apply-watch" "*string" {
+      doc = "If provided, replaces the workspace's apply-watch setting."
+    }
+    input "drift-policy" "*string" {
+      doc = "If provided, replaces the workspace's drift-policy setting."
+    }
+  }
   
   method "resolve-workspace" {
     input "ref" "string" {}
@@ -36,6 +48,9 @@ interface "store" {
     input "types" "[]string" {}
     input "include-dependencies" "bool" {}
     input "include-dependents" "bool" {}
+    input "include-deleted" "bool" {
+      doc = "If true, includes tombstones of deleted components."
+    }
 
     output "components" "[]ComponentDescription" {}
   }
@@ -48,6 +63,9 @@ interface "store" {
     input "spec" "string" {}
     input "created" "string" {}
     input "depends-on" "[]string" {}
+    input "spec-source" "string" {
+      doc = "What created the component, such as apply or api. Recorded with the first revision in the component's spec history."
+    }
   }
 
   method "patch-component" {
@@ -59,12 +77,40 @@ interface "store" {
     }
     input "spec" "string" {
       doc = "If provided, replaces component spec."
+    }
+    input "spec-source" "string" {
+      doc = "What changed the spec, such as apply, edit or api. If the spec changed, recorded with a new revision in the component's spec history."
     }
 	  input "state" "string" {}
 	  input "depends-on" "*[]string" {}
   }
 
+  method "describe-spec-revisions" {
+    doc = "Returns the history of a component's spec, oldest first."
+
+    input "id" "string" {}
+
+    output "revisions" "[]SpecRevision" {}
+  }
+
+  method "delete-component" {
+    doc = "Marks a component as deleted, keeping it as a tombstone. Its name may be reused by other components."
+
+    input "id" "string" {}
+    input "deleted" "string" {
+      doc = "Time of deletion."
+    }
+  }
+
+  method "undelete-component" {
+    doc = "Restores the tombstone of a deleted component."
+
+    input "id" "string" {}
+  }
+
   method "remove-component" {
+    doc = "Permanently removes a component or its tombstone."
+
     input "id" "string" {}
   }
 
@@ -74,6 +120,10 @@ struct "workspace-description" {
   field "id" "string" {}
   field "root" "string" {}
   field "display-name" "string" {}
+  field "apply-watch" "string" {
+    doc = "Opaque, JSON-encoded settings with which the workspace's manifest is re-applied when it changes. Empty if the manifest is not watched."
+  }
+  field "drift-policy" "string" {}
 }
 
 struct "component-description" {
@@ -85,4 +135,16 @@ struct "component-description" {
 	field "state" "string" {}
 	field "created" "string" {}
 	field "depends-on" "[]string" {}
+	field "deleted" "string" {
+		doc = "Time of deletion. Empty unless the component is a tombstone."
+	}
+}
+
+struct "spec-revision" {
+  field "revision" "int" {
+    doc = "Numbered from 1 for each component."
+  }
+  field "timestamp" "string" {}
+  field "source" "string" {}
+  field "spec" "string" {}
 }
@@ -36,6 +36,11 @@ func (c *Store) RemoveWorkspace(ctx context.Context, input *api.RemoveWorkspaceI
 	return
 }
 
+func (c *Store) PatchWorkspace(ctx context.Context, input *api.PatchWorkspaceInput) (output *api.PatchWorkspaceOutput, err error) {
+	err = c.client.Invoke(ctx, "patch-workspace", input, &output)
+	return
+}
+
 func (c *Store) ResolveWorkspace(ctx context.Context, input *api.ResolveWorkspaceInput) (output *api.ResolveWorkspaceOutput, err error) {
 	err = c.client.Invoke(ctx, "resolve-workspace", input, &output)
 	return
@@ -61,6 +66,21 @@ func (c *Store) PatchComponent(ctx context.Context, input *api.PatchComponentInp
 	return
 }
 
+func (c *Store) DescribeSpecRevisions(ctx context.Context, input *api.DescribeSpecRevisionsInput) (output *api.DescribeSpecRevisionsOutput, err error) {
+	err = c.client.Invoke(ctx, "describe-spec-revisions", input, &output)
+	return
+}
+
+func (c *Store) DeleteComponent(ctx context.Context, input *api.DeleteComponentInput) (output *api.DeleteComponentOutput, err error) {
+	err = c.client.Invoke(ctx, "delete-component", input, &output)
+	return
+}
+
+func (c *Store) UndeleteComponent(ctx context.Context, input *api.UndeleteComponentInput) (output *api.UndeleteComponentOutput, err error) {
+	err = c.client.Invoke(ctx, "undelete-component", input, &output)
+	return
+}
+
 func (c *Store) RemoveComponent(ctx context.Context, input *api.RemoveComponentInput) (output *api.RemoveComponentOutput, err error) {
 	err = c.client.Invoke(ctx, "remove-component", input, &output)
 	return
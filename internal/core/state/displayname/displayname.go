@@ -1,4 +1,4 @@
-package statefile
+package displayname
 
 import (
 	"fmt"
@@ -6,13 +6,15 @@ import (
 	"strings"
 )
 
-type displayNameBuilder struct {
+// Builder abbreviates paths, such as workspace roots, to their shortest
+// suffixes that are unique among the paths added to it.
+type Builder struct {
 	tree      reversePathTree
 	separator string
 }
 
-func newDisplayNameBuilder(separator string) *displayNameBuilder {
-	return &displayNameBuilder{
+func NewBuilder(separator string) *Builder {
+	return &Builder{
 		tree:      make(reversePathTree),
 		separator: separator,
 	}
@@ -20,7 +22,7 @@ func newDisplayNameBuilder(separator string) *displayNameBuilder {
 
 type reversePathTree map[string]reversePathTree
 
-func (b *displayNameBuilder) dump() {
+func (b *Builder) dump() {
 	b.tree.dump("")
 }
 
@@ -31,11 +33,11 @@ func (tree reversePathTree) dump(prefix string) {
 	}
 }
 
-func (b *displayNameBuilder) pathParts(path string) []string {
+func (b *Builder) pathParts(path string) []string {
 	return strings.Split(filepath.Clean(path), b.separator)
 }
 
-func (b *displayNameBuilder) AddPath(path string) {
+func (b *Builder) AddPath(path string) {
 	parts := b.pathParts(path)
 	tree := b.tree
 	for i := len(parts) - 1; i >= 0; i-- {
@@ -49,7 +51,7 @@ func (b *displayNameBuilder) AddPath(path string) {
 	}
 }
 
-func (b *displayNameBuilder) GetDisplayName(path string) string {
+func (b *Builder) GetDisplayName(path string) string {
 	parts := b.pathParts(path)
 	tree := b.tree
 	for i := len(parts) - 1; i >= 0; i-- {
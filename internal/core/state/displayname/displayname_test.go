@@ -1,4 +1,4 @@
-package statefile
+package displayname
 
 import (
 	"testing"
@@ -8,7 +8,7 @@ import (
 
 func TestDisplayNameBuilder(t *testing.T) {
 	filepathSeparator := "/"
-	b := newDisplayNameBuilder(filepathSeparator)
+	b := NewBuilder(filepathSeparator)
 	b.AddPath("/personal/unique")
 	b.AddPath("/personal/duplicate")
 	b.AddPath("/work/duplicate")
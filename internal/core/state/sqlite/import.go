@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	state "github.com/deref/exo/internal/core/state/api"
+	"github.com/deref/exo/internal/core/state/statefile"
+	"github.com/deref/exo/internal/util/jsonutil"
+	"github.com/deref/exo/internal/util/sqliteutil"
+	"github.com/jmoiron/sqlx"
+)
+
+// ImportStateFile copies the workspaces and components of a legacy state.json
+// file into the store in a single transaction. On success, the file is renamed
+// with an ".imported" suffix so that the import happens only once. A missing
+// file is not an error.
+func (sto *Store) ImportStateFile(ctx context.Context, filePath string) error {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return nil
+	}
+	var root statefile.Root
+	if err := jsonutil.UnmarshalFile(filePath, &root); err != nil {
+		return fmt.Errorf("reading state file: %w", err)
+	}
+
+	if err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		for workspaceID, workspace := range root.Workspaces {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO workspace ( id, root, apply_watch, drift_policy )
+				VALUES ( ?, ?, ?, ? )
+			`, workspaceID, workspace.Root, workspace.ApplyWatch, workspace.DriftPolicy); err != nil {
+				return fmt.Errorf("inserting workspace %q: %w", workspaceID, err)
+			}
+			for componentID, component := range workspace.Components {
+				if _, err := tx.ExecContext(ctx, `
+					INSERT INTO component ( id, workspace_id, name, type, spec, state, created, depends_on, deleted )
+					VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ? )
+				`, componentID, workspaceID, component.Name, component.Type, component.Spec, component.State,
+					component.Created, marshalDependsOn(component.DependsOn), component.Deleted); err != nil {
+					return fmt.Errorf("inserting component %q: %w", componentID, err)
+				}
+				history := component.SpecHistory
+				if len(history) == 0 {
+					history = []state.SpecRevision{{
+						Revision:  1,
+						Timestamp: component.Created,
+						Spec:      component.Spec,
+					}}
+				}
+				for _, revision := range history {
+					if _, err := tx.ExecContext(ctx, `
+						INSERT INTO component_spec ( component_id, revision, timestamp, source, spec )
+						VALUES ( ?, ?, ?, ?, ? )
+					`, componentID, revision.Revision, revision.Timestamp, revision.Source, revision.Spec); err != nil {
+						return fmt.Errorf("inserting spec history of component %q: %w", componentID, err)
+					}
+				}
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := os.Rename(filePath, filePath+".imported"); err != nil {
+		return fmt.Errorf("renaming imported state file: %w", err)
+	}
+	return nil
+}
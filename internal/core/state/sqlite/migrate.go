@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/deref/exo/internal/util/sqliteutil"
+)
+
+// See sqliteutil.Migrate.
+var migrations = []sqliteutil.Migration{
+	{
+		Name: "state-0001-create-tables",
+		Statements: []string{
+			`CREATE TABLE workspace (
+				id TEXT NOT NULL PRIMARY KEY,
+				root TEXT NOT NULL UNIQUE,
+				apply_watch TEXT NOT NULL DEFAULT '',
+				drift_policy TEXT NOT NULL DEFAULT ''
+			)`,
+			`CREATE TABLE component (
+				id TEXT NOT NULL PRIMARY KEY,
+				workspace_id TEXT NOT NULL REFERENCES workspace ( id ),
+				name TEXT NOT NULL,
+				type TEXT NOT NULL,
+				spec TEXT NOT NULL,
+				state TEXT NOT NULL,
+				created TEXT NOT NULL,
+				depends_on TEXT NOT NULL,
+				deleted TEXT NOT NULL DEFAULT ''
+			)`,
+			`CREATE INDEX component_workspace ON component ( workspace_id, name )`,
+			// Names are unique among the components of a workspace, but not among
+			// tombstones of deleted components.
+			`CREATE UNIQUE INDEX component_live_name ON component ( workspace_id, name ) WHERE deleted = ''`,
+		},
+	},
+	{
+		Name: "state-0002-spec-history",
+		Statements: []string{
+			`CREATE TABLE component_spec (
+				component_id TEXT NOT NULL,
+				revision INTEGER NOT NULL,
+				timestamp TEXT NOT NULL,
+				source TEXT NOT NULL,
+				spec TEXT NOT NULL,
+				PRIMARY KEY ( component_id, revision )
+			)`,
+			// Existing specs become the first revisions, of unknown source.
+			`INSERT INTO component_spec ( component_id, revision, timestamp, source, spec )
+			SELECT id, 1, created, '', spec
+			FROM component`,
+		},
+	},
+}
+
+func (sto *Store) Migrate(ctx context.Context) error {
+	return sqliteutil.Migrate(ctx, sto.DB, migrations)
+}
@@ -0,0 +1,651 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/deref/exo/internal/chrono"
+	state "github.com/deref/exo/internal/core/state/api"
+	"github.com/deref/exo/internal/core/state/displayname"
+	"github.com/deref/exo/internal/deps"
+	"github.com/deref/exo/internal/util/errutil"
+	"github.com/deref/exo/internal/util/jsonutil"
+	"github.com/deref/exo/internal/util/pathutil"
+	"github.com/deref/exo/internal/util/sqliteutil"
+)
+
+type Store struct {
+	DB *sqlx.DB
+}
+
+var _ state.Store = (*Store)(nil)
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+type workspaceRow struct {
+	ID          string `db:"id"`
+	Root        string `db:"root"`
+	ApplyWatch  string `db:"apply_watch"`
+	DriftPolicy string `db:"drift_policy"`
+}
+
+type componentRow struct {
+	ID          string `db:"id"`
+	WorkspaceID string `db:"workspace_id"`
+	Name        string `db:"name"`
+	Type        string `db:"type"`
+	Spec        string `db:"spec"`
+	State       string `db:"state"`
+	Created     string `db:"created"`
+	DependsOn   string `db:"depends_on"`
+	Deleted     string `db:"deleted"`
+}
+
+func (row componentRow) getDescription() (state.ComponentDescription, error) {
+	desc := state.ComponentDescription{
+		ID:          row.ID,
+		WorkspaceID: row.WorkspaceID,
+		Name:        row.Name,
+		Type:        row.Type,
+		Spec:        row.Spec,
+		State:       row.State,
+		Created:     row.Created,
+		Deleted:     row.Deleted,
+	}
+	if err := jsonutil.UnmarshalString(row.DependsOn, &desc.DependsOn); err != nil {
+		return desc, fmt.Errorf("unmarshalling dependencies of component %q: %w", row.ID, err)
+	}
+	return desc, nil
+}
+
+func marshalDependsOn(dependsOn []string) string {
+	if dependsOn == nil {
+		return "null"
+	}
+	return jsonutil.MustMarshalString(dependsOn)
+}
+
+func (sto *Store) DescribeWorkspaces(ctx context.Context, input *state.DescribeWorkspacesInput) (*state.DescribeWorkspacesOutput, error) {
+	// All workspaces are needed to compute display names.
+	var rows []workspaceRow
+	if err := sto.DB.SelectContext(ctx, &rows, `
+		SELECT id, root, apply_watch, drift_policy
+		FROM workspace
+		ORDER BY id ASC
+	`); err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+
+	var ids map[string]bool
+	if input.IDs != nil {
+		ids = make(map[string]bool, len(input.IDs))
+	}
+	for _, id := range input.IDs {
+		ids[id] = true
+	}
+
+	dnb := displayname.NewBuilder(string(filepath.Separator))
+
+	var output state.DescribeWorkspacesOutput
+	for _, row := range rows {
+		dnb.AddPath(row.Root)
+		if ids == nil || ids[row.ID] {
+			output.Workspaces = append(output.Workspaces, state.WorkspaceDescription{
+				ID:          row.ID,
+				Root:        row.Root,
+				ApplyWatch:  row.ApplyWatch,
+				DriftPolicy: row.DriftPolicy,
+			})
+		}
+	}
+
+	for i := range output.Workspaces {
+		output.Workspaces[i].DisplayName = dnb.GetDisplayName(output.Workspaces[i].Root)
+	}
+
+	return &output, nil
+}
+
+func (sto *Store) AddWorkspace(ctx context.Context, input *state.AddWorkspaceInput) (*state.AddWorkspaceOutput, error) {
+	rootPath := filepath.Clean(input.Root)
+	if !filepath.IsAbs(rootPath) {
+		return nil, errutil.NewHTTPError(http.StatusBadRequest, "root must be absolute path")
+	}
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		var existing []workspaceRow
+		if err := tx.SelectContext(ctx, &existing, `
+			SELECT id, root, apply_watch, drift_policy
+			FROM workspace
+			WHERE id = ? OR root = ?
+		`, input.ID, rootPath); err != nil {
+			return fmt.Errorf("querying: %w", err)
+		}
+		for _, workspace := range existing {
+			if workspace.ID == input.ID {
+				return fmt.Errorf("workspace %q already exists", input.ID)
+			}
+			return errutil.HTTPErrorf(http.StatusConflict, "workspace with root %q already exists", rootPath)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO workspace ( id, root )
+			VALUES ( ?, ? )
+		`, input.ID, rootPath); err != nil {
+			return fmt.Errorf("inserting: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.AddWorkspaceOutput{}, nil
+}
+
+func (sto *Store) RemoveWorkspace(ctx context.Context, input *state.RemoveWorkspaceInput) (*state.RemoveWorkspaceOutput, error) {
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		var live int
+		if err := tx.GetContext(ctx, &live, `
+			SELECT COUNT(*)
+			FROM component
+			WHERE workspace_id = ? AND deleted = ''
+		`, input.ID); err != nil {
+			return fmt.Errorf("querying: %w", err)
+		}
+		if live > 0 {
+			return fmt.Errorf("cannot remove non-empty workspace %q", input.ID)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM component_spec
+			WHERE component_id IN (
+				SELECT id FROM component WHERE workspace_id = ?
+			)
+		`, input.ID); err != nil {
+			return fmt.Errorf("deleting spec history: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM component
+			WHERE workspace_id = ?
+		`, input.ID); err != nil {
+			return fmt.Errorf("deleting tombstones: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM workspace
+			WHERE id = ?
+		`, input.ID); err != nil {
+			return fmt.Errorf("deleting: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.RemoveWorkspaceOutput{}, nil
+}
+
+func (sto *Store) PatchWorkspace(ctx context.Context, input *state.PatchWorkspaceInput) (*state.PatchWorkspaceOutput, error) {
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		var workspace workspaceRow
+		if err := tx.GetContext(ctx, &workspace, `
+			SELECT id, root, apply_watch, drift_policy
+			FROM workspace
+			WHERE id = ?
+		`, input.ID); err == sql.ErrNoRows {
+			return errutil.HTTPErrorf(http.StatusNotFound, "no such workspace: %q", input.ID)
+		} else if err != nil {
+			return fmt.Errorf("querying: %w", err)
+		}
+		if input.ApplyWatch != nil {
+			workspace.ApplyWatch = *input.ApplyWatch
+		}
+		if input.DriftPolicy != nil {
+			workspace.DriftPolicy = *input.DriftPolicy
+		}
+		if _, err := tx.NamedExecContext(ctx, `
+			UPDATE workspace
+			SET apply_watch = :apply_watch, drift_policy = :drift_policy
+			WHERE id = :id
+		`, workspace); err != nil {
+			return fmt.Errorf("updating: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.PatchWorkspaceOutput{}, nil
+}
+
+func (sto *Store) ResolveWorkspace(ctx context.Context, input *state.ResolveWorkspaceInput) (*state.ResolveWorkspaceOutput, error) {
+	var rows []workspaceRow
+	if err := sto.DB.SelectContext(ctx, &rows, `
+		SELECT id, root, apply_watch, drift_policy
+		FROM workspace
+	`); err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+
+	// Resolve by ID.
+	for _, row := range rows {
+		if row.ID == input.Ref {
+			return &state.ResolveWorkspaceOutput{
+				ID: &input.Ref,
+			}, nil
+		}
+	}
+
+	// Resolve by path. Searches for the deepest root prefix match.
+	maxLen := 0
+	found := ""
+	for _, row := range rows {
+		n := len(row.Root)
+		if n > maxLen && pathutil.HasFilePathPrefix(input.Ref, row.Root) {
+			found = row.ID
+			maxLen = n
+		}
+	}
+	var output state.ResolveWorkspaceOutput
+	if maxLen > 0 {
+		output.ID = &found
+	}
+	return &output, nil
+}
+
+func (sto *Store) requireWorkspace(ctx context.Context, q sqlx.QueryerContext, id string) error {
+	if id == "" {
+		return errors.New("workspace-id is required")
+	}
+	var n int
+	if err := sqlx.GetContext(ctx, q, &n, `
+		SELECT COUNT(*)
+		FROM workspace
+		WHERE id = ?
+	`, id); err != nil {
+		return fmt.Errorf("querying: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("no such workspace: %q", id)
+	}
+	return nil
+}
+
+func (sto *Store) Resolve(ctx context.Context, input *state.ResolveInput) (*state.ResolveOutput, error) {
+	if err := sto.requireWorkspace(ctx, sto.DB, input.WorkspaceID); err != nil {
+		return nil, err
+	}
+	ids := make([]*string, len(input.Refs))
+	if len(input.Refs) == 0 {
+		return &state.ResolveOutput{IDs: ids}, nil
+	}
+	query, args, err := sqlx.In(`
+		SELECT id, name
+		FROM component
+		WHERE workspace_id = ? AND deleted = '' AND ( id IN (?) OR name IN (?) )
+	`, input.WorkspaceID, input.Refs, input.Refs)
+	if err != nil {
+		return nil, fmt.Errorf("building query: %w", err)
+	}
+	var rows []struct {
+		ID   string `db:"id"`
+		Name string `db:"name"`
+	}
+	if err := sto.DB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+	byID := make(map[string]bool, len(rows))
+	byName := make(map[string]string, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = true
+		byName[row.Name] = row.ID
+	}
+	for i, ref := range input.Refs {
+		if byID[ref] {
+			id := ref
+			ids[i] = &id
+		} else if id, ok := byName[ref]; ok {
+			ids[i] = &id
+		}
+	}
+	return &state.ResolveOutput{IDs: ids}, nil
+}
+
+func (sto *Store) DescribeComponents(ctx context.Context, input *state.DescribeComponentsInput) (*state.DescribeComponentsOutput, error) {
+	if input.WorkspaceID == "" {
+		return nil, errors.New("workspace-id is required")
+	}
+
+	var rows []componentRow
+	if err := sto.DB.SelectContext(ctx, &rows, `
+		SELECT id, workspace_id, name, type, spec, state, created, depends_on, deleted
+		FROM component
+		WHERE workspace_id = ? AND ( ? OR deleted = '' )
+	`, input.WorkspaceID, input.IncludeDeleted); err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+	components := make(map[string]state.ComponentDescription, len(rows))
+	liveNames := make(map[string]string, len(rows))
+	for _, row := range rows {
+		desc, err := row.getDescription()
+		if err != nil {
+			return nil, err
+		}
+		components[desc.ID] = desc
+		if desc.Deleted == "" {
+			liveNames[desc.Name] = desc.ID
+		}
+	}
+
+	var refs map[string]bool
+	if input.Refs != nil {
+		refs = make(map[string]bool, len(input.Refs))
+		for _, ref := range input.Refs {
+			refs[ref] = true
+		}
+	}
+
+	var types map[string]bool
+	if input.Types != nil {
+		types = make(map[string]bool, len(input.Types))
+		for _, typ := range input.Types {
+			types[typ] = true
+		}
+	}
+
+	selected := make(map[string]bool)
+	for id, component := range components {
+		if (refs == nil || refs[id] || refs[component.Name]) &&
+			(types == nil || types[component.Type]) {
+			selected[id] = true
+		}
+	}
+
+	if input.IncludeDependencies || input.IncludeDependents {
+		componentGraph := deps.New()
+		for id, component := range components {
+			for _, dependency := range component.DependsOn {
+				dependencyID := liveNames[dependency]
+				if _, isID := components[dependency]; isID {
+					dependencyID = dependency
+				}
+				if dependencyID != "" {
+					componentGraph.DependOn(deps.StringNode(id), deps.StringNode(dependencyID))
+				}
+			}
+		}
+
+		nextIDs := make([]string, 0, len(selected))
+		for id := range selected {
+			nextIDs = append(nextIDs, id)
+		}
+		markSeen := func(id string) {
+			if !selected[id] {
+				selected[id] = true
+				nextIDs = append(nextIDs, id)
+			}
+		}
+		for len(nextIDs) > 0 {
+			ids := nextIDs
+			nextIDs = []string{}
+			for _, id := range ids {
+				if input.IncludeDependencies {
+					for dependencyID := range componentGraph.Dependencies(id) {
+						markSeen(dependencyID)
+					}
+				}
+				if input.IncludeDependents {
+					for dependentID := range componentGraph.Dependents(id) {
+						markSeen(dependentID)
+					}
+				}
+			}
+		}
+	}
+
+	output := &state.DescribeComponentsOutput{
+		Components: make([]state.ComponentDescription, 0, len(selected)),
+	}
+	for id := range selected {
+		output.Components = append(output.Components, components[id])
+	}
+	sort.Slice(output.Components, func(i, j int) bool {
+		a, b := output.Components[i], output.Components[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+	return output, nil
+}
+
+func (sto *Store) AddComponent(ctx context.Context, input *state.AddComponentInput) (*state.AddComponentOutput, error) {
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		if err := sto.requireWorkspace(ctx, tx, input.WorkspaceID); err != nil {
+			return err
+		}
+		// The tombstone of a deleted component may be replaced by a component
+		// with the same ID. Its spec history is kept.
+		var existing []componentRow
+		if err := tx.SelectContext(ctx, &existing, `
+			SELECT id, workspace_id, name, type, spec, state, created, depends_on, deleted
+			FROM component
+			WHERE id = ?
+		`, input.ID); err != nil {
+			return fmt.Errorf("querying: %w", err)
+		}
+		for _, component := range existing {
+			if component.Deleted == "" {
+				return fmt.Errorf("component id %q already exists", input.ID)
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM component WHERE id = ?`, input.ID); err != nil {
+				return fmt.Errorf("deleting tombstone: %w", err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO component ( id, workspace_id, name, type, spec, state, created, depends_on )
+			VALUES ( ?, ?, ?, ?, ?, '', ?, ? )
+		`, input.ID, input.WorkspaceID, input.Name, input.Type, input.Spec, input.Created, marshalDependsOn(input.DependsOn)); err != nil {
+			if isUniqueViolation(err) {
+				return errutil.HTTPErrorf(http.StatusConflict, "component named %q already exists", input.Name)
+			}
+			return fmt.Errorf("inserting: %w", err)
+		}
+		return addSpecRevision(ctx, tx, input.ID, input.Created, input.SpecSource, input.Spec)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.AddComponentOutput{}, nil
+}
+
+// getComponent returns the component, or tombstone, with the given ID.
+func getComponent(ctx context.Context, tx *sqlx.Tx, id string) (componentRow, error) {
+	var component componentRow
+	err := tx.GetContext(ctx, &component, `
+		SELECT id, workspace_id, name, type, spec, state, created, depends_on, deleted
+		FROM component
+		WHERE id = ?
+	`, id)
+	if err == sql.ErrNoRows {
+		return component, fmt.Errorf("no component for id: %q", id)
+	}
+	return component, err
+}
+
+func (sto *Store) PatchComponent(ctx context.Context, input *state.PatchComponentInput) (*state.PatchComponentOutput, error) {
+	if input.ID == "" {
+		return nil, errors.New("component id is required")
+	}
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		component, err := getComponent(ctx, tx, input.ID)
+		if err != nil {
+			return err
+		}
+		if input.Name != "" {
+			component.Name = input.Name
+		}
+		if input.DependsOn != nil {
+			component.DependsOn = marshalDependsOn(*input.DependsOn)
+		}
+		specChanged := input.Spec != "" && input.Spec != component.Spec
+		if specChanged {
+			component.Spec = input.Spec
+		}
+		if input.State != "" {
+			component.State = input.State
+		}
+		if _, err := tx.NamedExecContext(ctx, `
+			UPDATE component
+			SET name = :name, spec = :spec, state = :state, depends_on = :depends_on
+			WHERE id = :id
+		`, component); err != nil {
+			if isUniqueViolation(err) {
+				return errutil.HTTPErrorf(http.StatusConflict, "component named %q already exists", input.Name)
+			}
+			return fmt.Errorf("updating: %w", err)
+		}
+		if specChanged {
+			return addSpecRevision(ctx, tx, input.ID, chrono.NowString(ctx), input.SpecSource, input.Spec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.PatchComponentOutput{}, nil
+}
+
+func addSpecRevision(ctx context.Context, tx *sqlx.Tx, componentID, timestamp, source, spec string) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO component_spec ( component_id, revision, timestamp, source, spec )
+		SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ?
+		FROM component_spec
+		WHERE component_id = ?
+	`, componentID, timestamp, source, spec, componentID); err != nil {
+		return fmt.Errorf("recording spec revision: %w", err)
+	}
+	return nil
+}
+
+func (sto *Store) DescribeSpecRevisions(ctx context.Context, input *state.DescribeSpecRevisionsInput) (*state.DescribeSpecRevisionsOutput, error) {
+	var exists int
+	if err := sto.DB.GetContext(ctx, &exists, `
+		SELECT COUNT(*)
+		FROM component
+		WHERE id = ?
+	`, input.ID); err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+	if exists == 0 {
+		return nil, fmt.Errorf("no component for id: %q", input.ID)
+	}
+	var rows []struct {
+		Revision  int    `db:"revision"`
+		Timestamp string `db:"timestamp"`
+		Source    string `db:"source"`
+		Spec      string `db:"spec"`
+	}
+	if err := sto.DB.SelectContext(ctx, &rows, `
+		SELECT revision, timestamp, source, spec
+		FROM component_spec
+		WHERE component_id = ?
+		ORDER BY revision ASC
+	`, input.ID); err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+	output := &state.DescribeSpecRevisionsOutput{
+		Revisions: make([]state.SpecRevision, len(rows)),
+	}
+	for i, row := range rows {
+		output.Revisions[i] = state.SpecRevision{
+			Revision:  row.Revision,
+			Timestamp: row.Timestamp,
+			Source:    row.Source,
+			Spec:      row.Spec,
+		}
+	}
+	return output, nil
+}
+
+func (sto *Store) DeleteComponent(ctx context.Context, input *state.DeleteComponentInput) (*state.DeleteComponentOutput, error) {
+	if input.Deleted == "" {
+		return nil, errors.New("deleted is required")
+	}
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		if _, err := getComponent(ctx, tx, input.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE component
+			SET deleted = ?
+			WHERE id = ? AND deleted = ''
+		`, input.Deleted, input.ID); err != nil {
+			return fmt.Errorf("updating: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.DeleteComponentOutput{}, nil
+}
+
+func (sto *Store) UndeleteComponent(ctx context.Context, input *state.UndeleteComponentInput) (*state.UndeleteComponentOutput, error) {
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		component, err := getComponent(ctx, tx, input.ID)
+		if err != nil {
+			return err
+		}
+		if component.Deleted == "" {
+			return errutil.HTTPErrorf(http.StatusBadRequest, "component %q is not deleted", component.Name)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE component
+			SET deleted = ''
+			WHERE id = ?
+		`, input.ID); err != nil {
+			if isUniqueViolation(err) {
+				return errutil.HTTPErrorf(http.StatusConflict, "component named %q already exists", component.Name)
+			}
+			return fmt.Errorf("updating: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.UndeleteComponentOutput{}, nil
+}
+
+func (sto *Store) RemoveComponent(ctx context.Context, input *state.RemoveComponentInput) (*state.RemoveComponentOutput, error) {
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		if _, err := getComponent(ctx, tx, input.ID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM component
+			WHERE id = ?
+		`, input.ID); err != nil {
+			return fmt.Errorf("deleting: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM component_spec
+			WHERE component_id = ?
+		`, input.ID); err != nil {
+			return fmt.Errorf("deleting spec history: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.RemoveComponentOutput{}, nil
+}
@@ -0,0 +1,252 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	state "github.com/deref/exo/internal/core/state/api"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *Store {
+	db, err := sqlx.Open("sqlite3", filepath.Join(t.TempDir(), "exo.sqlite3")+"?_txlock=exclusive")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	sto := &Store{DB: db}
+	require.NoError(t, sto.Migrate(context.Background()))
+	return sto
+}
+
+func TestTombstones(t *testing.T) {
+	ctx := context.Background()
+	sto := newTestStore(t)
+
+	_, err := sto.AddWorkspace(ctx, &state.AddWorkspaceInput{ID: "ws", Root: "/app"})
+	require.NoError(t, err)
+	add := func(id string) error {
+		_, err := sto.AddComponent(ctx, &state.AddComponentInput{
+			WorkspaceID: "ws",
+			ID:          id,
+			Name:        "web",
+			Type:        "process",
+		})
+		return err
+	}
+	describe := func(includeDeleted bool) []state.ComponentDescription {
+		output, err := sto.DescribeComponents(ctx, &state.DescribeComponentsInput{
+			WorkspaceID:    "ws",
+			IncludeDeleted: includeDeleted,
+		})
+		require.NoError(t, err)
+		return output.Components
+	}
+
+	require.NoError(t, add("a"))
+	assert.Error(t, add("c"), "names of live components are unique")
+	_, err = sto.DeleteComponent(ctx, &state.DeleteComponentInput{ID: "a", Deleted: "2021-10-20T00:00:00Z"})
+	require.NoError(t, err)
+	assert.Empty(t, describe(false))
+	if tombstones := describe(true); assert.Len(t, tombstones, 1) {
+		assert.Equal(t, "2021-10-20T00:00:00Z", tombstones[0].Deleted)
+	}
+	resolved, err := sto.Resolve(ctx, &state.ResolveInput{WorkspaceID: "ws", Refs: []string{"a", "web"}})
+	require.NoError(t, err)
+	assert.Equal(t, []*string{nil, nil}, resolved.IDs)
+
+	// The name of a tombstone may be reused.
+	require.NoError(t, add("b"))
+	_, err = sto.UndeleteComponent(ctx, &state.UndeleteComponentInput{ID: "a"})
+	assert.Error(t, err)
+
+	_, err = sto.RemoveComponent(ctx, &state.RemoveComponentInput{ID: "a"})
+	require.NoError(t, err)
+	resolved, err = sto.Resolve(ctx, &state.ResolveInput{WorkspaceID: "ws", Refs: []string{"web"}})
+	require.NoError(t, err)
+	if assert.NotNil(t, resolved.IDs[0]) {
+		assert.Equal(t, "b", *resolved.IDs[0])
+	}
+
+	_, err = sto.DeleteComponent(ctx, &state.DeleteComponentInput{ID: "b", Deleted: "2021-10-20T00:00:00Z"})
+	require.NoError(t, err)
+	_, err = sto.UndeleteComponent(ctx, &state.UndeleteComponentInput{ID: "b"})
+	require.NoError(t, err)
+	if components := describe(false); assert.Len(t, components, 1) {
+		assert.Equal(t, "b", components[0].ID)
+		assert.Empty(t, components[0].Deleted)
+	}
+
+	_, err = sto.RemoveWorkspace(ctx, &state.RemoveWorkspaceInput{ID: "ws"})
+	assert.Error(t, err, "workspace has live components")
+}
+
+func TestDescribeDependencies(t *testing.T) {
+	ctx := context.Background()
+	sto := newTestStore(t)
+
+	_, err := sto.AddWorkspace(ctx, &state.AddWorkspaceInput{ID: "ws", Root: "/app"})
+	require.NoError(t, err)
+	for _, c := range []struct {
+		id        string
+		dependsOn []string
+	}{
+		{"db", nil},
+		{"api", []string{"db"}},
+		{"web", []string{"api"}},
+	} {
+		_, err := sto.AddComponent(ctx, &state.AddComponentInput{
+			WorkspaceID: "ws",
+			ID:          c.id,
+			Name:        c.id,
+			Type:        "process",
+			DependsOn:   c.dependsOn,
+		})
+		require.NoError(t, err)
+	}
+
+	names := func(input *state.DescribeComponentsInput) []string {
+		input.WorkspaceID = "ws"
+		output, err := sto.DescribeComponents(ctx, input)
+		require.NoError(t, err)
+		var names []string
+		for _, component := range output.Components {
+			names = append(names, component.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"api", "db"}, names(&state.DescribeComponentsInput{
+		Refs:                []string{"api"},
+		IncludeDependencies: true,
+	}))
+	assert.Equal(t, []string{"api", "web"}, names(&state.DescribeComponentsInput{
+		Refs:              []string{"api"},
+		IncludeDependents: true,
+	}))
+}
+
+func TestImportStateFile(t *testing.T) {
+	ctx := context.Background()
+	sto := newTestStore(t)
+
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	require.NoError(t, sto.ImportStateFile(ctx, statePath), "missing file is skipped")
+
+	require.NoError(t, os.WriteFile(statePath, []byte(`{
+		"workspaces": {
+			"ws": {
+				"root": "/app",
+				"names": {"web": "b"},
+				"components": {
+					"a": {"name": "web", "type": "process", "spec": "{}", "created": "2021-10-19T00:00:00Z", "dependsOn": null, "deleted": "2021-10-20T00:00:00Z"},
+					"b": {"name": "web", "type": "process", "spec": "{}", "state": "{\"pid\":1}", "created": "2021-10-20T00:00:00Z", "dependsOn": ["db"]}
+				},
+				"driftPolicy": "repair"
+			}
+		},
+		"componentWorkspaces": {"a": "ws", "b": "ws"}
+	}`), 0600))
+	require.NoError(t, sto.ImportStateFile(ctx, statePath))
+
+	_, err := os.Stat(statePath)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(statePath + ".imported")
+	assert.NoError(t, err)
+
+	workspaces, err := sto.DescribeWorkspaces(ctx, &state.DescribeWorkspacesInput{})
+	require.NoError(t, err)
+	if assert.Len(t, workspaces.Workspaces, 1) {
+		assert.Equal(t, "/app", workspaces.Workspaces[0].Root)
+		assert.Equal(t, "repair", workspaces.Workspaces[0].DriftPolicy)
+	}
+
+	components, err := sto.DescribeComponents(ctx, &state.DescribeComponentsInput{
+		WorkspaceID:    "ws",
+		IncludeDeleted: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []state.ComponentDescription{
+		{
+			ID:          "a",
+			WorkspaceID: "ws",
+			Name:        "web",
+			Type:        "process",
+			Spec:        "{}",
+			Created:     "2021-10-19T00:00:00Z",
+			Deleted:     "2021-10-20T00:00:00Z",
+		},
+		{
+			ID:          "b",
+			WorkspaceID: "ws",
+			Name:        "web",
+			Type:        "process",
+			Spec:        "{}",
+			State:       `{"pid":1}`,
+			Created:     "2021-10-20T00:00:00Z",
+			DependsOn:   []string{"db"},
+		},
+	}, components.Components)
+}
+
+func TestSpecHistory(t *testing.T) {
+	ctx := context.Background()
+	sto := newTestStore(t)
+
+	_, err := sto.AddWorkspace(ctx, &state.AddWorkspaceInput{ID: "ws", Root: "/app"})
+	require.NoError(t, err)
+	_, err = sto.AddComponent(ctx, &state.AddComponentInput{
+		WorkspaceID: "ws",
+		ID:          "a",
+		Name:        "web",
+		Type:        "process",
+		Spec:        "v1",
+		Created:     "2021-10-20T00:00:00Z",
+		SpecSource:  "apply",
+	})
+	require.NoError(t, err)
+	patch := func(spec, source string) {
+		_, err := sto.PatchComponent(ctx, &state.PatchComponentInput{
+			ID:         "a",
+			Spec:       spec,
+			SpecSource: source,
+		})
+		require.NoError(t, err)
+	}
+	patch("v2", "edit")
+	patch("v2", "edit") // Unchanged specs are not recorded.
+	patch("", "")
+
+	history := func() []string {
+		output, err := sto.DescribeSpecRevisions(ctx, &state.DescribeSpecRevisionsInput{ID: "a"})
+		require.NoError(t, err)
+		var revisions []string
+		for _, revision := range output.Revisions {
+			revisions = append(revisions, fmt.Sprintf("%d %s %s", revision.Revision, revision.Source, revision.Spec))
+		}
+		return revisions
+	}
+	assert.Equal(t, []string{"1 apply v1", "2 edit v2"}, history())
+
+	// Re-creating a deleted component continues its history.
+	_, err = sto.DeleteComponent(ctx, &state.DeleteComponentInput{ID: "a", Deleted: "2021-10-21T00:00:00Z"})
+	require.NoError(t, err)
+	_, err = sto.AddComponent(ctx, &state.AddComponentInput{
+		WorkspaceID: "ws",
+		ID:          "a",
+		Name:        "web",
+		Type:        "process",
+		Spec:        "v1",
+		Created:     "2021-10-22T00:00:00Z",
+		SpecSource:  "apply",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1 apply v1", "2 edit v2", "3 apply v1"}, history())
+
+	_, err = sto.RemoveComponent(ctx, &state.RemoveComponentInput{ID: "a"})
+	require.NoError(t, err)
+	_, err = sto.DescribeSpecRevisions(ctx, &state.DescribeSpecRevisionsInput{ID: "a"})
+	assert.Error(t, err)
+}
@@ -9,8 +9,10 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/deref/exo/internal/chrono"
 	"github.com/deref/exo/internal/core/state/api"
 	state "github.com/deref/exo/internal/core/state/api"
+	"github.com/deref/exo/internal/core/state/displayname"
 	"github.com/deref/exo/internal/deps"
 	"github.com/deref/exo/internal/util/atom"
 	"github.com/deref/exo/internal/util/errutil"
@@ -41,6 +43,18 @@ type Component struct {
 	State     string   `json:"state"`
 	Created   string   `json:"created"`
 	DependsOn []string `json:"dependsOn"`
+	// Set for tombstones of deleted components.
+	Deleted     string               `json:"deleted,omitempty"`
+	SpecHistory []state.SpecRevision `json:"specHistory,omitempty"`
+}
+
+func (c *Component) addSpecRevision(timestamp, source string) {
+	c.SpecHistory = append(c.SpecHistory, state.SpecRevision{
+		Revision:  len(c.SpecHistory) + 1,
+		Timestamp: timestamp,
+		Source:    source,
+		Spec:      c.Spec,
+	})
 }
 
 func (c *Component) getDescription(id, workspaceID string) state.ComponentDescription {
@@ -53,6 +67,7 @@ func (c *Component) getDescription(id, workspaceID string) state.ComponentDescri
 		State:       c.State,
 		Created:     c.Created,
 		DependsOn:   c.DependsOn,
+		Deleted:     c.Deleted,
 	}
 }
 
@@ -90,15 +105,17 @@ func (sto *Store) DescribeWorkspaces(ctx context.Context, input *state.DescribeW
 		ids[id] = true
 	}
 
-	dnb := newDisplayNameBuilder(string(filepath.Separator))
+	dnb := displayname.NewBuilder(string(filepath.Separator))
 
 	var output state.DescribeWorkspacesOutput
 	for id, workspace := range root.Workspaces {
 		dnb.AddPath(workspace.Root)
 		if ids == nil || ids[id] {
 			output.Workspaces = append(output.Workspaces, state.WorkspaceDescription{
-				ID:   id,
-				Root: workspace.Root,
+				ID:          id,
+				Root:        workspace.Root,
+				ApplyWatch:  workspace.ApplyWatch,
+				DriftPolicy: workspace.DriftPolicy,
 			})
 		}
 	}
@@ -147,8 +164,13 @@ func (sto *Store) RemoveWorkspace(ctx context.Context, input *state.RemoveWorksp
 		if workspace == nil {
 			return nil
 		}
-		if len(workspace.Components) > 0 {
-			return fmt.Errorf("cannot remove non-empty workspace %q", input.ID)
+		for _, component := range workspace.Components {
+			if component.Deleted == "" {
+				return fmt.Errorf("cannot remove non-empty workspace %q", input.ID)
+			}
+		}
+		for id := range workspace.Components {
+			delete(root.ComponentWorkspaces, id)
 		}
 		delete(root.Workspaces, input.ID)
 		return nil
@@ -159,6 +181,26 @@ func (sto *Store) RemoveWorkspace(ctx context.Context, input *state.RemoveWorksp
 	return &state.RemoveWorkspaceOutput{}, nil
 }
 
+func (sto *Store) PatchWorkspace(ctx context.Context, input *state.PatchWorkspaceInput) (*state.PatchWorkspaceOutput, error) {
+	_, err := sto.swap(func(root *Root) error {
+		workspace := root.Workspaces[input.ID]
+		if workspace == nil {
+			return errutil.HTTPErrorf(http.StatusNotFound, "no such workspace: %q", input.ID)
+		}
+		if input.ApplyWatch != nil {
+			workspace.ApplyWatch = *input.ApplyWatch
+		}
+		if input.DriftPolicy != nil {
+			workspace.DriftPolicy = *input.DriftPolicy
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.PatchWorkspaceOutput{}, nil
+}
+
 func (sto *Store) ResolveWorkspace(ctx context.Context, input *state.ResolveWorkspaceInput) (*state.ResolveWorkspaceOutput, error) {
 	var root Root
 	if err := sto.atom.Deref(&root); err != nil {
@@ -251,6 +293,9 @@ func (sto *Store) DescribeComponents(ctx context.Context, input *state.DescribeC
 	}
 
 	for componentID, component := range workspace.Components {
+		if component.Deleted != "" && !input.IncludeDeleted {
+			continue
+		}
 		if (refs == nil || refs[componentID] || refs[component.Name]) &&
 			(types == nil || types[component.Type]) {
 			output.Components = append(output.Components, component.getDescription(componentID, input.WorkspaceID))
@@ -358,17 +403,26 @@ func (sto *Store) AddComponent(ctx context.Context, input *state.AddComponentInp
 		if workspace.Names[input.Name] != "" {
 			return errutil.HTTPErrorf(http.StatusConflict, "component named %q already exists", input.Name)
 		}
-		workspace.Names[input.Name] = input.ID
-		if workspace.Components[input.ID] != nil {
+		// The tombstone of a deleted component may be replaced by a component
+		// with the same ID.
+		// Its spec history is kept.
+		existing := workspace.Components[input.ID]
+		if existing != nil && existing.Deleted == "" {
 			return fmt.Errorf("component id %q already exists", input.ID)
 		}
-		workspace.Components[input.ID] = &Component{
+		component := &Component{
 			Name:      input.Name,
 			Type:      input.Type,
 			Spec:      input.Spec,
 			Created:   input.Created,
 			DependsOn: input.DependsOn,
 		}
+		if existing != nil {
+			component.SpecHistory = existing.SpecHistory
+		}
+		component.addSpecRevision(input.Created, input.SpecSource)
+		workspace.Names[input.Name] = input.ID
+		workspace.Components[input.ID] = component
 		root.ComponentWorkspaces[input.ID] = input.WorkspaceID
 		return nil
 	})
@@ -406,8 +460,9 @@ func (sto *Store) PatchComponent(ctx context.Context, input *state.PatchComponen
 		if input.DependsOn != nil {
 			component.DependsOn = *input.DependsOn
 		}
-		if input.Spec != "" {
+		if input.Spec != "" && input.Spec != component.Spec {
 			component.Spec = input.Spec
+			component.addSpecRevision(chrono.NowString(ctx), input.SpecSource)
 		}
 		if input.State != "" {
 			component.State = input.State
@@ -421,6 +476,87 @@ func (sto *Store) PatchComponent(ctx context.Context, input *state.PatchComponen
 
 }
 
+func (sto *Store) DescribeSpecRevisions(ctx context.Context, input *state.DescribeSpecRevisionsInput) (*state.DescribeSpecRevisionsOutput, error) {
+	root, err := sto.deref()
+	if err != nil {
+		return nil, err
+	}
+	_, component, err := root.findComponent(input.ID)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]state.SpecRevision, len(component.SpecHistory))
+	copy(revisions, component.SpecHistory)
+	return &state.DescribeSpecRevisionsOutput{
+		Revisions: revisions,
+	}, nil
+}
+
+func (sto *Store) DeleteComponent(ctx context.Context, input *state.DeleteComponentInput) (*state.DeleteComponentOutput, error) {
+	if input.Deleted == "" {
+		return nil, errors.New("deleted is required")
+	}
+	_, err := sto.swap(func(root *Root) error {
+		workspace, component, err := root.findComponent(input.ID)
+		if err != nil {
+			return err
+		}
+		if component.Deleted != "" {
+			return nil
+		}
+		component.Deleted = input.Deleted
+		if workspace.Names[component.Name] == input.ID {
+			delete(workspace.Names, component.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.DeleteComponentOutput{}, nil
+}
+
+func (sto *Store) UndeleteComponent(ctx context.Context, input *state.UndeleteComponentInput) (*state.UndeleteComponentOutput, error) {
+	_, err := sto.swap(func(root *Root) error {
+		workspace, component, err := root.findComponent(input.ID)
+		if err != nil {
+			return err
+		}
+		if component.Deleted == "" {
+			return errutil.HTTPErrorf(http.StatusBadRequest, "component %q is not deleted", component.Name)
+		}
+		if workspace.Names == nil {
+			workspace.Names = make(map[string]string)
+		}
+		if workspace.Names[component.Name] != "" {
+			return errutil.HTTPErrorf(http.StatusConflict, "component named %q already exists", component.Name)
+		}
+		workspace.Names[component.Name] = input.ID
+		component.Deleted = ""
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state.UndeleteComponentOutput{}, nil
+}
+
+func (root *Root) findComponent(id string) (*Workspace, *Component, error) {
+	workspaceID := root.ComponentWorkspaces[id]
+	if workspaceID == "" {
+		return nil, nil, fmt.Errorf("cannot find workspace for component %q", id)
+	}
+	workspace := root.Workspaces[workspaceID]
+	if workspace == nil {
+		return nil, nil, fmt.Errorf("component %q has invalid workspace %q", id, workspaceID)
+	}
+	component := workspace.Components[id]
+	if component == nil {
+		return nil, nil, fmt.Errorf("no component for id: %q", id)
+	}
+	return workspace, component, nil
+}
+
 func (sto *Store) RemoveComponent(ctx context.Context, input *state.RemoveComponentInput) (*state.RemoveComponentOutput, error) {
 	_, err := sto.swap(func(root *Root) error {
 		workspaceID := root.ComponentWorkspaces[input.ID]
@@ -439,7 +575,8 @@ func (sto *Store) RemoveComponent(ctx context.Context, input *state.RemoveCompon
 			return fmt.Errorf("no component for id: %q", input.ID)
 		}
 		delete(workspace.Components, input.ID)
-		if workspace.Names != nil {
+		// The name of a tombstone may have been reused.
+		if workspace.Names != nil && workspace.Names[component.Name] == input.ID {
 			delete(workspace.Names, component.Name)
 		}
 		if root.ComponentWorkspaces != nil {
@@ -0,0 +1,105 @@
+package statefile
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	state "github.com/deref/exo/internal/core/state/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTombstones(t *testing.T) {
+	ctx := context.Background()
+	sto := New(filepath.Join(t.TempDir(), "state.json"))
+
+	_, err := sto.AddWorkspace(ctx, &state.AddWorkspaceInput{ID: "ws", Root: "/app"})
+	require.NoError(t, err)
+	add := func(id string) error {
+		_, err := sto.AddComponent(ctx, &state.AddComponentInput{
+			WorkspaceID: "ws",
+			ID:          id,
+			Name:        "web",
+			Type:        "process",
+		})
+		return err
+	}
+	describe := func(includeDeleted bool) []state.ComponentDescription {
+		output, err := sto.DescribeComponents(ctx, &state.DescribeComponentsInput{
+			WorkspaceID:    "ws",
+			IncludeDeleted: includeDeleted,
+		})
+		require.NoError(t, err)
+		return output.Components
+	}
+
+	require.NoError(t, add("a"))
+	_, err = sto.DeleteComponent(ctx, &state.DeleteComponentInput{ID: "a", Deleted: "2021-10-20T00:00:00Z"})
+	require.NoError(t, err)
+	assert.Empty(t, describe(false))
+	if tombstones := describe(true); assert.Len(t, tombstones, 1) {
+		assert.Equal(t, "2021-10-20T00:00:00Z", tombstones[0].Deleted)
+	}
+	resolved, err := sto.Resolve(ctx, &state.ResolveInput{WorkspaceID: "ws", Refs: []string{"a", "web"}})
+	require.NoError(t, err)
+	assert.Equal(t, []*string{nil, nil}, resolved.IDs)
+
+	// The name of a tombstone may be reused.
+	require.NoError(t, add("b"))
+	_, err = sto.UndeleteComponent(ctx, &state.UndeleteComponentInput{ID: "a"})
+	assert.Error(t, err)
+
+	// Removing the tombstone leaves the name to its new owner.
+	_, err = sto.RemoveComponent(ctx, &state.RemoveComponentInput{ID: "a"})
+	require.NoError(t, err)
+	resolved, err = sto.Resolve(ctx, &state.ResolveInput{WorkspaceID: "ws", Refs: []string{"web"}})
+	require.NoError(t, err)
+	if assert.NotNil(t, resolved.IDs[0]) {
+		assert.Equal(t, "b", *resolved.IDs[0])
+	}
+
+	_, err = sto.DeleteComponent(ctx, &state.DeleteComponentInput{ID: "b", Deleted: "2021-10-20T00:00:00Z"})
+	require.NoError(t, err)
+	_, err = sto.UndeleteComponent(ctx, &state.UndeleteComponentInput{ID: "b"})
+	require.NoError(t, err)
+	if components := describe(false); assert.Len(t, components, 1) {
+		assert.Equal(t, "b", components[0].ID)
+		assert.Empty(t, components[0].Deleted)
+	}
+}
+
+func TestSpecHistory(t *testing.T) {
+	ctx := context.Background()
+	sto := New(filepath.Join(t.TempDir(), "state.json"))
+
+	_, err := sto.AddWorkspace(ctx, &state.AddWorkspaceInput{ID: "ws", Root: "/app"})
+	require.NoError(t, err)
+	_, err = sto.AddComponent(ctx, &state.AddComponentInput{
+		WorkspaceID: "ws",
+		ID:          "a",
+		Name:        "web",
+		Type:        "process",
+		Spec:        "v1",
+		Created:     "2021-10-20T00:00:00Z",
+		SpecSource:  "apply",
+	})
+	require.NoError(t, err)
+	for _, spec := range []string{"v2", "v2", ""} {
+		_, err := sto.PatchComponent(ctx, &state.PatchComponentInput{
+			ID:         "a",
+			Spec:       spec,
+			SpecSource: "edit",
+		})
+		require.NoError(t, err)
+	}
+
+	output, err := sto.DescribeSpecRevisions(ctx, &state.DescribeSpecRevisionsInput{ID: "a"})
+	require.NoError(t, err)
+	if assert.Len(t, output.Revisions, 2) {
+		assert.Equal(t, state.SpecRevision{Revision: 1, Timestamp: "2021-10-20T00:00:00Z", Source: "apply", Spec: "v1"}, output.Revisions[0])
+		assert.Equal(t, 2, output.Revisions[1].Revision)
+		assert.Equal(t, "edit", output.Revisions[1].Source)
+		assert.Equal(t, "v2", output.Revisions[1].Spec)
+	}
+}
@@ -1,15 +1,17 @@
 package statefile
 
 type Workspace struct {
-	Root       string                `json:"root"`
-	Names      map[string]string     `json:"names"`      // Name -> ID.
-	Components map[string]*Component `json:"components"` // Keyed by ID.
+	Root        string                `json:"root"`
+	Names       map[string]string     `json:"names"`      // Name -> ID.
+	Components  map[string]*Component `json:"components"` // Keyed by ID.
+	ApplyWatch  string                `json:"applyWatch,omitempty"`
+	DriftPolicy string                `json:"driftPolicy,omitempty"`
 }
 
 func (ws *Workspace) resolve(refs []string) []*string {
 	results := make([]*string, len(refs))
 	for i, ref := range refs {
-		if _, isID := ws.Components[ref]; isID {
+		if component, isID := ws.Components[ref]; isID && component.Deleted == "" {
 			id := ref
 			results[i] = &id
 			continue
@@ -102,11 +102,26 @@ func (g *Graph) UnmetDependencies() []string {
 	return idSlice
 }
 
+// CycleError is returned when adding an edge would introduce a circular dependency.
+type CycleError struct {
+	// Path is the sequence of node IDs forming the cycle. The first and last
+	// elements are the same node.
+	Path []string
+}
+
+func (err *CycleError) Error() string {
+	return fmt.Sprintf("circular dependencies not allowed: %s", strings.Join(err.Path, " -> "))
+}
+
 // AddEdge registers a dependency between the node identified by parentID and the node identified by childID.
 // This assumes that the nodes will be added separately via AddNode() or DependOn().
+// If the new edge would introduce a cycle, a *CycleError is returned and the graph is not modified.
 func (g *Graph) AddEdge(parentID, childID string) error {
-	if g.dependsOn(childID, parentID) {
-		return errors.New("circular dependencies not allowed")
+	if parentID == childID {
+		return &CycleError{Path: []string{parentID, childID}}
+	}
+	if path := g.dependencyPath(childID, parentID); path != nil {
+		return &CycleError{Path: append([]string{parentID}, path...)}
 	}
 
 	updateNodeIDs(g.dependencies, parentID, func(ids []string) []string {
@@ -119,6 +134,37 @@ func (g *Graph) AddEdge(parentID, childID string) error {
 	return nil
 }
 
+// dependencyPath returns the shortest chain of dependency edges leading from
+// fromID to toID, inclusive of both ends, or nil if there is no such chain.
+// Unlike the transitive queries, edges are followed even if their nodes have
+// not yet been added to the graph.
+func (g *Graph) dependencyPath(fromID, toID string) []string {
+	prev := map[string]string{fromID: ""}
+	queue := []string{fromID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == toID {
+			var path []string
+			for ; id != fromID; id = prev[id] {
+				path = append(path, id)
+			}
+			path = append(path, fromID)
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path
+		}
+		for _, next := range g.dependencies[id] {
+			if _, seen := prev[next]; !seen {
+				prev[next] = id
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
 func (g *Graph) DependsOn(node, dep Node) bool {
 	return g.dependsOn(node.ID(), dep.ID())
 }
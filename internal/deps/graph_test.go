@@ -1,6 +1,7 @@
 package deps_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/deref/exo/internal/deps"
@@ -134,3 +135,20 @@ func TestLayeredTopologicalSort(t *testing.T) {
 	assert.ElementsMatch(t, []deps.StringNode{"aggregator"}, layers[2])
 	assert.ElementsMatch(t, []deps.StringNode{"web"}, layers[3])
 }
+
+func TestCycleError(t *testing.T) {
+	g := deps.New()
+	assert.NoError(t, g.AddEdge("a", "b"))
+	assert.NoError(t, g.AddEdge("b", "c"))
+
+	err := g.AddEdge("c", "a")
+	var cycleErr *deps.CycleError
+	if assert.True(t, errors.As(err, &cycleErr)) {
+		assert.Equal(t, []string{"c", "a", "b", "c"}, cycleErr.Path)
+	}
+
+	err = g.AddEdge("d", "d")
+	if assert.True(t, errors.As(err, &cycleErr)) {
+		assert.Equal(t, []string{"d", "d"}, cycleErr.Path)
+	}
+}
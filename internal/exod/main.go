@@ -11,10 +11,11 @@ import (
 	golog "log"
 
 	"github.com/deref/exo/gui"
+	"github.com/deref/exo/internal/chrono"
 	"github.com/deref/exo/internal/config"
 	"github.com/deref/exo/internal/core/server"
 	kernel "github.com/deref/exo/internal/core/server"
-	"github.com/deref/exo/internal/core/state/statefile"
+	statesqlite "github.com/deref/exo/internal/core/state/sqlite"
 	"github.com/deref/exo/internal/esv"
 	eventdapi "github.com/deref/exo/internal/eventd/api"
 	eventdsqlite "github.com/deref/exo/internal/eventd/sqlite"
@@ -23,7 +24,7 @@ import (
 	"github.com/deref/exo/internal/syslogd"
 	"github.com/deref/exo/internal/task"
 	"github.com/deref/exo/internal/task/api"
-	taskserver "github.com/deref/exo/internal/task/server"
+	tasksqlite "github.com/deref/exo/internal/task/sqlite"
 	"github.com/deref/exo/internal/telemetry"
 	"github.com/deref/exo/internal/token"
 	"github.com/deref/exo/internal/util/cmdutil"
@@ -100,8 +101,18 @@ func RunServer(ctx context.Context, flags map[string]string) {
 		cmdutil.Fatalf("chdir failed: %w", err)
 	}
 
-	statePath := filepath.Join(cfg.VarDir, "state.json")
-	store := statefile.New(statePath)
+	tombstoneRetention, err := time.ParseDuration(cfg.Components.TombstoneRetention)
+	if err != nil {
+		cmdutil.Fatalf("invalid tombstone retention: %v", err)
+	}
+	driftCheckInterval, err := time.ParseDuration(cfg.Components.DriftCheckInterval)
+	if err != nil {
+		cmdutil.Fatalf("invalid drift check interval: %v", err)
+	}
+	jobRetention, err := time.ParseDuration(cfg.Jobs.Retention)
+	if err != nil {
+		cmdutil.Fatalf("invalid job retention: %v", err)
+	}
 
 	dbPath := filepath.Join(cfg.VarDir, "exo.sqlite3")
 	// Fully serialize transactions. Hurts performance, but reasonable for
@@ -120,13 +131,38 @@ func RunServer(ctx context.Context, flags map[string]string) {
 		}
 	}()
 
+	store := &statesqlite.Store{
+		DB: db,
+	}
+	if err := store.Migrate(ctx); err != nil {
+		cmdutil.Fatalf("migrating state store: %v", err)
+	}
+	// As a one-time migration, import state from the old JSON state file.
+	// TODO: Remove once upgrades from 2021.10.12, the last release to use
+	// state.json, are no longer supported.
+	statePath := filepath.Join(cfg.VarDir, "state.json")
+	if err := store.ImportStateFile(ctx, statePath); err != nil {
+		cmdutil.Fatalf("importing state file: %v", err)
+	}
+
 	dockerClient, err := docker.NewClientWithOpts()
 	if err != nil {
 		cmdutil.Fatalf("failed to create docker client: %v", err)
 	}
 
+	taskStore := &tasksqlite.Store{
+		DB: db,
+	}
+	if err := taskStore.Migrate(ctx); err != nil {
+		cmdutil.Fatalf("migrating task store: %v", err)
+	}
+	// Jobs that were running when the daemon last exited will never finish.
+	if err := taskStore.InterruptTasks(ctx); err != nil {
+		cmdutil.Fatalf("interrupting unfinished tasks: %v", err)
+	}
+
 	taskTracker := &task.TaskTracker{
-		Store:  taskserver.NewTaskStore(),
+		Store:  taskStore,
 		Logger: logger,
 	}
 
@@ -139,6 +175,10 @@ func RunServer(ctx context.Context, flags map[string]string) {
 		TaskTracker: taskTracker,
 		TokenClient: cfg.GetTokenClient(),
 		EsvClient:   esv.NewEsvClient(cfg.EsvTokenPath),
+		JobConcurrency: kernel.JobConcurrency{
+			Default:     cfg.Jobs.Concurrency,
+			ByOperation: cfg.Jobs.OperationConcurrency,
+		},
 	}
 
 	// As a one-time migration, simply delete all logs in the old Badger format.
@@ -186,6 +226,25 @@ func RunServer(ctx context.Context, flags map[string]string) {
 			}
 		}()
 
+		manifestWatcher := &server.ManifestWatcher{
+			Config:       kernelCfg,
+			PollInterval: 2 * time.Second,
+		}
+		go manifestWatcher.Run(ctx)
+
+		reconciler := &server.Reconciler{
+			Config:   kernelCfg,
+			Interval: driftCheckInterval,
+		}
+		go reconciler.Run(ctx)
+
+		tombstoneCollector := &server.TombstoneCollector{
+			Config:    kernelCfg,
+			Retention: tombstoneRetention,
+			Interval:  time.Minute,
+		}
+		go tombstoneCollector.Run(ctx)
+
 		go func() {
 			if err := syslogServer.Run(ctx); err != nil {
 				cmdutil.Fatalf("syslog server error: %w", err)
@@ -196,9 +255,12 @@ func RunServer(ctx context.Context, flags map[string]string) {
 			for {
 				select {
 				case <-ctx.Done():
-				case <-time.After(10 * time.Second):
-					if _, err := taskTracker.Store.EvictTasks(ctx, &api.EvictTasksInput{}); err != nil {
-						logger.Infof("task eviction error: %w", err)
+					return
+				case <-time.After(time.Minute):
+					if _, err := taskTracker.Store.EvictTasks(ctx, &api.EvictTasksInput{
+						Before: chrono.IsoNano(chrono.Now(ctx).Add(-jobRetention)),
+					}); err != nil {
+						logger.Infof("task eviction error: %v", err)
 					}
 				}
 			}
@@ -1,9 +1,11 @@
 package compose
 
 import (
-	"bytes"
 	"fmt"
+	"io/ioutil"
+	"math"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/deref/exo/internal/manifest/exohcl"
@@ -18,10 +20,23 @@ import (
 type Converter struct {
 	// ProjectName is used as a prefix for the resources created by this importer.
 	ProjectName string
+	// Filename of the converted file. Used to resolve `extends`.
+	Filename string
+	// Overrides are additional compose files that are merged in to the
+	// converted file, in order.
+	Overrides []compose.File
+	// ReadFile reads the files of extended services. Defaults to
+	// ioutil.ReadFile.
+	ReadFile func(path string) ([]byte, error)
 }
 
 func (c *Converter) Convert(bs []byte) (*hcl.File, hcl.Diagnostics) {
-	project, err := compose.Parse(bytes.NewBuffer(bs))
+	files := append([]compose.File{{Filename: c.Filename, Bytes: bs}}, c.Overrides...)
+	readFile := c.ReadFile
+	if readFile == nil {
+		readFile = ioutil.ReadFile
+	}
+	project, err := compose.LoadWith(readFile, files...)
 	if err != nil {
 		return nil, hcl.Diagnostics{
 			&hcl.Diagnostic{
@@ -54,7 +69,9 @@ func (c *Converter) Convert(bs []byte) (*hcl.File, hcl.Diagnostics) {
 		}
 		volumeKeyToName[volume.Key] = volume.Name.Value
 
-		b.AddComponentBlock(makeComponentBlock("volume", name, volume, nil))
+		block, blockDiags := makeComponentBlock("volume", name, volume, nil)
+		diags = append(diags, blockDiags...)
+		b.AddComponentBlock(block)
 	}
 
 	// Set up networks.
@@ -81,7 +98,9 @@ func (c *Converter) Convert(bs []byte) (*hcl.File, hcl.Diagnostics) {
 			network.Driver = compose.MakeString("bridge")
 		}
 
-		b.AddComponentBlock(makeComponentBlock("network", name, network, nil))
+		block, blockDiags := makeComponentBlock("network", name, network, nil)
+		diags = append(diags, blockDiags...)
+		b.AddComponentBlock(block)
 	}
 	// TODO: Docker Compose only creates the default network if there is at least 1 service that does not
 	// specify a network. We should do the same.
@@ -90,10 +109,26 @@ func (c *Converter) Convert(bs []byte) (*hcl.File, hcl.Diagnostics) {
 		name := c.prefixedName(key, "")
 		networkKeyToName[key] = name
 
-		b.AddComponentBlock(makeComponentBlock("network", key, map[string]string{
+		block, blockDiags := makeComponentBlock("network", key, map[string]string{
 			"name":   name,
 			"driver": "bridge",
-		}, nil))
+		}, nil)
+		diags = append(diags, blockDiags...)
+		b.AddComponentBlock(block)
+	}
+
+	secrets := make(map[string]compose.Secret, len(project.Secrets))
+	for _, secret := range project.Secrets {
+		secrets[secret.Key] = secret
+	}
+	configs := make(map[string]compose.Config, len(project.Configs))
+	for _, config := range project.Configs {
+		configs[config.Key] = config
+	}
+
+	replicas := make(map[string]int, len(project.Services))
+	for _, service := range project.Services {
+		replicas[service.Key] = serviceReplicas(service)
 	}
 
 	for _, service := range project.Services {
@@ -102,15 +137,21 @@ func (c *Converter) Convert(bs []byte) (*hcl.File, hcl.Diagnostics) {
 			var subject *hcl.Range
 			diags = append(diags, exohcl.NewRenameWarning(service.Key, name, subject))
 		}
+		if replicas[service.Key] == 0 {
+			continue
+		}
 		var dependsOn []string
 
-		if service.ContainerName.Value == "" {
-			// The generated container name intentionally matches the container name generated by Docker Compose
-			// when the scale is set at 1. When we address scaling containers, this will need to be updated to
-			// use a different suffix for each container.
-			service.ContainerName = compose.MakeString(c.prefixedName(service.Key, "1"))
+		if replicas[service.Key] > 1 && service.ContainerName.Value != "" {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("service %q specifies container_name, so cannot have more than one replica", service.Key),
+			})
+			continue
 		}
 
+		diags = append(diags, c.convertDeploy(&service)...)
+
 		for _, item := range service.Labels.Items {
 			if strings.HasPrefix(item.Key, "com.docker.compose") {
 				diags = append(diags, &hcl.Diagnostic{
@@ -173,6 +214,52 @@ func (c *Converter) Convert(bs []byte) (*hcl.File, hcl.Diagnostics) {
 			}
 		}
 
+		// Secrets and configs are copied in to the container, so their sources are
+		// inlined in to the service's references to them.
+		for i := range service.Secrets {
+			ref := &service.Secrets[i]
+			secret, ok := secrets[ref.Source.Value]
+			if !ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("unknown secret: %q", ref.Source.Value),
+				})
+				continue
+			}
+			if secret.External.Value {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("external secrets are not supported, but %q is external", secret.Key),
+				})
+				continue
+			}
+			ref.ShortForm = compose.String{}
+			ref.File = secret.File
+			ref.Environment = secret.Environment
+		}
+		for i := range service.Configs {
+			ref := &service.Configs[i]
+			config, ok := configs[ref.Source.Value]
+			if !ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("unknown config: %q", ref.Source.Value),
+				})
+				continue
+			}
+			if config.External.Value {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  fmt.Sprintf("external configs are not supported, but %q is external", config.Key),
+				})
+				continue
+			}
+			ref.ShortForm = compose.String{}
+			ref.File = config.File
+			ref.Environment = config.Environment
+			ref.Content = config.Content
+		}
+
 		for _, dependency := range service.DependsOn.Items {
 			condition := dependency.Condition
 			if condition.Value == "" {
@@ -186,7 +273,18 @@ func (c *Converter) Convert(bs []byte) (*hcl.File, hcl.Diagnostics) {
 					subject,
 				))
 			}
-			dependsOn = append(dependsOn, exohcl.MangleName(dependency.Service.Value))
+			// Dependents depend on every replica of a service, or on none if it
+			// has no replicas.
+			dependencyReplicas := replicas[dependency.Service.Value]
+			if dependencyReplicas == 0 {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  fmt.Sprintf("service %q depends on %q, which has no replicas, so the dependency is ignored", service.Key, dependency.Service.Value),
+				})
+			}
+			for i := 1; i <= dependencyReplicas; i++ {
+				dependsOn = append(dependsOn, replicaComponentName(exohcl.MangleName(dependency.Service.Value), i))
+			}
 		}
 
 		for idx, link := range service.Links {
@@ -221,15 +319,112 @@ func (c *Converter) Convert(bs []byte) (*hcl.File, hcl.Diagnostics) {
 			mangledServiceName := exohcl.MangleName(linkService)
 			containerName := c.prefixedName(mangledServiceName, "1")
 			service.Links[idx] = compose.MakeString(fmt.Sprintf("%s:%s", containerName, linkAlias))
-			dependsOn = append(dependsOn, mangledServiceName)
+			if replicas[linkService] == 0 {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagWarning,
+					Summary:  fmt.Sprintf("service %q links to %q, which has no replicas", service.Key, linkService),
+				})
+				continue
+			}
+			// Links are to the first replica only.
+			dependsOn = append(dependsOn, replicaComponentName(mangledServiceName, 1))
 		}
 
-		b.AddComponentBlock(makeComponentBlock("container", name, service, dependsOn))
+		// The generated container names intentionally match the container names
+		// generated by Docker Compose. Replicas after the first are given their
+		// own components.
+		for i := 1; i <= replicas[service.Key]; i++ {
+			replica := service
+			if replica.ContainerName.Value == "" {
+				replica.ContainerName = compose.MakeString(c.prefixedName(service.Key, strconv.Itoa(i)))
+			}
+			block, blockDiags := makeComponentBlock("container", replicaComponentName(name, i), replica, dependsOn)
+			diags = append(diags, blockDiags...)
+			b.AddComponentBlock(block)
+		}
 	}
 
 	return b.Build(), diags
 }
 
+// replicaComponentName returns the name of the component for the ith replica
+// of a service, counting from 1. The first replica is named after the service.
+func replicaComponentName(name string, i int) string {
+	if i == 1 {
+		return name
+	}
+	return fmt.Sprintf("%s-%d", name, i)
+}
+
+// serviceReplicas returns the number of containers to create for a service.
+func serviceReplicas(service compose.Service) int {
+	if service.Deploy != nil && service.Deploy.Replicas != nil {
+		return service.Deploy.Replicas.Int()
+	}
+	if service.Scale != nil {
+		return service.Scale.Int()
+	}
+	return 1
+}
+
+// convertDeploy warns about, and removes, the parts of a service's deploy
+// configuration that only apply to Swarm. See NOTE [DOCKER SWARM FEATURES].
+func (c *Converter) convertDeploy(service *compose.Service) hcl.Diagnostics {
+	deploy := service.Deploy
+	if deploy == nil {
+		return nil
+	}
+	var diags hcl.Diagnostics
+	var subject *hcl.Range
+	unsupported := func(field string) {
+		diags = append(diags, exohcl.NewUnsupportedFeatureWarning(
+			fmt.Sprintf("deploy.%s", field),
+			fmt.Sprintf("It only applies to Docker Swarm, so is ignored for service %q.", service.Key),
+			subject,
+		))
+	}
+
+	if deploy.Mode.Value != "" && deploy.Mode.Value != "replicated" {
+		unsupported("mode")
+		deploy.Mode = compose.String{}
+	}
+	if deploy.EndpointMode.Value != "" {
+		unsupported("endpoint_mode")
+		deploy.EndpointMode = compose.String{}
+	}
+	if len(deploy.Labels.Items) > 0 {
+		unsupported("labels")
+		deploy.Labels = compose.Dictionary{}
+	}
+	if deploy.Placement != nil {
+		unsupported("placement")
+		deploy.Placement = nil
+	}
+	if deploy.RollbackConfig != nil {
+		unsupported("rollback_config")
+		deploy.RollbackConfig = nil
+	}
+	if deploy.UpdateConfig != nil {
+		unsupported("update_config")
+		deploy.UpdateConfig = nil
+	}
+	if policy := deploy.RestartPolicy; policy != nil {
+		if policy.Delay != nil {
+			unsupported("restart_policy.delay")
+			policy.Delay = nil
+		}
+		if policy.Window != nil {
+			unsupported("restart_policy.window")
+			policy.Window = nil
+		}
+	}
+	if reservations := deploy.Resources.Reservations; reservations != nil && reservations.CPUs.Value != "" {
+		unsupported("resources.reservations.cpus")
+		reservations.CPUs = compose.String{}
+	}
+	return diags
+}
+
 func (c *Converter) prefixedName(name string, suffix string) string {
 	var out strings.Builder
 	out.WriteString(c.ProjectName)
@@ -243,8 +438,9 @@ func (c *Converter) prefixedName(name string, suffix string) string {
 	return out.String()
 }
 
-func makeComponentBlock(typ string, name string, spec interface{}, dependsOn []string) *hclgen.Block {
-	obj := yamlToHCL(spec).(*hclsyntax.ObjectConsExpr)
+func makeComponentBlock(typ string, name string, spec interface{}, dependsOn []string) (*hclgen.Block, hcl.Diagnostics) {
+	specExpr, diags := yamlToHCL(spec)
+	obj := specExpr.(*hclsyntax.ObjectConsExpr)
 	attrs := make([]*hclsyntax.Attribute, len(obj.Items))
 	for i, item := range obj.Items {
 		key := item.KeyExpr.(*hclsyntax.ObjectConsKeyExpr)
@@ -263,13 +459,15 @@ func makeComponentBlock(typ string, name string, spec interface{}, dependsOn []s
 	}
 	var blocks []*hclgen.Block
 	if len(dependsOn) > 0 {
+		dependsOnExpr, dependsOnDiags := yamlToHCL(dependsOn)
+		diags = append(diags, dependsOnDiags...)
 		blocks = append(blocks, &hclgen.Block{
 			Type: "_",
 			Body: &hclgen.Body{
 				Attributes: []*hclsyntax.Attribute{
 					{
 						Name: "depends_on",
-						Expr: yamlToHCL(dependsOn),
+						Expr: dependsOnExpr,
 					},
 				},
 			},
@@ -282,17 +480,20 @@ func makeComponentBlock(typ string, name string, spec interface{}, dependsOn []s
 			Attributes: attrs,
 			Blocks:     blocks,
 		},
-	}
+	}, diags
 }
 
-func yamlToHCL(v interface{}) hclsyntax.Expression {
+// yamlToHCL converts a value, as it would be marshalled to YAML, to an HCL
+// expression. Values that HCL cannot represent, such as infinite numbers, are
+// reported as diagnostics.
+func yamlToHCL(v interface{}) (hclsyntax.Expression, hcl.Diagnostics) {
 	if v == nil {
-		return hclgen.NewNullLiteral(hcl.Range{})
+		return hclgen.NewNullLiteral(hcl.Range{}), nil
 	}
 	rv := reflect.ValueOf(v)
 	for rv.Kind() == reflect.Ptr {
 		if rv.IsNil() {
-			return hclgen.NewNullLiteral(hcl.Range{})
+			return hclgen.NewNullLiteral(hcl.Range{}), nil
 		}
 		rv = rv.Elem()
 	}
@@ -309,59 +510,79 @@ func yamlToHCL(v interface{}) hclsyntax.Expression {
 
 	switch v := v.(type) {
 	case string:
-		return hclgen.NewStringLiteral(v, hcl.Range{})
+		return hclgen.NewStringLiteral(v, hcl.Range{}), nil
 	case int:
 		return &hclsyntax.LiteralValueExpr{
 			Val: cty.NumberIntVal(int64(v)),
-		}
+		}, nil
 	case int16:
 		return &hclsyntax.LiteralValueExpr{
 			Val: cty.NumberIntVal(int64(v)),
-		}
+		}, nil
 	case uint16:
 		return &hclsyntax.LiteralValueExpr{
 			Val: cty.NumberIntVal(int64(v)),
-		}
+		}, nil
 	case int64:
 		return &hclsyntax.LiteralValueExpr{
 			Val: cty.NumberIntVal(v),
-		}
+		}, nil
 	case float64:
 		return &hclsyntax.LiteralValueExpr{
 			Val: cty.NumberFloatVal(v),
-		}
+		}, nil
 	case bool:
 		return &hclsyntax.LiteralValueExpr{
 			Val: cty.BoolVal(v),
-		}
+		}, nil
 	case yaml.Node:
 		switch v.Kind {
 		case yaml.SequenceNode:
+			var diags hcl.Diagnostics
 			elems := make([]hclsyntax.Expression, len(v.Content))
 			for i, c := range v.Content {
-				elems[i] = yamlToHCL(c)
+				var elemDiags hcl.Diagnostics
+				elems[i], elemDiags = yamlToHCL(c)
+				diags = append(diags, elemDiags...)
 			}
 			return &hclsyntax.TupleConsExpr{
 				Exprs: elems,
-			}
+			}, diags
 		case yaml.MappingNode:
+			var diags hcl.Diagnostics
 			n := len(v.Content) / 2
 			items := make([]hclsyntax.ObjectConsItem, n)
 			for i := 0; i < n; i++ {
+				keyExpr, keyDiags := yamlToHCLKey(v.Content[i*2+0])
+				diags = append(diags, keyDiags...)
+				valueExpr, valueDiags := yamlToHCL(v.Content[i*2+1])
+				diags = append(diags, valueDiags...)
 				items[i] = hclsyntax.ObjectConsItem{
-					KeyExpr:   yamlToHCLKey(v.Content[i*2+0]),
-					ValueExpr: yamlToHCL(v.Content[i*2+1]),
+					KeyExpr:   keyExpr,
+					ValueExpr: valueExpr,
 				}
 			}
 			return &hclsyntax.ObjectConsExpr{
 				Items: items,
-			}
+			}, diags
 
 		case yaml.ScalarNode:
-			if v.Tag != "" && v.Tag != "!!str" {
+			switch v.Tag {
+			case "", "!!str":
+				return yamlToHCL(v.Value)
+			case "!!int", "!!float":
+				return yamlNumberToHCL(v)
+			case "!!bool":
+				var b bool
+				if err := v.Decode(&b); err != nil {
+					panic(err)
+				}
+				return yamlToHCL(b)
+			case "!!null":
+				return hclgen.NewNullLiteral(hcl.Range{}), nil
+			default:
 				panic(fmt.Errorf("unexpected yaml node tag: %q", v.Tag))
 			}
-			return yamlToHCL(v.Value)
 		default:
 			panic(fmt.Errorf("unexpected yaml node kind: %d", v.Kind))
 		}
@@ -373,6 +594,7 @@ func yamlToHCL(v interface{}) hclsyntax.Expression {
 
 			numField := rv.NumField()
 			var items []hclsyntax.ObjectConsItem
+			var diags hcl.Diagnostics
 			for i := 0; i < numField; i++ {
 				fld := typ.Field(i)
 				tag := fld.Tag.Get("yaml")
@@ -395,7 +617,8 @@ func yamlToHCL(v interface{}) hclsyntax.Expression {
 				}
 
 				fldV := rv.Field(i)
-				valueExpr := yamlToHCL(fldV.Interface())
+				valueExpr, valueDiags := yamlToHCL(fldV.Interface())
+				diags = append(diags, valueDiags...)
 				if omitempty && isZeroExpr(valueExpr) {
 					continue
 				}
@@ -408,31 +631,39 @@ func yamlToHCL(v interface{}) hclsyntax.Expression {
 			}
 			return &hclsyntax.ObjectConsExpr{
 				Items: items,
-			}
+			}, diags
 
 		case reflect.Slice:
+			var diags hcl.Diagnostics
 			elems := make([]hclsyntax.Expression, rv.Len())
 			for i := range elems {
-				elems[i] = yamlToHCL(rv.Index(i).Interface())
+				var elemDiags hcl.Diagnostics
+				elems[i], elemDiags = yamlToHCL(rv.Index(i).Interface())
+				diags = append(diags, elemDiags...)
 			}
 			return &hclsyntax.TupleConsExpr{
 				Exprs: elems,
-			}
+			}, diags
 
 		case reflect.Map:
 			// XXX need a stable sort!
+			var diags hcl.Diagnostics
 			items := make([]hclsyntax.ObjectConsItem, 0, rv.Len())
 			iter := rv.MapRange()
 			for iter.Next() {
+				keyExpr, keyDiags := yamlToHCLKey(iter.Key().Interface())
+				diags = append(diags, keyDiags...)
+				valueExpr, valueDiags := yamlToHCL(iter.Value().Interface())
+				diags = append(diags, valueDiags...)
 				item := hclsyntax.ObjectConsItem{
-					KeyExpr:   yamlToHCLKey(iter.Key().Interface()),
-					ValueExpr: yamlToHCL(iter.Value().Interface()),
+					KeyExpr:   keyExpr,
+					ValueExpr: valueExpr,
 				}
 				items = append(items, item)
 			}
 			return &hclsyntax.ObjectConsExpr{
 				Items: items,
-			}
+			}, diags
 
 		default:
 			panic(fmt.Errorf("unexpected yaml type: %T", v))
@@ -440,16 +671,51 @@ func yamlToHCL(v interface{}) hclsyntax.Expression {
 	}
 }
 
-func yamlToHCLKey(v interface{}) hclsyntax.Expression {
-	x := yamlToHCL(v)
+func yamlToHCLKey(v interface{}) (hclsyntax.Expression, hcl.Diagnostics) {
+	x, diags := yamlToHCL(v)
 	if template, isTemplate := x.(*hclsyntax.TemplateExpr); isTemplate && len(template.Parts) == 1 {
 		if lit, isLit := template.Parts[0].(*hclsyntax.LiteralValueExpr); isLit && lit.Val.Type() == cty.String {
-			return hclgen.NewObjStringKey(lit.Val.AsString(), hcl.Range{})
+			return hclgen.NewObjStringKey(lit.Val.AsString(), hcl.Range{}), diags
 		}
 	}
 	return &hclsyntax.ObjectConsKeyExpr{
 		Wrapped: x,
+	}, diags
+}
+
+// yamlNumberToHCL converts a YAML number, in any notation that YAML allows,
+// such as 0x1F or 1e3, to a number literal.
+func yamlNumberToHCL(node yaml.Node) (hclsyntax.Expression, hcl.Diagnostics) {
+	invalid := func(reason string) (hclsyntax.Expression, hcl.Diagnostics) {
+		return hclgen.NewNullLiteral(hcl.Range{}), hcl.Diagnostics{&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("unsupported number: %q", node.Value),
+			Detail:   reason,
+		}}
+	}
+	var decoded interface{}
+	if err := node.Decode(&decoded); err != nil {
+		return invalid(err.Error())
+	}
+	var n cty.Value
+	switch decoded := decoded.(type) {
+	case int:
+		n = cty.NumberIntVal(int64(decoded))
+	case int64:
+		n = cty.NumberIntVal(decoded)
+	case uint64:
+		n = cty.NumberUIntVal(decoded)
+	case float64:
+		if math.IsInf(decoded, 0) || math.IsNaN(decoded) {
+			return invalid("Infinite and NaN numbers cannot be represented in HCL.")
+		}
+		n = cty.NumberFloatVal(decoded)
+	default:
+		return invalid(fmt.Sprintf("Decoded as %T.", decoded))
 	}
+	return &hclsyntax.LiteralValueExpr{
+		Val: n,
+	}, nil
 }
 
 func isZeroExpr(x hcl.Expression) bool {
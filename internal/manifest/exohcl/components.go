@@ -2,22 +2,32 @@ package exohcl
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/deref/exo/internal/deps"
 	"github.com/deref/exo/internal/manifest/exohcl/hclgen"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 )
 
 type ComponentSet struct {
 	m          *Manifest
 	containers hcl.Blocks
 	components []*Component
+	byName     map[string]*Component
+	graph      *deps.Graph
 }
 
-func newComponentSet(m *Manifest, containers hcl.Blocks) *ComponentSet {
+func newComponentSet(m *Manifest, containers hcl.Blocks, modules []*Module) *ComponentSet {
 	cs := &ComponentSet{
 		m:          m,
 		containers: containers,
+		byName:     make(map[string]*Component),
+		graph:      deps.New(),
 	}
 
 	if len(containers) > 1 {
@@ -56,15 +66,117 @@ func newComponentSet(m *Manifest, containers hcl.Blocks) *ComponentSet {
 				Subject:  body.Attributes.Range().Ptr(),
 			})
 		}
-		cs.components = make([]*Component, len(body.Blocks))
-		for i, block := range body.Blocks {
-			cs.components[i] = newComponent(m, block)
+		for _, block := range body.Blocks {
+			cs.components = append(cs.components, newComponent(m, block))
 		}
 	}
+	for _, mod := range modules {
+		cs.components = append(cs.components, mod.importComponents(m)...)
+	}
+
+	cs.resolveDependencies()
+	cs.evaluate()
 
 	return cs
 }
 
+// resolveDependencies builds the dependency graph from both explicit
+// depends_on declarations and implicit references between components.
+func (cs *ComponentSet) resolveDependencies() {
+	m := cs.m
+	for _, c := range cs.components {
+		if c.name == "" {
+			continue
+		}
+		if prev, exists := cs.byName[c.name]; exists {
+			m.appendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate component name",
+				Detail:   fmt.Sprintf("A component named %q was already declared at %s.", c.name, prev.source.DefRange()),
+				Subject:  c.source.DefRange().Ptr(),
+			})
+			continue
+		}
+		cs.byName[c.name] = c
+		cs.graph.AddNode(deps.StringNode(c.name))
+	}
+
+	for _, c := range cs.components {
+		if cs.byName[c.name] != c {
+			continue
+		}
+		c.dependsOn = make([]string, 0, len(c.dependencies))
+		for _, dep := range c.dependencies {
+			if _, declared := cs.byName[dep.name]; !declared {
+				m.appendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Reference to undeclared component",
+					Detail:   fmt.Sprintf("Component %q depends on %q, but no such component is declared.", c.name, dep.name),
+					Subject:  dep.rng.Ptr(),
+				})
+				continue
+			}
+			if err := cs.graph.AddEdge(c.name, dep.name); err != nil {
+				var detail string
+				if cycle, ok := err.(*deps.CycleError); ok {
+					detail = fmt.Sprintf("Component %q would depend on itself: %s.", c.name, strings.Join(cycle.Path, " -> "))
+				} else {
+					detail = err.Error()
+				}
+				m.appendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Dependency cycle",
+					Detail:   detail,
+					Subject:  dep.rng.Ptr(),
+				})
+				continue
+			}
+			c.dependsOn = appendUnique(c.dependsOn, dep.name)
+		}
+	}
+}
+
+// evaluate computes component specs in dependency order, so that each
+// component's spec may refer to the specs of the components it depends on.
+func (cs *ComponentSet) evaluate() {
+	values := make(map[string]cty.Value, len(cs.byName))
+	for name, c := range cs.byName {
+		values[name] = c.value(c.specValue, cty.DynamicVal)
+	}
+	ctx := cs.m.evalCtx
+	ctx.Variables["components"] = cty.ObjectVal(values)
+
+	evaluated := make(map[*Component]bool, len(cs.components))
+	evaluate := func(c *Component) {
+		if evaluated[c] {
+			return
+		}
+		evaluated[c] = true
+		if c.specExpr == nil {
+			return
+		}
+		var diags hcl.Diagnostics
+		c.spec, c.specValue, c.deferred, diags = c.evalSpec(ctx)
+		cs.m.appendDiags(diags...)
+		if cs.byName[c.name] == c {
+			// State is not known until the component has been created. See
+			// EvalSpec for resolving references to state.
+			values[c.name] = c.value(c.specValue, cty.DynamicVal)
+			ctx.Variables["components"] = cty.ObjectVal(values)
+		}
+	}
+
+	for _, layer := range cs.graph.TopoSortedLayers() {
+		for _, node := range layer {
+			evaluate(cs.byName[node.ID()])
+		}
+	}
+	// Evaluate any remaining invalid components to surface their diagnostics.
+	for _, c := range cs.components {
+		evaluate(c)
+	}
+}
+
 func (cs *ComponentSet) Len() int {
 	return len(cs.components)
 }
@@ -79,20 +191,41 @@ type Component struct {
 	expansion *hclsyntax.Block
 	typ       string
 	name      string
+	// Nil if the spec was provided as a literal block.
+	specExpr  hcl.Expression
 	spec      string
-	dependsOn []string
+	specValue cty.Value
+	deferred  bool
+	// Both explicitly declared and implied by references in the spec.
+	dependencies []componentReference
+	dependsOn    []string
+	profiles     []string
+	// Non-nil if the component was included from a module.
+	imported *importedComponent
+}
+
+type componentReference struct {
+	name string
+	rng  hcl.Range
 }
 
 func newComponent(m *Manifest, block *hclsyntax.Block) *Component {
 	c := &Component{
-		m:      m,
-		source: block,
+		m:         m,
+		source:    block,
+		specValue: cty.DynamicVal,
 	}
 
 	if block.Type != "component" {
-		var diags hcl.Diagnostics
-		block, diags = expandComponent(block)
+		expansion, diags := expandComponent(block)
 		m.appendDiags(diags...)
+		if expansion == nil {
+			if len(block.Labels) > 0 {
+				c.name = block.Labels[0]
+			}
+			return c
+		}
+		block = expansion
 	}
 	c.expansion = block
 
@@ -101,6 +234,7 @@ func newComponent(m *Manifest, block *hclsyntax.Block) *Component {
 			{Name: "type", Required: true},
 			{Name: "spec"},
 			{Name: "depends_on"},
+			{Name: "profiles"},
 		},
 		Blocks: []hcl.BlockHeaderSchema{
 			{Type: "spec"},
@@ -136,6 +270,43 @@ func newComponent(m *Manifest, block *hclsyntax.Block) *Component {
 		}
 	}
 
+	depsAttr := content.Attributes["depends_on"]
+	if depsAttr != nil {
+		depsExpr := depsAttr.Expr
+		tup, ok := depsExpr.(*hclsyntax.TupleConsExpr)
+		if !ok {
+			m.appendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Expected array of component names",
+				Detail:   fmt.Sprintf("Expected literal array of strings or component references, got %T", depsExpr),
+				Subject:  depsExpr.Range().Ptr(),
+			})
+		} else {
+			for _, elem := range tup.Exprs {
+				if ref, ok := parseComponentReference(elem); ok {
+					c.dependencies = append(c.dependencies, ref)
+					continue
+				}
+				dep, diag := parseLiteralString(elem)
+				if diag != nil {
+					m.appendDiags(diag)
+					continue
+				}
+				c.dependencies = append(c.dependencies, componentReference{
+					name: dep,
+					rng:  elem.Range(),
+				})
+			}
+		}
+	}
+
+	if attr := content.Attributes["profiles"]; attr != nil {
+		var profiles []string
+		if m.evalAs(attr.Expr, cty.List(cty.String), "list of strings", &profiles) {
+			c.profiles = profiles
+		}
+	}
+
 	specAttr := content.Attributes["spec"]
 	if specAttr == nil {
 		specBlocks := content.Blocks.OfType("spec")
@@ -158,33 +329,172 @@ func newComponent(m *Manifest, block *hclsyntax.Block) *Component {
 			})
 		}
 	} else {
-		c.spec = m.evalString(specAttr.Expr)
+		c.specExpr = specAttr.Expr
+		for _, traversal := range specAttr.Expr.Variables() {
+			if traversal.RootName() != "components" {
+				continue
+			}
+			ref, ok := traversalComponentReference(traversal)
+			if !ok {
+				m.appendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Invalid component reference",
+					Detail:   `A reference to a component must be of the form components.NAME or components["NAME"].`,
+					Subject:  traversal.SourceRange().Ptr(),
+				})
+				continue
+			}
+			c.dependencies = append(c.dependencies, ref)
+		}
 	}
 
-	depsAttr := content.Attributes["depends_on"]
-	if depsAttr != nil {
-		depsExpr := depsAttr.Expr
-		tup, ok := depsExpr.(*hclsyntax.TupleConsExpr)
-		if !ok {
-			m.appendDiags(&hcl.Diagnostic{
+	return c
+}
+
+func parseComponentReference(x hcl.Expression) (componentReference, bool) {
+	scope, ok := x.(*hclsyntax.ScopeTraversalExpr)
+	if !ok || scope.Traversal.RootName() != "components" || len(scope.Traversal) != 2 {
+		return componentReference{}, false
+	}
+	return traversalComponentReference(scope.Traversal)
+}
+
+func traversalComponentReference(traversal hcl.Traversal) (componentReference, bool) {
+	if len(traversal) < 2 {
+		return componentReference{}, false
+	}
+	var name string
+	switch step := traversal[1].(type) {
+	case hcl.TraverseAttr:
+		name = step.Name
+	case hcl.TraverseIndex:
+		// Allow components["NAME"] as an alternative to components.NAME.
+		if step.Key.Type() != cty.String || !step.Key.IsKnown() || step.Key.IsNull() {
+			return componentReference{}, false
+		}
+		name = step.Key.AsString()
+	default:
+		return componentReference{}, false
+	}
+	return componentReference{
+		name: name,
+		rng:  hcl.RangeBetween(traversal[0].SourceRange(), traversal[1].SourceRange()),
+	}, true
+}
+
+func appendUnique(names []string, name string) []string {
+	for _, existing := range names {
+		if existing == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
+
+// Returns the value of the component as seen by references from other
+// components via `components.NAME`.
+func (c *Component) value(spec cty.Value, state cty.Value) cty.Value {
+	return cty.ObjectVal(map[string]cty.Value{
+		"name":  cty.StringVal(c.name),
+		"type":  cty.StringVal(c.typ),
+		"spec":  spec,
+		"state": state,
+	})
+}
+
+// evalSpec evaluates the spec expression, returning both the encoded spec
+// string and its structured value. If the spec depends on values that are not
+// yet known, it is deferred and the returned spec string is empty.
+func (c *Component) evalSpec(ctx *hcl.EvalContext) (spec string, value cty.Value, deferred bool, diags hcl.Diagnostics) {
+	var v cty.Value
+	call, isCall := c.specExpr.(*hclsyntax.FunctionCallExpr)
+	structured := isCall && len(call.Args) == 1 && (call.Name == "jsonencode" || call.Name == "yamlencode")
+	if structured {
+		// Preserve the structure of the encoded object, so that it may be
+		// referenced by other components without having to decode it.
+		value, diags = call.Args[0].Value(ctx)
+		if diags.HasErrors() {
+			return "", cty.DynamicVal, false, diags
+		}
+		if !value.IsWhollyKnown() {
+			return "", value, true, diags
+		}
+		var err error
+		v, err = lookupFunction(ctx, call.Name).Call([]cty.Value{value})
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
-				Summary:  "Expected array of strings",
-				Detail:   fmt.Sprintf("Expected literal array of strings, got %T", depsExpr),
-				Subject:  depsExpr.Range().Ptr(),
+				Summary:  "Error in function call",
+				Detail:   fmt.Sprintf("Call to function %q failed: %v.", call.Name, err),
+				Subject:  c.specExpr.Range().Ptr(),
 			})
+			return "", cty.DynamicVal, false, diags
 		}
-		c.dependsOn = make([]string, 0, len(tup.Exprs))
-		for _, elem := range tup.Exprs {
-			dep, diag := parseLiteralString(elem)
-			if diag != nil {
-				m.appendDiags(diag)
-				continue
-			}
-			c.dependsOn = append(c.dependsOn, dep)
+	} else {
+		var moreDiags hcl.Diagnostics
+		v, moreDiags = c.specExpr.Value(ctx)
+		diags = append(diags, moreDiags...)
+		if diags.HasErrors() {
+			return "", cty.DynamicVal, false, diags
+		}
+		if !v.IsWhollyKnown() {
+			return "", cty.DynamicVal, true, diags
 		}
 	}
+	if v.IsNull() || v.Type() != cty.String {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Expected string",
+			Detail:   fmt.Sprintf("Expected spec to be a string, but got %s", v.Type().FriendlyName()),
+			Subject:  c.specExpr.Range().Ptr(),
+		})
+		return "", cty.DynamicVal, false, diags
+	}
+	spec = v.AsString()
+	if !structured {
+		value = decodeSpec(spec)
+	}
+	return spec, value, false, diags
+}
 
-	return c
+func lookupFunction(ctx *hcl.EvalContext, name string) function.Function {
+	for ; ctx != nil; ctx = ctx.Parent() {
+		if f, ok := ctx.Functions[name]; ok {
+			return f
+		}
+	}
+	panic(fmt.Errorf("undefined function: %q", name))
+}
+
+// decodeSpec parses a spec string for use in references. Since JSON is a
+// subset of YAML, this handles the encodings of all component types.
+func decodeSpec(spec string) cty.Value {
+	bs := []byte(spec)
+	typ, err := ctyyaml.ImpliedType(bs)
+	if err != nil {
+		return cty.DynamicVal
+	}
+	v, err := ctyyaml.Unmarshal(bs, typ)
+	if err != nil {
+		return cty.DynamicVal
+	}
+	return v
+}
+
+func decodeState(state string) cty.Value {
+	if state == "" {
+		return cty.EmptyObjectVal
+	}
+	bs := []byte(state)
+	typ, err := ctyjson.ImpliedType(bs)
+	if err != nil {
+		return cty.DynamicVal
+	}
+	v, err := ctyjson.Unmarshal(bs, typ)
+	if err != nil {
+		return cty.DynamicVal
+	}
+	return v
 }
 
 func (c *Component) Name() string {
@@ -195,24 +505,108 @@ func (c *Component) Type() string {
 	return c.typ
 }
 
+// Spec returns the evaluated spec. If the component is deferred, the spec is
+// empty and must be computed with EvalSpec.
 func (c *Component) Spec() string {
 	return c.spec
 }
 
+// Deferred is true if the spec refers to values that are not known until the
+// components it depends on have been created, such as their state.
+func (c *Component) Deferred() bool {
+	return c.deferred
+}
+
+// Returns the names of the components this component depends on, including
+// both those declared with depends_on and those implied by references.
 func (c *Component) DependsOn() []string {
 	return c.dependsOn
 }
 
+// Profiles returns the names of the profiles this component belongs to.
+func (c *Component) Profiles() []string {
+	return c.profiles
+}
+
+// InProfiles reports whether the component belongs to any of the given
+// profiles. Components that do not declare any profiles are in all of them.
+func (c *Component) InProfiles(profiles []string) bool {
+	if len(c.profiles) == 0 {
+		return true
+	}
+	for _, want := range profiles {
+		for _, have := range c.profiles {
+			if want == have {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ComponentInstance describes the existing counterpart of a manifest component.
+type ComponentInstance struct {
+	Spec  string
+	State string
+}
+
+// EvalSpec evaluates the spec with references to other components resolved
+// against the given instances, keyed by component name. This is used to
+// compute deferred specs once their dependencies have been created.
+func (c *Component) EvalSpec(instances map[string]ComponentInstance) (string, hcl.Diagnostics) {
+	if c.imported != nil {
+		return c.imported.evalSpec(instances)
+	}
+	if c.specExpr == nil {
+		return c.spec, nil
+	}
+	cs := c.m.components
+	values := make(map[string]cty.Value, len(cs.byName))
+	for name, other := range cs.byName {
+		if instance, ok := instances[name]; ok {
+			values[name] = other.value(decodeSpec(instance.Spec), decodeState(instance.State))
+		} else {
+			values[name] = other.value(other.specValue, cty.DynamicVal)
+		}
+	}
+	ctx := c.m.evalCtx.NewChild()
+	ctx.Variables = map[string]cty.Value{
+		"components": cty.ObjectVal(values),
+	}
+	spec, _, deferred, diags := c.evalSpec(ctx)
+	if deferred {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unresolved component reference",
+			Detail:   fmt.Sprintf("The spec of component %q refers to values of other components that are not known.", c.name),
+			Subject:  c.specExpr.Range().Ptr(),
+		})
+	}
+	return spec, diags
+}
+
+// shorthandEncodeFuncs maps the component types that may be declared with
+// shorthand blocks to the function used to encode their specs.
+var shorthandEncodeFuncs = map[string]string{
+	"process":   "jsonencode",
+	"container": "yamlencode",
+	"volume":    "yamlencode",
+	"network":   "yamlencode",
+}
+
+// dockerLabelTypes are the shorthand component types whose resources may be
+// labeled with labels declared in their "_" block.
+var dockerLabelTypes = map[string]bool{
+	"container": true,
+	"volume":    true,
+	"network":   true,
+}
+
 func expandComponent(block *hclsyntax.Block) (*hclsyntax.Block, hcl.Diagnostics) {
 	var diags hcl.Diagnostics
 	body := block.Body
-	var encodefunc string
-	switch block.Type {
-	case "process":
-		encodefunc = "jsonencode"
-	case "container", "volume", "network":
-		encodefunc = "yamlencode"
-	default:
+	encodefunc, ok := shorthandEncodeFuncs[block.Type]
+	if !ok {
 		diags = append(diags, &hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Unsupported component type",
@@ -221,16 +615,80 @@ func expandComponent(block *hclsyntax.Block) (*hclsyntax.Block, hcl.Diagnostics)
 		})
 		return nil, diags
 	}
+	// Attributes of "meta" blocks are copied in to the expanded component.
+	var metaBlock *hclsyntax.Block
+	metaAttrs := make(hclsyntax.Attributes)
+	var labelsAttr *hclsyntax.Attribute
 	for _, subblock := range body.Blocks {
 		switch subblock.Type {
 		case "_":
-			// TODO: copy content of "meta" blocks in to the expanded output.
+			if metaBlock != nil {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Expected at most one meta block",
+					Detail:   fmt.Sprintf(`Only one "_" block may appear in a %q component.`, block.Type),
+					Subject:  subblock.DefRange().Ptr(),
+				})
+				continue
+			}
+			metaBlock = subblock
+			if len(subblock.Labels) > 0 {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unexpected label on meta block",
+					Detail:   fmt.Sprintf(`A "_" block expects no labels, but has %d`, len(subblock.Labels)),
+					Subject:  &subblock.LabelRanges[0],
+				})
+			}
+			for _, nested := range subblock.Body.Blocks {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Unexpected block",
+					Detail:   fmt.Sprintf(`Unexpected %q block in "_" block.`, nested.Type),
+					Subject:  nested.DefRange().Ptr(),
+				})
+			}
+			for name, attr := range subblock.Body.Attributes {
+				switch name {
+				case "type", "spec":
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "Unsupported meta attribute",
+						Detail:   fmt.Sprintf(`The %q attribute is not allowed in a "_" block.`, name),
+						Subject:  attr.NameRange.Ptr(),
+					})
+				case "labels":
+					// Labels are applied to Docker resources, so become part of the spec.
+					if !dockerLabelTypes[block.Type] {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Unsupported meta attribute",
+							Detail:   fmt.Sprintf(`Labels are applied as Docker labels, so are not supported by %q components.`, block.Type),
+							Subject:  attr.NameRange.Ptr(),
+						})
+						continue
+					}
+					if specLabels, ok := body.Attributes["labels"]; ok {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "Duplicate labels",
+							Detail:   fmt.Sprintf(`Labels of %q are given both in its spec and in its "_" block.`, block.Labels[0]),
+							Subject:  attr.NameRange.Ptr(),
+							Context:  specLabels.SrcRange.Ptr(),
+						})
+						continue
+					}
+					labelsAttr = attr
+				default:
+					metaAttrs[name] = attr
+				}
+			}
 		default:
 			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Unexpected block",
 				Detail:   fmt.Sprintf(`Unexpected %q block in %q component.`, subblock.Type, block.Type),
-				Subject:  body.Blocks[0].DefRange().Ptr(),
+				Subject:  subblock.DefRange().Ptr(),
 			})
 		}
 	}
@@ -242,37 +700,43 @@ func expandComponent(block *hclsyntax.Block) (*hclsyntax.Block, hcl.Diagnostics)
 			ValueExpr: attr.Expr,
 		})
 	}
+	if labelsAttr != nil {
+		specItems = append(specItems, hclsyntax.ObjectConsItem{
+			KeyExpr:   hclgen.NewObjStringKey("labels", labelsAttr.Range()),
+			ValueExpr: labelsAttr.Expr,
+		})
+	}
 	// sort.Sort(specItemsSorter{specItems}) // XXX sort specItems by attr range?
-	// XXX search for "_" blocks with depends_on, etc. and other meta properties.
+	metaAttrs["type"] = &hclsyntax.Attribute{
+		Name:        "type",
+		Expr:        hclgen.NewStringLiteral(block.Type, block.TypeRange),
+		SrcRange:    block.TypeRange,
+		NameRange:   block.TypeRange,
+		EqualsRange: block.TypeRange,
+	}
+	metaAttrs["spec"] = &hclsyntax.Attribute{
+		Name: "spec",
+		Expr: &hclsyntax.FunctionCallExpr{
+			Name: encodefunc,
+			Args: []hclsyntax.Expression{
+				&hclsyntax.ObjectConsExpr{
+					Items:     specItems,
+					SrcRange:  body.SrcRange,
+					OpenRange: block.OpenBraceRange,
+				},
+			},
+		},
+		SrcRange:    body.SrcRange,
+		NameRange:   block.TypeRange,
+		EqualsRange: block.TypeRange,
+	}
 	return &hclsyntax.Block{
 		Type:   "component",
 		Labels: block.Labels,
 		Body: &hclsyntax.Body{
-			Attributes: hclsyntax.Attributes{
-				"type": &hclsyntax.Attribute{
-					Name:        "type",
-					Expr:        hclgen.NewStringLiteral(block.Type, block.TypeRange),
-					SrcRange:    block.TypeRange,
-					NameRange:   block.TypeRange,
-					EqualsRange: block.TypeRange,
-				},
-				"spec": &hclsyntax.Attribute{
-					Name: "spec",
-					Expr: &hclsyntax.FunctionCallExpr{
-						Name: encodefunc,
-						Args: []hclsyntax.Expression{
-							&hclsyntax.ObjectConsExpr{
-								Items:     specItems,
-								SrcRange:  body.SrcRange,
-								OpenRange: block.OpenBraceRange,
-							},
-						},
-					},
-					SrcRange:    body.SrcRange,
-					NameRange:   block.TypeRange,
-					EqualsRange: block.TypeRange,
-				},
-			},
+			Attributes: metaAttrs,
+			SrcRange:   body.SrcRange,
+			EndRange:   body.EndRange,
 		},
 		TypeRange:       block.TypeRange,
 		LabelRanges:     block.LabelRanges,
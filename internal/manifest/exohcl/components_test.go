@@ -0,0 +1,158 @@
+package exohcl_test
+
+import (
+	"testing"
+
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentReferences(t *testing.T) {
+	src := `
+exo = "0.1"
+
+components {
+  process "web" {
+    program = "server"
+    environment = {
+      DATABASE_URL = "postgres://${components.db.name}:${components.db.spec.environment.PORT}"
+    }
+  }
+
+  process "db" {
+    program = "postgres"
+    environment = {
+      PORT = "5432"
+    }
+  }
+
+  component "worker" {
+    type = "process"
+    spec = jsonencode({
+      program = "worker"
+      environment = {
+        DB_PID = tostring(components.db.state.pid)
+      }
+    })
+    depends_on = [components.web, "web"]
+  }
+}
+`
+	m := exohcl.Parse("exo.hcl", []byte(src), nil)
+	require.Empty(t, m.Diagnostics())
+
+	components := m.Components()
+	require.Equal(t, 3, components.Len())
+
+	web := components.Index(0)
+	assert.Equal(t, []string{"db"}, web.DependsOn())
+	assert.False(t, web.Deferred())
+	assert.Equal(t, `{"environment":{"DATABASE_URL":"postgres://db:5432"},"program":"server"}`, web.Spec())
+
+	worker := components.Index(2)
+	assert.ElementsMatch(t, []string{"web", "db"}, worker.DependsOn())
+	assert.True(t, worker.Deferred())
+	assert.Equal(t, "", worker.Spec())
+
+	spec, diags := worker.EvalSpec(map[string]exohcl.ComponentInstance{
+		"db": {
+			State: `{"pid":123}`,
+		},
+	})
+	require.Empty(t, diags)
+	assert.Equal(t, `{"environment":{"DB_PID":"123"},"program":"worker"}`, spec)
+}
+
+func TestComponentReferenceCycle(t *testing.T) {
+	src := `
+exo = "0.1"
+
+components {
+  process "a" {
+    program = components.c.name
+  }
+  process "b" {
+    program = components.a.name
+  }
+  process "c" {
+    program = components.b.name
+  }
+}
+`
+	m := exohcl.Parse("exo.hcl", []byte(src), nil)
+	diags := m.Diagnostics()
+	require.Len(t, diags, 1)
+	assert.Equal(t, "Dependency cycle", diags[0].Summary)
+	assert.Equal(t, `Component "c" would depend on itself: c -> b -> a -> c.`, diags[0].Detail)
+}
+
+func TestMetaBlock(t *testing.T) {
+	src := `
+exo = "0.1"
+
+components {
+  volume "data" {}
+
+  container "db" {
+    image = "postgres"
+    _ {
+      depends_on = ["data"]
+      profiles   = ["backend", "full"]
+      labels     = { tier = "storage" }
+    }
+  }
+}
+`
+	m := exohcl.Parse("exo.hcl", []byte(src), nil)
+	require.Empty(t, m.Diagnostics())
+
+	db := m.Components().Index(1)
+	assert.Equal(t, "\"image\": \"postgres\"\n\"labels\":\n  \"tier\": \"storage\"\n", db.Spec())
+	assert.Equal(t, []string{"data"}, db.DependsOn())
+	assert.Equal(t, []string{"backend", "full"}, db.Profiles())
+	assert.True(t, db.InProfiles([]string{"full"}))
+	assert.False(t, db.InProfiles(nil))
+	assert.True(t, m.Components().Index(0).InProfiles(nil))
+
+	invalid := exohcl.Parse("exo.hcl", []byte(`
+exo = "0.1"
+
+components {
+  process "web" {
+    _ {
+      spec = "{}"
+      restart = "always"
+    }
+  }
+}
+`), nil)
+	summaries := []string{}
+	for _, diag := range invalid.Diagnostics() {
+		summaries = append(summaries, diag.Summary)
+	}
+	assert.ElementsMatch(t, []string{"Unsupported meta attribute", "Unsupported argument"}, summaries)
+
+	invalid = exohcl.Parse("exo.hcl", []byte(`
+exo = "0.1"
+
+components {
+  process "web" {
+    _ {
+      labels = { tier = "web" }
+    }
+  }
+  container "db" {
+    labels = { tier = "storage" }
+    _ {
+      labels = { tier = "storage" }
+    }
+  }
+}
+`), nil)
+	summaries = []string{}
+	for _, diag := range invalid.Diagnostics() {
+		summaries = append(summaries, diag.Summary)
+	}
+	assert.ElementsMatch(t, []string{"Unsupported meta attribute", "Duplicate labels"}, summaries)
+}
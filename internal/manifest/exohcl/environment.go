@@ -59,6 +59,7 @@ func newEnvironment(m *Manifest, blocks hcl.Blocks) *Environment {
 						Subject:  attr.Expr.Range().Ptr(),
 						Context:  &attr.Range,
 					})
+					continue
 				}
 				env.vars[attr.Name] = v.AsString()
 			}
@@ -0,0 +1,324 @@
+package exohcl
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/deref/exo/internal/util/pathutil"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// makeFunctions returns the functions available to manifest expressions.
+// File functions read with files, and the env function reads from environ.
+func makeFunctions(files *fileReader, environ map[string]string) map[string]function.Function {
+	funcs := map[string]function.Function{
+		// Numeric.
+		"abs":      stdlib.AbsoluteFunc,
+		"ceil":     stdlib.CeilFunc,
+		"floor":    stdlib.FloorFunc,
+		"log":      stdlib.LogFunc,
+		"max":      stdlib.MaxFunc,
+		"min":      stdlib.MinFunc,
+		"parseint": stdlib.ParseIntFunc,
+		"pow":      stdlib.PowFunc,
+		"signum":   stdlib.SignumFunc,
+
+		// String.
+		"chomp":      stdlib.ChompFunc,
+		"format":     stdlib.FormatFunc,
+		"formatlist": stdlib.FormatListFunc,
+		"indent":     stdlib.IndentFunc,
+		"join":       stdlib.JoinFunc,
+		"lower":      stdlib.LowerFunc,
+		"regex":      stdlib.RegexFunc,
+		"regexall":   stdlib.RegexAllFunc,
+		"replace":    stdlib.ReplaceFunc,
+		"split":      stdlib.SplitFunc,
+		"strlen":     stdlib.StrlenFunc,
+		"strrev":     stdlib.ReverseFunc,
+		"substr":     stdlib.SubstrFunc,
+		"title":      stdlib.TitleFunc,
+		"trim":       stdlib.TrimFunc,
+		"trimprefix": stdlib.TrimPrefixFunc,
+		"trimspace":  stdlib.TrimSpaceFunc,
+		"trimsuffix": stdlib.TrimSuffixFunc,
+		"upper":      stdlib.UpperFunc,
+
+		// Collection.
+		"chunklist":       stdlib.ChunklistFunc,
+		"coalesce":        stdlib.CoalesceFunc,
+		"coalescelist":    stdlib.CoalesceListFunc,
+		"compact":         stdlib.CompactFunc,
+		"concat":          stdlib.ConcatFunc,
+		"contains":        stdlib.ContainsFunc,
+		"distinct":        stdlib.DistinctFunc,
+		"element":         stdlib.ElementFunc,
+		"flatten":         stdlib.FlattenFunc,
+		"keys":            stdlib.KeysFunc,
+		"length":          stdlib.LengthFunc,
+		"lookup":          stdlib.LookupFunc,
+		"merge":           stdlib.MergeFunc,
+		"range":           stdlib.RangeFunc,
+		"reverse":         stdlib.ReverseListFunc,
+		"setintersection": stdlib.SetIntersectionFunc,
+		"setproduct":      stdlib.SetProductFunc,
+		"setsubtract":     stdlib.SetSubtractFunc,
+		"setunion":        stdlib.SetUnionFunc,
+		"slice":           stdlib.SliceFunc,
+		"sort":            stdlib.SortFunc,
+		"values":          stdlib.ValuesFunc,
+		"zipmap":          stdlib.ZipmapFunc,
+
+		// Encoding.
+		"base64decode": base64DecodeFunc,
+		"base64encode": base64EncodeFunc,
+		"csvdecode":    stdlib.CSVDecodeFunc,
+		"jsondecode":   stdlib.JSONDecodeFunc,
+		"jsonencode":   stdlib.JSONEncodeFunc,
+		"urlencode":    urlEncodeFunc,
+		"yamldecode":   ctyyaml.YAMLDecodeFunc,
+		"yamlencode":   ctyyaml.YAMLEncodeFunc,
+
+		// Date and time.
+		"formatdate": stdlib.FormatDateFunc,
+		"timeadd":    stdlib.TimeAddFunc,
+
+		// Type conversion.
+		"can":      tryfunc.CanFunc,
+		"try":      tryfunc.TryFunc,
+		"tobool":   stdlib.MakeToFunc(cty.Bool),
+		"tolist":   stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+		"tomap":    stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+		"tonumber": stdlib.MakeToFunc(cty.Number),
+		"toset":    stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+		"tostring": stdlib.MakeToFunc(cty.String),
+
+		// Environment and filesystem.
+		"env":        makeEnvFunc(environ),
+		"file":       makeFileFunc(files),
+		"fileexists": makeFileExistsFunc(files),
+	}
+
+	// Templates may call any function except templatefile itself, which
+	// prevents unbounded recursion.
+	templateFuncs := make(map[string]function.Function, len(funcs))
+	for name, f := range funcs {
+		templateFuncs[name] = f
+	}
+	funcs["templatefile"] = makeTemplateFileFunc(files, templateFuncs)
+
+	return funcs
+}
+
+// fileReader reads the files of file functions. Relative paths are resolved
+// against baseDir, and paths may not escape rootDir.
+type fileReader struct {
+	baseDir  string
+	rootDir  string
+	readFile func(path string) ([]byte, error)
+}
+
+func (r *fileReader) resolvePath(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.baseDir, path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	rootDir, err := filepath.Abs(r.rootDir)
+	if err != nil {
+		return "", err
+	}
+	if !pathutil.HasFilePathPrefix(path, rootDir) {
+		return "", errors.New("cannot read file outside of workspace root")
+	}
+	return path, nil
+}
+
+func (r *fileReader) read(path string) ([]byte, error) {
+	resolved, err := r.resolvePath(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q: %w", path, err)
+	}
+	bs, err := r.readFile(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q: %w", path, err)
+	}
+	if !utf8.Valid(bs) {
+		return nil, fmt.Errorf("contents of %q are not valid UTF-8", path)
+	}
+	return bs, nil
+}
+
+func makeEnvFunc(environ map[string]string) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "name",
+				Type: cty.String,
+			},
+		},
+		VarParam: &function.Parameter{
+			Name: "default",
+			Type: cty.String,
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name := args[0].AsString()
+			if value, ok := environ[name]; ok {
+				return cty.StringVal(value), nil
+			}
+			switch len(args) {
+			case 1:
+				return cty.NilVal, function.NewArgErrorf(0, "environment variable %q is not set", name)
+			case 2:
+				return args[1], nil
+			default:
+				return cty.NilVal, function.NewArgErrorf(2, "expected at most one default value")
+			}
+		},
+	})
+}
+
+func makeFileFunc(files *fileReader) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "path",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			bs, err := files.read(args[0].AsString())
+			if err != nil {
+				return cty.NilVal, function.NewArgError(0, err)
+			}
+			return cty.StringVal(string(bs)), nil
+		},
+	})
+}
+
+func makeFileExistsFunc(files *fileReader) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "path",
+				Type: cty.String,
+			},
+		},
+		Type: function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			path := args[0].AsString()
+			resolved, err := files.resolvePath(path)
+			if err != nil {
+				return cty.NilVal, function.NewArgError(0, fmt.Errorf("checking %q: %w", path, err))
+			}
+			_, err = files.readFile(resolved)
+			return cty.BoolVal(err == nil), nil
+		},
+	})
+}
+
+func makeTemplateFileFunc(files *fileReader, funcs map[string]function.Function) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "path",
+				Type: cty.String,
+			},
+			{
+				Name: "vars",
+				Type: cty.DynamicPseudoType,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			path := args[0].AsString()
+			bs, err := files.read(path)
+			if err != nil {
+				return cty.NilVal, function.NewArgError(0, err)
+			}
+			filename, _ := files.resolvePath(path)
+			vars := args[1]
+			if !(vars.Type().IsObjectType() || vars.Type().IsMapType()) {
+				return cty.NilVal, function.NewArgErrorf(1, "template variables must be an object or map")
+			}
+			expr, diags := hclsyntax.ParseTemplate(bs, filename, hcl.InitialPos)
+			if diags.HasErrors() {
+				return cty.NilVal, function.NewArgError(0, diags)
+			}
+			ctx := &hcl.EvalContext{
+				Variables: vars.AsValueMap(),
+				Functions: funcs,
+			}
+			v, diags := expr.Value(ctx)
+			if diags.HasErrors() {
+				return cty.NilVal, diags
+			}
+			v, err = convert.Convert(v, cty.String)
+			if err != nil {
+				return cty.NilVal, fmt.Errorf("template result: %w", err)
+			}
+			return v, nil
+		},
+	})
+}
+
+var base64EncodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "str",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.StringVal(base64.StdEncoding.EncodeToString([]byte(args[0].AsString()))), nil
+	},
+})
+
+var base64DecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "str",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		bs, err := base64.StdEncoding.DecodeString(args[0].AsString())
+		if err != nil {
+			return cty.NilVal, function.NewArgErrorf(0, "invalid base64: %v", err)
+		}
+		if !utf8.Valid(bs) {
+			return cty.NilVal, function.NewArgErrorf(0, "decoded bytes are not valid UTF-8")
+		}
+		return cty.StringVal(string(bs)), nil
+	},
+})
+
+var urlEncodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{
+			Name: "str",
+			Type: cty.String,
+		},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.StringVal(url.QueryEscape(args[0].AsString())), nil
+	},
+})
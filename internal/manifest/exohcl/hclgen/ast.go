@@ -145,8 +145,10 @@ func (a Attributes) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a Attributes) Less(i, j int) bool {
 	lhs := a[i]
 	rhs := a[j]
-	if lhs.Range().Start.Byte < rhs.Range().Start.Byte {
-		return true
+	lhsStart := lhs.Range().Start.Byte
+	rhsStart := rhs.Range().Start.Byte
+	if lhsStart != rhsStart {
+		return lhsStart < rhsStart
 	}
 	return lhs.Name < rhs.Name
 }
@@ -28,6 +28,14 @@ func TestGenerate(t *testing.T) {
 				obj = { bare = "BARE", "quoted" = "QUOTED" }
 			}
 		}`,
+		`process "web" {
+			program = "server"
+			_ {
+				depends_on = ["db", components.cache]
+				profiles = ["dev"]
+				labels = { team = "web" }
+			}
+		}`,
 	}
 	for _, src := range tests {
 		f, diags := hclsyntax.ParseConfig([]byte(src), "", hcl.InitialPos)
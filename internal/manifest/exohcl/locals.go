@@ -0,0 +1,108 @@
+package exohcl
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// newLocals evaluates the attributes of all `locals` blocks. Locals may
+// refer to variables and to each other, so they are evaluated in dependency
+// order. Each evaluated local is immediately made available to subsequent
+// evaluations as `local.<name>`.
+func newLocals(m *Manifest, blocks hcl.Blocks) map[string]cty.Value {
+	pending := make(map[string]*hcl.Attribute)
+	for _, block := range blocks {
+		if len(block.Labels) > 0 {
+			m.appendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Unexpected label on locals block",
+				Detail:   fmt.Sprintf("A locals block expects no labels, but has %d", len(block.Labels)),
+				Subject:  &block.LabelRanges[0],
+			})
+		}
+		attrs, diags := block.Body.JustAttributes()
+		m.appendDiags(diags...)
+		for name, attr := range attrs {
+			if prev, ok := pending[name]; ok {
+				m.appendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Duplicate local value",
+					Detail:   fmt.Sprintf("A local value named %q was already defined at %s.", name, prev.Range),
+					Subject:  attr.NameRange.Ptr(),
+				})
+				continue
+			}
+			pending[name] = attr
+		}
+	}
+
+	locals := make(map[string]cty.Value, len(pending))
+	setLocals := func() {
+		m.evalCtx.Variables["local"] = cty.ObjectVal(locals)
+	}
+	setLocals()
+
+	for len(pending) > 0 {
+		progress := false
+		for _, name := range sortedAttributeNames(pending) {
+			attr := pending[name]
+			if dependsOnPendingLocal(attr.Expr, pending) {
+				continue
+			}
+			v, diags := attr.Expr.Value(m.evalCtx)
+			m.appendDiags(diags...)
+			if diags.HasErrors() {
+				v = cty.DynamicVal
+			}
+			locals[name] = v
+			delete(pending, name)
+			setLocals()
+			progress = true
+		}
+		if !progress {
+			for _, name := range sortedAttributeNames(pending) {
+				attr := pending[name]
+				m.appendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Cycle in local values",
+					Detail:   fmt.Sprintf("The local value %q depends on itself, directly or indirectly.", name),
+					Subject:  attr.Expr.Range().Ptr(),
+					Context:  &attr.Range,
+				})
+				locals[name] = cty.DynamicVal
+			}
+			setLocals()
+			break
+		}
+	}
+
+	return locals
+}
+
+func dependsOnPendingLocal(x hcl.Expression, pending map[string]*hcl.Attribute) bool {
+	for _, traversal := range x.Variables() {
+		if traversal.RootName() != "local" || len(traversal) < 2 {
+			continue
+		}
+		attr, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		if _, isPending := pending[attr.Name]; isPending {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedAttributeNames(attrs map[string]*hcl.Attribute) []string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -2,63 +2,125 @@ package exohcl
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
-	ctyyaml "github.com/zclconf/go-cty-yaml"
 	"github.com/zclconf/go-cty/cty"
-	"github.com/zclconf/go-cty/cty/function"
-	"github.com/zclconf/go-cty/cty/function/stdlib"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/gocty"
 )
 
 type Manifest struct {
 	filename    string
+	baseDir     string
 	f           *hcl.File
 	evalCtx     *hcl.EvalContext
 	diags       hcl.Diagnostics
 	content     *hcl.BodyContent
+	variables   []*Variable
+	locals      map[string]cty.Value
+	modules     []*Module
 	environment *Environment
 	components  *ComponentSet
 }
 
-func Parse(filename string, bs []byte) *Manifest {
+// Options are external inputs to the evaluation of a manifest.
+type Options struct {
+	// Relative paths given to functions such as file() are resolved against
+	// BaseDir. Defaults to the directory containing the manifest file.
+	BaseDir string
+	// RootDir bounds the files that functions such as file() may read.
+	// Defaults to BaseDir.
+	RootDir string
+	// Variables explicitly assigns values to declared variables, such as
+	// from the command line.
+	Variables map[string]string
+	// Dotenv contains the contents of a .env file. Entries with the same name
+	// as a declared variable override that variable's default. Other entries
+	// are ignored.
+	Dotenv map[string]string
+	// Environment is made available to expressions via the env() function.
+	Environment map[string]string
+	// ReadFile reads the files of functions such as file(). Defaults to
+	// ioutil.ReadFile.
+	ReadFile func(path string) ([]byte, error)
+	// LoadModule is called to load the manifest referenced by each module
+	// block. If nil, module blocks are reported as errors.
+	LoadModule ModuleLoader
+}
+
+func Parse(filename string, bs []byte, opts *Options) *Manifest {
 	file, diags := hclsyntax.ParseConfig(bs, filename, hcl.InitialPos)
-	return NewManifest(filename, file, diags)
+	return NewManifest(filename, file, diags, opts)
 }
 
-func NewManifest(filename string, f *hcl.File, diags hcl.Diagnostics) *Manifest {
+func NewManifest(filename string, f *hcl.File, diags hcl.Diagnostics, opts *Options) *Manifest {
+	if opts == nil {
+		opts = &Options{}
+	}
+	baseDir := opts.BaseDir
+	if baseDir == "" && filename != "" {
+		baseDir = filepath.Dir(filename)
+	}
+	rootDir := opts.RootDir
+	if rootDir == "" {
+		rootDir = baseDir
+	}
+	readFile := opts.ReadFile
+	if readFile == nil {
+		readFile = ioutil.ReadFile
+	}
 	m := &Manifest{
 		filename: filename,
+		baseDir:  baseDir,
 		f:        f,
 		diags:    diags,
 		evalCtx: &hcl.EvalContext{
-			Functions: map[string]function.Function{
-				"jsonencode": stdlib.JSONEncodeFunc,
-				"yamlencode": ctyyaml.YAMLEncodeFunc,
-			},
+			Variables: map[string]cty.Value{},
+			Functions: makeFunctions(&fileReader{
+				baseDir:  baseDir,
+				rootDir:  rootDir,
+				readFile: readFile,
+			}, opts.Environment),
 		},
 	}
 
-	m.content, diags = m.f.Body.Content(&hcl.BodySchema{
+	var body hcl.Body = hcl.EmptyBody()
+	if f != nil {
+		body = f.Body
+	}
+	m.content, diags = body.Content(&hcl.BodySchema{
 		Attributes: []hcl.AttributeSchema{
 			{Name: "exo", Required: true},
 		},
 		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "variable", LabelNames: []string{"name"}},
+			{Type: "locals"},
+			{Type: "module", LabelNames: []string{"name"}},
 			{Type: "environment"},
 			{Type: "components"},
 		},
 	})
 	m.appendDiags(diags...)
 
-	var environmentBlocks, componentBlocks hcl.Blocks
+	var variableBlocks, localsBlocks, moduleBlocks, environmentBlocks, componentBlocks hcl.Blocks
 	if m.content != nil {
+		variableBlocks = m.content.Blocks.OfType("variable")
+		localsBlocks = m.content.Blocks.OfType("locals")
+		moduleBlocks = m.content.Blocks.OfType("module")
 		environmentBlocks = m.content.Blocks.OfType("environment")
 		componentBlocks = m.content.Blocks.OfType("components")
 	}
+	m.variables = newVariables(m, variableBlocks, opts)
+	m.evalCtx.Variables["var"] = variablesObject(m.variables)
+	m.locals = newLocals(m, localsBlocks)
+	m.modules = newModules(m, moduleBlocks, opts.LoadModule)
 	m.environment = newEnvironment(m, environmentBlocks)
-	m.components = newComponentSet(m, componentBlocks)
+	m.components = newComponentSet(m, componentBlocks, m.modules)
 
 	return m
 }
@@ -75,11 +137,18 @@ func (m *Manifest) File() *hcl.File {
 	return m.f
 }
 
-func (m *Manifest) FormatVersion() (version FormatVersion) {
-	attr := m.content.Attributes["exo"]
+func (m *Manifest) FormatVersion() FormatVersion {
+	version, diags := parseFormatVersion(m.content.Attributes["exo"])
+	m.appendDiags(diags...)
+	return version
+}
+
+// parseFormatVersion parses the value of the exo attribute, checking that it
+// is supported by this version of exo.
+func parseFormatVersion(attr *hcl.Attribute) (version FormatVersion, diags hcl.Diagnostics) {
 	s, diag := parseLiteralString(attr.Expr)
 	if diag != nil {
-		m.appendDiags(diag)
+		diags = append(diags, diag)
 		return
 	}
 	parts := strings.Split(s, ".")
@@ -91,7 +160,7 @@ func (m *Manifest) FormatVersion() (version FormatVersion) {
 		ints[index] = i
 	}
 	if !ok {
-		m.appendDiags(&hcl.Diagnostic{
+		diags = append(diags, &hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Invalid exo format version constraint",
 			Detail:   fmt.Sprintf(`Exo format constraint should be specified as 'major.minor'. The latest version is %q.`, Latest),
@@ -103,18 +172,18 @@ func (m *Manifest) FormatVersion() (version FormatVersion) {
 	version.Major = ints[0]
 	version.Minor = ints[1]
 	switch version.Major {
-	case 1:
+	case Latest.Major:
 		if Latest.Minor < version.Minor {
-			m.appendDiags(&hcl.Diagnostic{
+			diags = append(diags, &hcl.Diagnostic{
 				Severity: hcl.DiagError,
 				Summary:  "Unsupported exo format minor version",
-				Detail:   fmt.Sprintf(`Unsupported exo format minor version. The maximum supported "1.x" version is %q.`, Latest),
+				Detail:   fmt.Sprintf(`Unsupported exo format minor version. The maximum supported "%d.x" version is %q.`, Latest.Major, Latest),
 				Subject:  attr.Expr.Range().Ptr(),
 				Context:  attr.Range.Ptr(),
 			})
 		}
 	default:
-		m.appendDiags(&hcl.Diagnostic{
+		diags = append(diags, &hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Unsupported exo format major version",
 			Detail:   fmt.Sprintf(`Unsupported exo format major version. The latest version is %q.`, Latest),
@@ -128,7 +197,10 @@ func (m *Manifest) FormatVersion() (version FormatVersion) {
 func (m *Manifest) evalString(x hcl.Expression) string {
 	v, diags := x.Value(m.evalCtx)
 	m.appendDiags(diags...)
-	if v.Type() != cty.String {
+	if diags.HasErrors() {
+		return ""
+	}
+	if v.Type() != cty.String || v.IsNull() || !v.IsKnown() {
 		m.appendDiags(&hcl.Diagnostic{
 			Severity: hcl.DiagError,
 			Summary:  "Expected string",
@@ -140,6 +212,40 @@ func (m *Manifest) evalString(x hcl.Expression) string {
 	return v.AsString()
 }
 
+// evalAs evaluates x, converts the result to typ, and then decodes it in to
+// the Go value pointed to by out. Returns false if any step failed, in which
+// case an error diagnostic has been recorded.
+func (m *Manifest) evalAs(x hcl.Expression, typ cty.Type, friendlyName string, out interface{}) bool {
+	v, diags := x.Value(m.evalCtx)
+	m.appendDiags(diags...)
+	if diags.HasErrors() {
+		return false
+	}
+	v, err := convert.Convert(v, typ)
+	if err == nil {
+		err = gocty.FromCtyValue(v, out)
+	}
+	if err != nil {
+		m.appendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("Expected %s", friendlyName),
+			Detail:   fmt.Sprintf("Expected %s: %v", friendlyName, err),
+			Subject:  x.Range().Ptr(),
+		})
+		return false
+	}
+	return true
+}
+
+func (m *Manifest) Variables() []*Variable {
+	return m.variables
+}
+
+// Modules returns the manifests included by module blocks.
+func (m *Manifest) Modules() []*Module {
+	return m.modules
+}
+
 func (m *Manifest) Environment() *Environment {
 	return m.environment
 }
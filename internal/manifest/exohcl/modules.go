@@ -0,0 +1,193 @@
+package exohcl
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ModuleLoader loads the manifest referenced by a module block. The source
+// path is relative to the including manifest's base directory and may be
+// either a manifest file or a directory containing one. An empty format
+// means the format should be guessed from the file name.
+type ModuleLoader func(name string, source string, format string, variables map[string]string) (*Manifest, error)
+
+// Module is a manifest included in another manifest with a `module` block.
+// The module's components are added to the including manifest, with names
+// prefixed by the module name and paths relocated relative to the module's
+// base directory.
+type Module struct {
+	name     string
+	manifest *Manifest
+}
+
+func (mod *Module) Name() string {
+	return mod.name
+}
+
+func (mod *Module) Manifest() *Manifest {
+	return mod.manifest
+}
+
+func newModules(m *Manifest, blocks hcl.Blocks, load ModuleLoader) []*Module {
+	var modules []*Module
+	declared := make(map[string]*hcl.Block, len(blocks))
+	for _, block := range blocks {
+		name := block.Labels[0]
+		if prev, ok := declared[name]; ok {
+			m.appendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate module",
+				Detail:   fmt.Sprintf("A module named %q was already declared at %s.", name, prev.DefRange),
+				Subject:  block.LabelRanges[0].Ptr(),
+			})
+			continue
+		}
+		declared[name] = block
+		if err := ValidateName(name); err != nil {
+			m.appendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid module name",
+				Detail:   fmt.Sprintf("Module name %q is invalid: %v", name, err),
+				Subject:  block.LabelRanges[0].Ptr(),
+			})
+			continue
+		}
+
+		content, diags := block.Body.Content(&hcl.BodySchema{
+			Attributes: []hcl.AttributeSchema{
+				{Name: "source", Required: true},
+				{Name: "format"},
+				{Name: "variables"},
+			},
+		})
+		m.appendDiags(diags...)
+		if diags.HasErrors() {
+			continue
+		}
+
+		source := m.evalString(content.Attributes["source"].Expr)
+		if source == "" {
+			continue
+		}
+		var format string
+		if attr := content.Attributes["format"]; attr != nil {
+			format = m.evalString(attr.Expr)
+		}
+		var variables map[string]string
+		if attr := content.Attributes["variables"]; attr != nil {
+			if !m.evalAs(attr.Expr, cty.Map(cty.String), "map of strings", &variables) {
+				continue
+			}
+		}
+
+		if load == nil {
+			m.appendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Modules not supported",
+				Detail:   "Module blocks are not supported in this context.",
+				Subject:  block.DefRange.Ptr(),
+			})
+			continue
+		}
+		sub, err := load(name, source, format, variables)
+		if err != nil {
+			m.appendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Error loading module",
+				Detail:   fmt.Sprintf("Could not load module %q: %v", name, err),
+				Subject:  content.Attributes["source"].Expr.Range().Ptr(),
+			})
+			continue
+		}
+		m.appendDiags(sub.Diagnostics()...)
+		modules = append(modules, &Module{
+			name:     name,
+			manifest: sub,
+		})
+	}
+	return modules
+}
+
+// importComponents returns copies of the module's components suitable for
+// inclusion in the including manifest.
+func (mod *Module) importComponents(m *Manifest) []*Component {
+	dir, err := filepath.Rel(m.baseDir, mod.manifest.baseDir)
+	if err != nil {
+		dir = mod.manifest.baseDir
+	}
+	prefix := mod.name + "-"
+	components := mod.manifest.Components()
+	res := make([]*Component, 0, components.Len())
+	for i := 0; i < components.Len(); i++ {
+		c := components.Index(i)
+		imported := &Component{
+			m:         m,
+			source:    c.source,
+			expansion: c.expansion,
+			typ:       c.typ,
+			name:      prefix + c.name,
+			specValue: c.specValue,
+			deferred:  c.deferred,
+			profiles:  c.profiles,
+			imported: &importedComponent{
+				origin: c,
+				prefix: prefix,
+				dir:    dir,
+			},
+		}
+		for _, dep := range c.dependsOn {
+			imported.dependencies = append(imported.dependencies, componentReference{
+				name: prefix + dep,
+				rng:  c.source.DefRange(),
+			})
+		}
+		if !c.deferred {
+			var err error
+			imported.spec, err = relocateSpec(c.typ, c.spec, dir)
+			if err != nil {
+				m.appendDiags(&hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "Cannot relocate component spec",
+					Detail:   fmt.Sprintf("Could not resolve paths in %q relative to module %q: %v", c.name, mod.name, err),
+					Subject:  c.source.DefRange().Ptr(),
+				})
+			}
+			imported.specValue = decodeSpec(imported.spec)
+		}
+		res = append(res, imported)
+	}
+	return res
+}
+
+type importedComponent struct {
+	origin *Component
+	prefix string
+	// Directory of the module, relative to the including manifest.
+	dir string
+}
+
+func (imp *importedComponent) evalSpec(instances map[string]ComponentInstance) (string, hcl.Diagnostics) {
+	originInstances := make(map[string]ComponentInstance, len(instances))
+	for name, instance := range instances {
+		if len(name) > len(imp.prefix) && name[:len(imp.prefix)] == imp.prefix {
+			originInstances[name[len(imp.prefix):]] = instance
+		}
+	}
+	spec, diags := imp.origin.EvalSpec(originInstances)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	spec, err := relocateSpec(imp.origin.typ, spec, imp.dir)
+	if err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cannot relocate component spec",
+			Detail:   err.Error(),
+			Subject:  imp.origin.source.DefRange().Ptr(),
+		})
+	}
+	return spec, diags
+}
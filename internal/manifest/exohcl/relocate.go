@@ -0,0 +1,68 @@
+package exohcl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"gopkg.in/yaml.v3"
+)
+
+// relocateSpec rewrites relative paths in a component spec, such that paths
+// that were relative to a module's directory become relative to the
+// directory of the including manifest. Process components without an
+// explicit directory are given the module directory as their working
+// directory.
+func relocateSpec(typ string, spec string, dir string) (string, error) {
+	if dir == "." || dir == "" {
+		return spec, nil
+	}
+	switch typ {
+	case "process":
+		return relocateProcessSpec(spec, dir)
+	case "container":
+		return relocateContainerSpec(spec, dir)
+	default:
+		return spec, nil
+	}
+}
+
+func relocateProcessSpec(spec string, dir string) (string, error) {
+	dec := json.NewDecoder(strings.NewReader(spec))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return "", fmt.Errorf("decoding process spec: %w", err)
+	}
+	directory, _ := obj["directory"].(string)
+	if directory == "" {
+		directory = "."
+	}
+	obj["directory"] = compose.RelocatePath(directory, dir)
+	bs, err := json.Marshal(obj)
+	if err != nil {
+		return "", fmt.Errorf("encoding process spec: %w", err)
+	}
+	return string(bs), nil
+}
+
+func relocateContainerSpec(spec string, dir string) (string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(spec), &doc); err != nil {
+		return "", fmt.Errorf("decoding container spec: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return spec, nil
+	}
+	compose.RelocateService(doc.Content[0], dir)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return "", fmt.Errorf("encoding container spec: %w", err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,259 @@
+package exohcl
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deref/exo/internal/manifest/exohcl/hclgen"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+// Upgrade rewrites the source of an exo manifest to the Latest format
+// version, converts long-form component blocks to the equivalent shorthand
+// where possible, and formats the result. Long-form blocks remain supported;
+// they are converted because shorthand is the preferred style.
+//
+// Unlike the language server's formatter, which regenerates files with
+// hclgen, Upgrade edits the source text in place and then runs
+// hclwrite.Format. hclgen does not carry comments, and a manifest upgrade
+// must not lose them, so comments and the order of declarations are
+// preserved. Converted blocks are assembled from the source of their
+// attributes.
+//
+// The following rewrites are performed:
+//
+//   - A missing or outdated exo format version is set to Latest.
+//   - Long-form component blocks of the built-in component types, whose spec
+//     is a literal object passed to jsonencode or yamlencode, are converted
+//     to shorthand blocks. The depends_on and profiles attributes move in to
+//     a "_" meta block.
+func Upgrade(filename string, src []byte) ([]byte, hcl.Diagnostics) {
+	f, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body := f.Body.(*hclsyntax.Body)
+
+	u := &upgrader{src: src}
+	diags = append(diags, u.upgradeVersion(body)...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	for _, block := range body.Blocks {
+		if block.Type != "components" {
+			continue
+		}
+		for _, component := range block.Body.Blocks {
+			if component.Type == "component" {
+				u.upgradeComponent(component)
+			}
+		}
+	}
+	return hclwrite.Format(u.apply()), diags
+}
+
+type upgrader struct {
+	src   []byte
+	edits []sourceEdit
+}
+
+// sourceEdit replaces the source bytes in [Start, End) with Text.
+type sourceEdit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+func (u *upgrader) replace(rng hcl.Range, text string) {
+	u.edits = append(u.edits, sourceEdit{
+		Start: rng.Start.Byte,
+		End:   rng.End.Byte,
+		Text:  text,
+	})
+}
+
+func (u *upgrader) source(rng hcl.Range) string {
+	return string(rng.SliceBytes(u.src))
+}
+
+// apply returns the source with all edits applied. Edits must not overlap.
+func (u *upgrader) apply() []byte {
+	sort.Slice(u.edits, func(i, j int) bool {
+		return u.edits[i].Start < u.edits[j].Start
+	})
+	var buf bytes.Buffer
+	offset := 0
+	for _, edit := range u.edits {
+		buf.Write(u.src[offset:edit.Start])
+		buf.WriteString(edit.Text)
+		offset = edit.End
+	}
+	buf.Write(u.src[offset:])
+	return buf.Bytes()
+}
+
+func (u *upgrader) upgradeVersion(body *hclsyntax.Body) hcl.Diagnostics {
+	latest := string(hclgen.TokensForExpression(hclgen.NewStringLiteral(Latest.String(), hcl.Range{})).Bytes())
+	attr := body.Attributes["exo"]
+	if attr == nil {
+		u.edits = append(u.edits, sourceEdit{
+			Text: fmt.Sprintf("exo = %s\n\n", latest),
+		})
+		return nil
+	}
+	version, diags := parseFormatVersion(attr.AsHCLAttribute())
+	if diags.HasErrors() {
+		return diags
+	}
+	if version.Less(Latest) {
+		u.replace(attr.Expr.Range(), latest)
+	}
+	return diags
+}
+
+// upgradeComponent converts a long-form component block to a shorthand block,
+// if possible. Blocks that cannot be represented in shorthand are left alone.
+func (u *upgrader) upgradeComponent(block *hclsyntax.Block) {
+	if len(block.Labels) != 1 || len(block.Body.Blocks) > 0 {
+		return
+	}
+	attrs := block.Body.Attributes
+	typeAttr, specAttr := attrs["type"], attrs["spec"]
+	if typeAttr == nil || specAttr == nil {
+		return
+	}
+	typ, diag := parseLiteralString(typeAttr.Expr)
+	if diag != nil {
+		return
+	}
+	encodefunc, ok := shorthandEncodeFuncs[typ]
+	if !ok {
+		return
+	}
+	call, ok := specAttr.Expr.(*hclsyntax.FunctionCallExpr)
+	if !ok || call.Name != encodefunc || len(call.Args) != 1 || call.ExpandFinal {
+		return
+	}
+	obj, ok := call.Args[0].(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return
+	}
+
+	var metaAttrs []*hclsyntax.Attribute
+	for name, attr := range attrs {
+		switch name {
+		case "type", "spec":
+		case "depends_on", "profiles":
+			metaAttrs = append(metaAttrs, attr)
+		default:
+			return
+		}
+	}
+	sort.Slice(metaAttrs, func(i, j int) bool {
+		return metaAttrs[i].SrcRange.Start.Byte < metaAttrs[j].SrcRange.Start.Byte
+	})
+
+	// Comments of the block that are not part of the spec object, nor of the
+	// meta attributes, are moved to the top of the shorthand block.
+	var lines []string
+	covered := []hcl.Range{obj.Range()}
+	for _, attr := range metaAttrs {
+		covered = append(covered, attr.SrcRange)
+	}
+	for _, comment := range u.comments(block.Body.SrcRange) {
+		if !rangesContain(covered, comment.Range) {
+			lines = append(lines, comment.Text)
+		}
+	}
+
+	// Each item of the spec object becomes an attribute, preserving the
+	// comments between items. A comment on the same line as the preceding item
+	// remains a trailing comment.
+	itemLine := -1
+	appendGapComments := func(start, end hcl.Pos) {
+		for _, comment := range u.comments(hcl.Range{Start: start, End: end}) {
+			trailing := !bytes.ContainsRune(u.src[start.Byte:comment.Range.Start.Byte], '\n')
+			if trailing && itemLine >= 0 {
+				lines[itemLine] += " " + comment.Text
+			} else {
+				lines = append(lines, comment.Text)
+			}
+		}
+	}
+	prevEnd := obj.OpenRange.End
+	for _, item := range obj.Items {
+		name, ok := objectKeyName(item.KeyExpr)
+		if !ok {
+			return
+		}
+		appendGapComments(prevEnd, item.KeyExpr.Range().Start)
+		lines = append(lines, fmt.Sprintf("%s = %s", name, u.source(item.ValueExpr.Range())))
+		itemLine = len(lines) - 1
+		prevEnd = item.ValueExpr.Range().End
+	}
+	appendGapComments(prevEnd, hcl.Pos{Byte: obj.SrcRange.End.Byte - 1})
+
+	if len(metaAttrs) > 0 {
+		lines = append(lines, "", "_ {")
+		for _, attr := range metaAttrs {
+			lines = append(lines, u.source(attr.SrcRange))
+		}
+		lines = append(lines, "}")
+	}
+
+	u.replace(block.Range(), fmt.Sprintf("%s %s {\n%s\n}", typ, u.source(block.LabelRanges[0]), strings.Join(lines, "\n")))
+}
+
+type sourceComment struct {
+	Text  string
+	Range hcl.Range
+}
+
+// comments returns the comments in a range of the source.
+func (u *upgrader) comments(rng hcl.Range) []sourceComment {
+	tokens, _ := hclsyntax.LexConfig(rng.SliceBytes(u.src), "", rng.Start)
+	var comments []sourceComment
+	for _, token := range tokens {
+		if token.Type != hclsyntax.TokenComment {
+			continue
+		}
+		comments = append(comments, sourceComment{
+			Text:  strings.TrimRight(string(token.Bytes), "\r\n"),
+			Range: token.Range,
+		})
+	}
+	return comments
+}
+
+func rangesContain(rngs []hcl.Range, rng hcl.Range) bool {
+	for _, r := range rngs {
+		if r.Start.Byte <= rng.Start.Byte && rng.End.Byte <= r.End.Byte {
+			return true
+		}
+	}
+	return false
+}
+
+// objectKeyName returns the name of an object key, if it is a valid attribute
+// name for a shorthand block.
+func objectKeyName(x hclsyntax.Expression) (string, bool) {
+	if key, ok := x.(*hclsyntax.ObjectConsKeyExpr); ok {
+		x = key.Wrapped
+	}
+	name := hcl.ExprAsKeyword(x)
+	if name == "" {
+		s, diag := parseLiteralString(x)
+		if diag != nil {
+			return "", false
+		}
+		name = s
+	}
+	if !hclsyntax.ValidIdentifier(name) || name == "_" {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,92 @@
+package exohcl_test
+
+import (
+	"testing"
+
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgrade(t *testing.T) {
+	src := `# The web app.
+components {
+  # Serves the API.
+  component "web" {
+    type = "process"
+    # Comment outside of the spec.
+    spec = jsonencode({
+      # Leading comment.
+      program = "server" # Trailing comment.
+      "arguments" = ["--port", var.port]
+    })
+    depends_on = ["db"]
+  }
+
+  component "db" {
+    type = "container"
+    spec = yamlencode({ image = "postgres" })
+  }
+
+  component "custom" {
+    type = "example"
+    spec = "{}"
+  }
+
+  component "dotted" {
+    type = "container"
+    spec = yamlencode({ "x.y" = 1 })
+  }
+}
+`
+	expected := `exo = "0.1"
+
+# The web app.
+components {
+  # Serves the API.
+  process "web" {
+    # Comment outside of the spec.
+    # Leading comment.
+    program   = "server" # Trailing comment.
+    arguments = ["--port", var.port]
+
+    _ {
+      depends_on = ["db"]
+    }
+  }
+
+  container "db" {
+    image = "postgres"
+  }
+
+  component "custom" {
+    type = "example"
+    spec = "{}"
+  }
+
+  component "dotted" {
+    type = "container"
+    spec = yamlencode({ "x.y" = 1 })
+  }
+}
+`
+	actual, diags := exohcl.Upgrade("exo.hcl", []byte(src))
+	require.Empty(t, diags)
+	assert.Equal(t, expected, string(actual))
+
+	again, diags := exohcl.Upgrade("exo.hcl", actual)
+	require.Empty(t, diags)
+	assert.Equal(t, expected, string(again))
+
+	m := exohcl.Parse("exo.hcl", actual, nil)
+	var errs []string
+	for _, diag := range m.Diagnostics() {
+		errs = append(errs, diag.Error())
+	}
+	assert.Len(t, errs, 1) // Undeclared variable.
+}
+
+func TestUpgradeFutureVersion(t *testing.T) {
+	_, diags := exohcl.Upgrade("exo.hcl", []byte(`exo = "0.99"`))
+	assert.True(t, diags.HasErrors())
+}
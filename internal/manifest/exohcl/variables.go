@@ -0,0 +1,187 @@
+package exohcl
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// Variable is an input to a manifest, declared with a `variable` block.
+// Variables are referenced in expressions as `var.<name>`.
+type Variable struct {
+	name        string
+	description string
+	typ         cty.Type
+	value       cty.Value
+}
+
+func (v *Variable) Name() string {
+	return v.name
+}
+
+func (v *Variable) Description() string {
+	return v.description
+}
+
+func (v *Variable) Value() cty.Value {
+	return v.value
+}
+
+// Assignments of variable values take precedence in the following order,
+// from lowest to highest: the declared default, the matching entry from a
+// .env file, then an explicit assignment, such as from the command line.
+func newVariables(m *Manifest, blocks hcl.Blocks, opts *Options) []*Variable {
+	var variables []*Variable
+	declared := make(map[string]*hcl.Block, len(blocks))
+	for _, block := range blocks {
+		name := block.Labels[0]
+		if prev, ok := declared[name]; ok {
+			m.appendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate variable declaration",
+				Detail:   fmt.Sprintf("A variable named %q was already declared at %s.", name, prev.DefRange),
+				Subject:  block.LabelRanges[0].Ptr(),
+			})
+			continue
+		}
+		declared[name] = block
+		if !hclsyntax.ValidIdentifier(name) {
+			m.appendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid variable name",
+				Detail:   "A variable name must be a valid identifier.",
+				Subject:  block.LabelRanges[0].Ptr(),
+			})
+			continue
+		}
+
+		content, diags := block.Body.Content(&hcl.BodySchema{
+			Attributes: []hcl.AttributeSchema{
+				{Name: "default"},
+				{Name: "description"},
+				{Name: "type"},
+			},
+		})
+		m.appendDiags(diags...)
+		if diags.HasErrors() {
+			continue
+		}
+
+		v := &Variable{
+			name:  name,
+			typ:   cty.DynamicPseudoType,
+			value: cty.NullVal(cty.DynamicPseudoType),
+		}
+
+		if attr := content.Attributes["description"]; attr != nil {
+			var diag *hcl.Diagnostic
+			v.description, diag = parseLiteralString(attr.Expr)
+			if diag != nil {
+				m.appendDiags(diag)
+			}
+		}
+
+		if attr := content.Attributes["type"]; attr != nil {
+			typ, diags := typeexpr.TypeConstraint(attr.Expr)
+			m.appendDiags(diags...)
+			if !diags.HasErrors() {
+				v.typ = typ
+			}
+		}
+
+		hasValue := false
+		if attr := content.Attributes["default"]; attr != nil {
+			// Defaults may not refer to other variables, locals, etc.
+			value, diags := attr.Expr.Value(nil)
+			m.appendDiags(diags...)
+			if !diags.HasErrors() {
+				v.value = m.convertVariable(name, value, v.typ, attr.Expr.Range())
+				hasValue = true
+			}
+		}
+
+		if s, ok := opts.Dotenv[name]; ok {
+			v.value = m.parseVariable(name, s, v.typ, block.DefRange)
+			hasValue = true
+		}
+		if s, ok := opts.Variables[name]; ok {
+			v.value = m.parseVariable(name, s, v.typ, block.DefRange)
+			hasValue = true
+		}
+
+		if !hasValue {
+			m.appendDiags(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "No value for required variable",
+				Detail:   fmt.Sprintf("The variable %q has no default, so a value must be provided, such as with --var %s=VALUE.", name, name),
+				Subject:  block.DefRange.Ptr(),
+			})
+		}
+
+		variables = append(variables, v)
+	}
+
+	undeclared := make([]string, 0, len(opts.Variables))
+	for name := range opts.Variables {
+		if _, ok := declared[name]; !ok {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	for _, name := range undeclared {
+		m.appendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "Value for undeclared variable",
+			Detail:   fmt.Sprintf("A value was assigned to %q, but the manifest does not declare a variable with that name.", name),
+		})
+	}
+
+	return variables
+}
+
+// parseVariable interprets an externally supplied string as a value of the
+// given type. Primitive values are converted directly from the string, but
+// collection and structural values are parsed as HCL literal expressions.
+func (m *Manifest) parseVariable(name string, s string, typ cty.Type, subject hcl.Range) cty.Value {
+	if typ == cty.DynamicPseudoType || typ.IsPrimitiveType() {
+		return m.convertVariable(name, cty.StringVal(s), typ, subject)
+	}
+	expr, diags := hclsyntax.ParseExpression([]byte(s), fmt.Sprintf("<value for var.%s>", name), hcl.InitialPos)
+	m.appendDiags(diags...)
+	if diags.HasErrors() {
+		return cty.DynamicVal
+	}
+	value, diags := expr.Value(nil)
+	m.appendDiags(diags...)
+	if diags.HasErrors() {
+		return cty.DynamicVal
+	}
+	return m.convertVariable(name, value, typ, subject)
+}
+
+func (m *Manifest) convertVariable(name string, value cty.Value, typ cty.Type, subject hcl.Range) cty.Value {
+	converted, err := convert.Convert(value, typ)
+	if err != nil {
+		m.appendDiags(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid value for variable",
+			Detail:   fmt.Sprintf("The value for variable %q is not compatible with its type constraint: %v.", name, err),
+			Subject:  subject.Ptr(),
+		})
+		return cty.DynamicVal
+	}
+	return converted
+}
+
+func variablesObject(variables []*Variable) cty.Value {
+	attrs := make(map[string]cty.Value, len(variables))
+	for _, v := range variables {
+		attrs[v.name] = v.value
+	}
+	return cty.ObjectVal(attrs)
+}
@@ -0,0 +1,158 @@
+package exohcl_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deref/exo/internal/environment"
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariablesAndLocals(t *testing.T) {
+	src := `
+exo = "0.1"
+
+variable "port" {
+  type    = number
+  default = 3000
+}
+
+variable "name" {
+  default = "web"
+}
+
+variable "mode" {}
+
+locals {
+  url  = "http://localhost:${local.port}"
+  port = var.port + 1
+}
+
+components {
+  process "web" {
+    program   = "server"
+    arguments   = [upper(var.name), var.mode, local.url, env("HOME", "none")]
+  }
+}
+`
+	m := exohcl.Parse("exo.hcl", []byte(src), &exohcl.Options{
+		Variables: map[string]string{
+			"mode": "dev",
+		},
+		Dotenv: map[string]string{
+			"port":      "8000",
+			"UNRELATED": "ignored",
+		},
+		Environment: map[string]string{},
+	})
+	require.Empty(t, m.Diagnostics())
+	require.Equal(t, 1, m.Components().Len())
+	assert.Equal(t,
+		`{"arguments":["WEB","dev","http://localhost:8001","none"],"program":"server"}`,
+		m.Components().Index(0).Spec(),
+	)
+}
+
+func TestVariableErrors(t *testing.T) {
+	check := func(src string, vars map[string]string, expected string) {
+		t.Helper()
+		m := exohcl.Parse("exo.hcl", []byte(src), &exohcl.Options{
+			Variables: vars,
+		})
+		diags := m.Diagnostics()
+		if assert.NotEmpty(t, diags) {
+			assert.Equal(t, expected, diags[0].Summary)
+		}
+	}
+	check(`
+exo = "0.1"
+variable "x" {}
+`, nil, "No value for required variable")
+	check(`
+exo = "0.1"
+variable "x" {
+  type = number
+}
+`, map[string]string{"x": "abc"}, "Invalid value for variable")
+	check(`
+exo = "0.1"
+`, map[string]string{"x": "abc"}, "Value for undeclared variable")
+	check(`
+exo = "0.1"
+locals {
+  a = local.b
+  b = local.a
+}
+`, nil, "Cycle in local values")
+}
+
+func TestFileFunctions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "greeting.txt"), []byte("hello"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "greeting.tmpl"), []byte("${greeting}, ${upper(name)}"), 0600))
+
+	src := `
+exo = "0.1"
+
+environment {
+  PLAIN    = file("greeting.txt")
+  TEMPLATE = templatefile("greeting.tmpl", { greeting = "hi", name = "bob" })
+  ENCODED  = base64encode("abc")
+}
+`
+	m := exohcl.Parse(filepath.Join(dir, "exo.hcl"), []byte(src), nil)
+	require.Empty(t, m.Diagnostics())
+
+	env := map[string]string{}
+	require.NoError(t, m.Environment().ExtendEnvironment(envMap(env)))
+	assert.Equal(t, map[string]string{
+		"PLAIN":    "hello",
+		"TEMPLATE": "hi, BOB",
+		"ENCODED":  "YWJj",
+	}, env)
+}
+
+func TestFileFunctionsOutsideRoot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exohcl")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rootDir := filepath.Join(dir, "root")
+	require.NoError(t, os.Mkdir(rootDir, 0700))
+	secretPath := filepath.Join(dir, "secret.txt")
+	require.NoError(t, ioutil.WriteFile(secretPath, []byte("secret"), 0600))
+
+	check := func(expr string) {
+		t.Helper()
+		src := fmt.Sprintf(`
+exo = "0.1"
+
+environment {
+  VALUE = %s
+}
+`, expr)
+		m := exohcl.Parse(filepath.Join(rootDir, "exo.hcl"), []byte(src), nil)
+		diags := m.Diagnostics()
+		if assert.NotEmpty(t, diags) {
+			assert.Contains(t, diags[0].Detail, "outside of workspace root")
+		}
+	}
+	check(`file("../secret.txt")`)
+	check(fmt.Sprintf(`file(%q)`, secretPath))
+	check(`templatefile("../secret.txt", {})`)
+	check(`fileexists("../secret.txt")`)
+}
+
+type envMap map[string]string
+
+func (env envMap) AppendVariable(src environment.Source, name string, value string) {
+	env[name] = value
+}
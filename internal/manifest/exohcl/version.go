@@ -20,3 +20,11 @@ type FormatVersion struct {
 func (ver FormatVersion) String() string {
 	return fmt.Sprintf("%d.%d", ver.Major, ver.Minor)
 }
+
+// Less reports whether ver is an older format version than other.
+func (ver FormatVersion) Less(other FormatVersion) bool {
+	if ver.Major != other.Major {
+		return ver.Major < other.Major
+	}
+	return ver.Minor < other.Minor
+}
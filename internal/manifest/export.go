@@ -0,0 +1,423 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/deref/exo/internal/manifest/exohcl"
+	"github.com/deref/exo/internal/manifest/exohcl/hclgen"
+	"github.com/deref/exo/internal/manifest/procfile"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/yaml.v3"
+)
+
+// Component is a component of a workspace to be exported as a manifest.
+type Component struct {
+	Name      string
+	Type      string
+	Spec      string
+	DependsOn []string
+}
+
+// Export serializes components as a manifest in the given format: exo,
+// compose or procfile. Anything that cannot be represented in the format is
+// omitted and reported with a warning.
+func Export(format string, components []Component) ([]byte, hcl.Diagnostics) {
+	components = append([]Component{}, components...)
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].Name < components[j].Name
+	})
+	switch format {
+	case "", "exo":
+		return exportExo(components)
+	case "compose":
+		return exportCompose(components)
+	case "procfile":
+		return exportProcfile(components)
+	default:
+		return nil, hcl.Diagnostics{
+			&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("unsupported manifest format: %q", format),
+			},
+		}
+	}
+}
+
+func newExportWarning(component Component, problem string) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  fmt.Sprintf("%s %q: %s", component.Type, component.Name, problem),
+	}
+}
+
+func exportExo(components []Component) ([]byte, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	b := exohcl.NewBuilder(nil)
+	for _, component := range components {
+		block, err := exportComponentBlock(component)
+		if err != nil {
+			diags = append(diags, newExportWarning(component, err.Error()))
+			continue
+		}
+		b.AddComponentBlock(block)
+	}
+	return hclgen.FormatFile(b.Build()), diags
+}
+
+// exportComponentBlock produces a shorthand block for the known component
+// types, with each spec field as an attribute. Other types use the long form
+// component block with the encoded spec.
+func exportComponentBlock(component Component) (*hclgen.Block, error) {
+	var attrs []*hclsyntax.Attribute
+	var blocks []*hclgen.Block
+	blockType := component.Type
+	switch component.Type {
+	case "process", "container", "volume", "network":
+		spec, err := decodeSpec(component.Spec)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range sortedKeys(spec) {
+			v := spec[key]
+			if isEmptyValue(v) {
+				continue
+			}
+			if !hclsyntax.ValidIdentifier(key) {
+				return nil, fmt.Errorf("spec field %q is not a valid attribute name", key)
+			}
+			attrs = append(attrs, &hclsyntax.Attribute{
+				Name: key,
+				Expr: &hclsyntax.LiteralValueExpr{Val: v},
+			})
+		}
+		if len(component.DependsOn) > 0 {
+			blocks = append(blocks, &hclgen.Block{
+				Type: "_",
+				Body: &hclgen.Body{
+					Attributes: []*hclsyntax.Attribute{dependsOnAttribute(component.DependsOn)},
+				},
+			})
+		}
+	default:
+		blockType = "component"
+		attrs = []*hclsyntax.Attribute{
+			{
+				Name: "type",
+				Expr: hclgen.NewStringLiteral(component.Type, hcl.Range{}),
+			},
+			{
+				Name: "spec",
+				Expr: hclgen.NewStringLiteral(component.Spec, hcl.Range{}),
+			},
+		}
+		if len(component.DependsOn) > 0 {
+			attrs = append(attrs, dependsOnAttribute(component.DependsOn))
+		}
+	}
+	return &hclgen.Block{
+		Type:   blockType,
+		Labels: []string{component.Name},
+		Body: &hclgen.Body{
+			Attributes: attrs,
+			Blocks:     blocks,
+		},
+	}, nil
+}
+
+func dependsOnAttribute(dependsOn []string) *hclsyntax.Attribute {
+	exprs := make([]hclsyntax.Expression, len(dependsOn))
+	for i, dependency := range dependsOn {
+		exprs[i] = hclgen.NewStringLiteral(dependency, hcl.Range{})
+	}
+	return &hclsyntax.Attribute{
+		Name: "depends_on",
+		Expr: hclgen.NewTuple(exprs, hcl.Range{}),
+	}
+}
+
+// decodeSpec decodes a JSON or YAML spec as an object.
+func decodeSpec(spec string) (map[string]cty.Value, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	bs := []byte(spec)
+	typ, err := ctyyaml.ImpliedType(bs)
+	if err != nil {
+		return nil, fmt.Errorf("decoding spec: %w", err)
+	}
+	v, err := ctyyaml.Unmarshal(bs, typ)
+	if err != nil {
+		return nil, fmt.Errorf("decoding spec: %w", err)
+	}
+	if v.IsNull() {
+		return nil, nil
+	}
+	if !v.Type().IsObjectType() {
+		return nil, fmt.Errorf("expected spec to be an object, got %s", v.Type().FriendlyName())
+	}
+	return v.AsValueMap(), nil
+}
+
+func sortedKeys(m map[string]cty.Value) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isEmptyValue(v cty.Value) bool {
+	switch {
+	case v.IsNull():
+		return true
+	case v.Type() == cty.String:
+		return v.AsString() == ""
+	case v.Type().IsObjectType() || v.Type().IsTupleType() || v.CanIterateElements():
+		return v.LengthInt() == 0
+	default:
+		return false
+	}
+}
+
+func exportCompose(components []Component) ([]byte, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+
+	// Docker resources are referenced by their Docker names, but compose
+	// services reference them by key.
+	typeOf := make(map[string]string, len(components))
+	dockerNames := map[string]map[string]string{
+		"volume":  {},
+		"network": {},
+	}
+	for _, component := range components {
+		typeOf[component.Name] = component.Type
+		if names, ok := dockerNames[component.Type]; ok {
+			var spec struct {
+				Name string `yaml:"name"`
+			}
+			if err := yaml.Unmarshal([]byte(component.Spec), &spec); err == nil && spec.Name != "" {
+				names[spec.Name] = component.Name
+			}
+		}
+	}
+
+	sections := map[string]*yaml.Node{
+		"container": newMapping(),
+		"volume":    newMapping(),
+		"network":   newMapping(),
+	}
+	for _, component := range components {
+		section, ok := sections[component.Type]
+		if !ok {
+			diags = append(diags, newExportWarning(component, "only containers, volumes and networks can be exported to compose files"))
+			continue
+		}
+		spec, err := parseYAMLMapping(component.Spec)
+		if err != nil {
+			diags = append(diags, newExportWarning(component, err.Error()))
+			continue
+		}
+		if component.Type == "container" {
+			var dependencies []string
+			for _, dependency := range component.DependsOn {
+				if typeOf[dependency] == "container" {
+					dependencies = append(dependencies, dependency)
+				}
+			}
+			exportService(spec, dependencies, dockerNames["volume"], dockerNames["network"])
+		}
+		appendMapping(section, component.Name, spec)
+	}
+
+	doc := newMapping()
+	for _, section := range []struct {
+		Key  string
+		Type string
+	}{
+		{"services", "container"},
+		{"volumes", "volume"},
+		{"networks", "network"},
+	} {
+		if node := sections[section.Type]; len(node.Content) > 0 {
+			appendMapping(doc, section.Key, node)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("encoding compose file: %v", err),
+		})
+		return nil, diags
+	}
+	return buf.Bytes(), diags
+}
+
+// exportService rewrites a container spec as a compose service. Labels added
+// when converting from compose are removed, and references to volumes and
+// networks by Docker name are replaced with their keys.
+func exportService(service *yaml.Node, dependsOn []string, volumeNames, networkNames map[string]string) {
+	if labels := mappingValue(service, "labels"); labels != nil {
+		switch labels.Kind {
+		case yaml.MappingNode:
+			filtered := labels.Content[:0]
+			for i := 0; i+1 < len(labels.Content); i += 2 {
+				if !strings.HasPrefix(labels.Content[i].Value, "com.docker.compose.") {
+					filtered = append(filtered, labels.Content[i], labels.Content[i+1])
+				}
+			}
+			labels.Content = filtered
+		case yaml.SequenceNode:
+			filtered := labels.Content[:0]
+			for _, item := range labels.Content {
+				if !strings.HasPrefix(item.Value, "com.docker.compose.") {
+					filtered = append(filtered, item)
+				}
+			}
+			labels.Content = filtered
+		}
+		if len(labels.Content) == 0 {
+			deleteMappingKey(service, "labels")
+		}
+	}
+
+	if networks := mappingValue(service, "networks"); networks != nil {
+		switch networks.Kind {
+		case yaml.SequenceNode:
+			for _, item := range networks.Content {
+				if key, ok := networkNames[item.Value]; ok {
+					item.Value = key
+				}
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(networks.Content); i += 2 {
+				if key, ok := networkNames[networks.Content[i].Value]; ok {
+					networks.Content[i].Value = key
+				}
+			}
+		}
+	}
+
+	if volumes := mappingValue(service, "volumes"); volumes != nil && volumes.Kind == yaml.SequenceNode {
+		for _, volume := range volumes.Content {
+			switch volume.Kind {
+			case yaml.ScalarNode:
+				parts := strings.SplitN(volume.Value, ":", 2)
+				if key, ok := volumeNames[parts[0]]; ok {
+					parts[0] = key
+					volume.Value = strings.Join(parts, ":")
+				}
+			case yaml.MappingNode:
+				if source := mappingValue(volume, "source"); source != nil {
+					if key, ok := volumeNames[source.Value]; ok {
+						source.Value = key
+					}
+				}
+			}
+		}
+	}
+
+	if len(dependsOn) > 0 && mappingValue(service, "depends_on") == nil {
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, dependency := range dependsOn {
+			seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: dependency})
+		}
+		appendMapping(service, "depends_on", seq)
+	}
+}
+
+func parseYAMLMapping(s string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(s), &doc); err != nil {
+		return nil, fmt.Errorf("decoding spec: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return newMapping(), nil
+	}
+	node := doc.Content[0]
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("expected spec to be a mapping")
+	}
+	return node, nil
+}
+
+func newMapping() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+func appendMapping(node *yaml.Node, key string, value *yaml.Node) {
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func deleteMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func exportProcfile(components []Component) ([]byte, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	var procs []procfile.Process
+	for _, component := range components {
+		if component.Type != "process" {
+			diags = append(diags, newExportWarning(component, "only processes can be exported to Procfiles"))
+			continue
+		}
+		var spec struct {
+			Program     string            `json:"program"`
+			Arguments   []string          `json:"arguments"`
+			Directory   string            `json:"directory"`
+			Environment map[string]string `json:"environment"`
+		}
+		if err := json.Unmarshal([]byte(component.Spec), &spec); err != nil {
+			diags = append(diags, newExportWarning(component, fmt.Sprintf("decoding spec: %v", err)))
+			continue
+		}
+		if spec.Directory != "" {
+			diags = append(diags, newExportWarning(component, "working directory omitted, since Procfiles cannot specify one"))
+		}
+		if len(component.DependsOn) > 0 {
+			diags = append(diags, newExportWarning(component, "dependencies omitted, since Procfiles cannot specify them"))
+		}
+		procs = append(procs, procfile.Process{
+			Name:        component.Name,
+			Program:     spec.Program,
+			Arguments:   spec.Arguments,
+			Environment: spec.Environment,
+		})
+	}
+	procfile.Organize(&procs)
+
+	var buf bytes.Buffer
+	if err := procfile.Generate(&buf, procs); err != nil {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("generating procfile: %v", err),
+		})
+		return nil, diags
+	}
+	return buf.Bytes(), diags
+}
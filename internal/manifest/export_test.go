@@ -0,0 +1,94 @@
+package manifest_test
+
+import (
+	"testing"
+
+	"github.com/deref/exo/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportComponents = []manifest.Component{
+	{
+		Name: "web",
+		Type: "process",
+		Spec: `{"arguments":["--port","3000"],"directory":"","environment":{"PORT":"5000"},"program":"./server","shutdownGracePeriodSeconds":null}`,
+	},
+	{
+		Name:      "db",
+		Type:      "container",
+		Spec:      "image: postgres\nlabels:\n  com.docker.compose.project: app\n  com.docker.compose.service: db\nnetworks:\n  - app_default\nvolumes:\n  - app_data:/var/lib/postgresql/data\n",
+		DependsOn: []string{"data", "default", "cache"},
+	},
+	{
+		Name: "cache",
+		Type: "container",
+		Spec: "image: redis\n",
+	},
+	{
+		Name: "data",
+		Type: "volume",
+		Spec: "name: app_data\n",
+	},
+	{
+		Name: "default",
+		Type: "network",
+		Spec: "driver: bridge\nname: app_default\n",
+	},
+}
+
+func TestExportExo(t *testing.T) {
+	bs, diags := manifest.Export("exo", exportComponents)
+	require.Empty(t, diags)
+
+	loader := &manifest.Loader{
+		WorkspaceName: "test",
+		Format:        "exo",
+		Bytes:         bs,
+	}
+	m, err := loader.Load()
+	require.NoError(t, err, string(bs))
+
+	components := m.Components()
+	require.Equal(t, len(exportComponents), components.Len())
+	for i := 0; i < components.Len(); i++ {
+		c := components.Index(i)
+		if c.Name() == "web" {
+			assert.Equal(t, `{"arguments":["--port","3000"],"environment":{"PORT":"5000"},"program":"./server"}`, c.Spec())
+		}
+		if c.Name() == "db" {
+			assert.Equal(t, []string{"data", "default", "cache"}, c.DependsOn())
+		}
+	}
+}
+
+func TestExportCompose(t *testing.T) {
+	bs, diags := manifest.Export("compose", exportComponents)
+	require.Len(t, diags, 1)
+	assert.Equal(t, `process "web": only containers, volumes and networks can be exported to compose files`, diags[0].Summary)
+	assert.Equal(t, `services:
+  cache:
+    image: redis
+  db:
+    image: postgres
+    networks:
+      - default
+    volumes:
+      - data:/var/lib/postgresql/data
+    depends_on:
+      - cache
+volumes:
+  data:
+    name: app_data
+networks:
+  default:
+    driver: bridge
+    name: app_default
+`, string(bs))
+}
+
+func TestExportProcfile(t *testing.T) {
+	bs, diags := manifest.Export("procfile", exportComponents)
+	assert.Len(t, diags, 4)
+	assert.Equal(t, "web: ./server --port 3000\n", string(bs))
+}
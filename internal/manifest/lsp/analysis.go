@@ -0,0 +1,283 @@
+package lsp
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Completion and hover operate on tokens rather than on a syntax tree, since
+// the document is usually incomplete while it is being edited.
+
+// scope is the set of attributes and nested blocks that are valid within a
+// body.
+type scope struct {
+	// Type of the block whose body this is. Empty at the top level.
+	Owner      string
+	Attributes []attributeSchema
+	Blocks     map[string]*blockSchema
+}
+
+// enclosingBlocks returns the types of the blocks enclosing offset, outermost
+// first. Object literals are included with an empty type.
+func enclosingBlocks(src []byte, offset int) []string {
+	tokens, _ := hclsyntax.LexConfig(src[:offset], "", hcl.InitialPos)
+	var stack []string
+	lineStart := 0
+	for i, tok := range tokens {
+		switch tok.Type {
+		case hclsyntax.TokenNewline:
+			lineStart = i + 1
+		case hclsyntax.TokenOBrace:
+			stack = append(stack, blockHeader(tokens[lineStart:i]))
+			lineStart = i + 1
+		case hclsyntax.TokenCBrace:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			lineStart = i + 1
+		}
+	}
+	return stack
+}
+
+// blockHeader returns the type of a block given the tokens preceding its
+// opening brace, or "" if the brace begins an object literal.
+func blockHeader(tokens hclsyntax.Tokens) string {
+	if len(tokens) == 0 || tokens[0].Type != hclsyntax.TokenIdent {
+		return ""
+	}
+	for _, tok := range tokens[1:] {
+		switch tok.Type {
+		case hclsyntax.TokenEqual, hclsyntax.TokenColon, hclsyntax.TokenOBrack, hclsyntax.TokenOParen:
+			return ""
+		}
+	}
+	return string(tokens[0].Bytes)
+}
+
+// scopeOf returns the scope of the innermost block of a stack returned by
+// enclosingBlocks. Returns nil for bodies that are not understood, such as
+// object literals.
+func scopeOf(stack []string) *scope {
+	switch len(stack) {
+	case 0:
+		return &scope{
+			Attributes: topLevelAttributes,
+			Blocks:     topLevelBlocks,
+		}
+	case 1:
+		if stack[0] == "components" {
+			blocks := make(map[string]*blockSchema, len(componentBlocks)+1)
+			for typ, block := range componentBlocks {
+				blocks[typ] = block
+			}
+			blocks["component"] = componentBlock
+			return &scope{
+				Owner:  "components",
+				Blocks: blocks,
+			}
+		}
+		if block, ok := topLevelBlocks[stack[0]]; ok && stack[0] != "locals" {
+			return &scope{
+				Owner:      stack[0],
+				Attributes: block.Attributes,
+			}
+		}
+	case 2:
+		if stack[0] != "components" {
+			return nil
+		}
+		if stack[1] == "component" {
+			return &scope{
+				Owner:      stack[1],
+				Attributes: componentBlock.Attributes,
+			}
+		}
+		if block, ok := componentBlocks[stack[1]]; ok {
+			return &scope{
+				Owner:      stack[1],
+				Attributes: block.Attributes,
+				Blocks: map[string]*blockSchema{
+					"_": metaBlock,
+				},
+			}
+		}
+	case 3:
+		if stack[0] == "components" && componentBlocks[stack[1]] != nil && stack[2] == "_" {
+			return &scope{
+				Owner:      "_",
+				Attributes: metaBlock.Attributes,
+			}
+		}
+	}
+	return nil
+}
+
+func (sc *scope) attribute(name string) *attributeSchema {
+	for i := range sc.Attributes {
+		if sc.Attributes[i].Name == name {
+			return &sc.Attributes[i]
+		}
+	}
+	return nil
+}
+
+// completions returns the attributes and blocks that may be inserted at
+// offset, which must be at the start of a line, ignoring an identifier being
+// typed.
+func completions(src []byte, offset int) []CompletionItem {
+	lineStart := offset
+	for lineStart > 0 && src[lineStart-1] != '\n' {
+		lineStart--
+	}
+	for _, c := range src[lineStart:offset] {
+		if !(c == ' ' || c == '\t' || c == '_' || c == '-' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')) {
+			return nil
+		}
+	}
+
+	sc := scopeOf(enclosingBlocks(src, lineStart))
+	if sc == nil {
+		return nil
+	}
+	items := []CompletionItem{}
+	for _, attr := range sc.Attributes {
+		items = append(items, CompletionItem{
+			Label:            attr.Name,
+			Kind:             CompletionItemKindProperty,
+			Detail:           sc.Owner + " attribute",
+			Documentation:    markdown(attr.Doc),
+			InsertText:       attr.Name + " = ",
+			InsertTextFormat: InsertTextFormatPlainText,
+		})
+	}
+	blockTypes := make([]string, 0, len(sc.Blocks))
+	for typ := range sc.Blocks {
+		blockTypes = append(blockTypes, typ)
+	}
+	sort.Strings(blockTypes)
+	for _, typ := range blockTypes {
+		block := sc.Blocks[typ]
+		items = append(items, CompletionItem{
+			Label:            typ,
+			Kind:             CompletionItemKindClass,
+			Detail:           "block",
+			Documentation:    markdown(block.Doc),
+			InsertText:       block.Snippet,
+			InsertTextFormat: InsertTextFormatSnippet,
+		})
+	}
+	return items
+}
+
+// hover returns documentation for the attribute name or block type at offset.
+func hover(src []byte, offset int) (doc string, rng hcl.Range, ok bool) {
+	tokens, _ := hclsyntax.LexConfig(src, "", hcl.InitialPos)
+	for i, tok := range tokens {
+		if tok.Range.Start.Byte > offset {
+			break
+		}
+		if tok.Type != hclsyntax.TokenIdent || offset >= tok.Range.End.Byte {
+			continue
+		}
+		name := string(tok.Bytes)
+		sc := scopeOf(enclosingBlocks(src, tok.Range.Start.Byte))
+		if sc == nil || i+1 >= len(tokens) {
+			return "", rng, false
+		}
+		switch tokens[i+1].Type {
+		case hclsyntax.TokenEqual:
+			if attr := sc.attribute(name); attr != nil {
+				return "**" + attr.Name + "** (" + ownerName(sc) + " attribute)\n\n" + attr.Doc, tok.Range, true
+			}
+		case hclsyntax.TokenOQuote, hclsyntax.TokenIdent, hclsyntax.TokenOBrace:
+			if block := sc.Blocks[name]; block != nil {
+				return "**" + name + "** block\n\n" + block.Doc, tok.Range, true
+			}
+		}
+		return "", rng, false
+	}
+	return "", rng, false
+}
+
+func ownerName(sc *scope) string {
+	if sc.Owner == "" {
+		return "manifest"
+	}
+	return sc.Owner
+}
+
+func markdown(s string) *MarkupContent {
+	if s == "" {
+		return nil
+	}
+	return &MarkupContent{
+		Kind:  "markdown",
+		Value: s,
+	}
+}
+
+// definition finds the declaration of the component named by a `depends_on`
+// element at offset.
+func definition(src []byte, filename string, offset int) (hcl.Range, bool) {
+	f, _ := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return hcl.Range{}, false
+	}
+
+	// Index component declarations and find the referenced name.
+	declarations := make(map[string]hcl.Range)
+	var target string
+	for _, components := range body.Blocks {
+		if components.Type != "components" {
+			continue
+		}
+		for _, component := range components.Body.Blocks {
+			if len(component.Labels) == 0 {
+				continue
+			}
+			declarations[component.Labels[0]] = component.LabelRanges[0]
+
+			if attr := dependsOnAttribute(component); attr != nil && target == "" {
+				target = dependencyAt(attr, offset)
+			}
+		}
+	}
+	rng, ok := declarations[target]
+	return rng, ok
+}
+
+func dependsOnAttribute(component *hclsyntax.Block) *hclsyntax.Attribute {
+	if component.Type == "component" {
+		return component.Body.Attributes["depends_on"]
+	}
+	for _, meta := range component.Body.Blocks {
+		if meta.Type == "_" {
+			return meta.Body.Attributes["depends_on"]
+		}
+	}
+	return nil
+}
+
+func dependencyAt(attr *hclsyntax.Attribute, offset int) string {
+	tuple, ok := attr.Expr.(*hclsyntax.TupleConsExpr)
+	if !ok {
+		return ""
+	}
+	for _, elem := range tuple.Exprs {
+		rng := elem.Range()
+		if offset < rng.Start.Byte || rng.End.Byte < offset {
+			continue
+		}
+		v, diags := elem.Value(nil)
+		if diags.HasErrors() || !v.IsKnown() || v.IsNull() || !v.Type().Equals(cty.String) {
+			return ""
+		}
+		return v.AsString()
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// document is an open text document. LSP positions are in UTF-16 code units,
+// whereas HCL positions are byte offsets, so conversions go via the text.
+type document struct {
+	URI     string
+	Path    string
+	Version int
+	Text    string
+}
+
+func newDocument(uri string, version int, text string) *document {
+	return &document{
+		URI:     uri,
+		Path:    uriToPath(uri),
+		Version: version,
+		Text:    text,
+	}
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(p string) string {
+	u := url.URL{
+		Scheme: "file",
+		Path:   filepath.ToSlash(p),
+	}
+	return u.String()
+}
+
+// offset converts a position to a byte offset, clamped to the bounds of the
+// text.
+func (doc *document) offset(pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(doc.Text[offset:], '\n')
+		if i < 0 {
+			return len(doc.Text)
+		}
+		offset += i + 1
+	}
+	for units := 0; units < pos.Character && offset < len(doc.Text); {
+		r, size := utf8.DecodeRuneInString(doc.Text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+// position converts a byte offset to a position.
+func (doc *document) position(offset int) Position {
+	if offset > len(doc.Text) {
+		offset = len(doc.Text)
+	}
+	var pos Position
+	lineStart := 0
+	for i := 0; i < offset; i++ {
+		if doc.Text[i] == '\n' {
+			pos.Line++
+			lineStart = i + 1
+		}
+	}
+	for _, r := range doc.Text[lineStart:offset] {
+		pos.Character += len(utf16.Encode([]rune{r}))
+	}
+	return pos
+}
+
+func (doc *document) hclRange(rng hcl.Range) Range {
+	return Range{
+		Start: doc.position(rng.Start.Byte),
+		End:   doc.position(rng.End.Byte),
+	}
+}
+
+// applyChange updates the text of the document with an incremental or full
+// change.
+func (doc *document) applyChange(change TextDocumentContentChangeEvent) {
+	if change.Range == nil {
+		doc.Text = change.Text
+		return
+	}
+	start := doc.offset(change.Range.Start)
+	end := doc.offset(change.Range.End)
+	doc.Text = doc.Text[:start] + change.Text + doc.Text[end:]
+}
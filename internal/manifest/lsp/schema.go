@@ -0,0 +1,149 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/deref/exo/internal/providers/unix/components/process"
+)
+
+// blockSchema describes a block type that may appear in an exo.hcl manifest.
+type blockSchema struct {
+	Doc        string
+	Attributes []attributeSchema
+	// Snippet used to insert the block, in LSP snippet syntax.
+	Snippet string
+}
+
+type attributeSchema struct {
+	Name string
+	Doc  string
+}
+
+var topLevelBlocks = map[string]*blockSchema{
+	"components": {
+		Doc:     "Declares the components of the workspace.",
+		Snippet: "components {\n\t$0\n}",
+	},
+	"module": {
+		Doc:     "Includes the components of another manifest, with names prefixed by the module name.",
+		Snippet: "module \"${1:name}\" {\n\tsource = \"$2\"\n}",
+		Attributes: []attributeSchema{
+			{"source", "Path of the included manifest, or of a directory containing one, relative to this manifest."},
+			{"format", "Format of the included manifest: exo, compose or procfile. Inferred from the file name if omitted."},
+			{"variables", "Values for the variables of the included manifest."},
+		},
+	},
+	"variable": {
+		Doc:     "Declares an input variable, set with `exo apply --var NAME=VALUE` or the workspace's `.env` file.",
+		Snippet: "variable \"${1:name}\" {\n\t$0\n}",
+		Attributes: []attributeSchema{
+			{"default", "Value used when the variable is not set."},
+			{"description", "Documentation for the variable."},
+			{"type", "Type constraint of the variable's value."},
+		},
+	},
+	"locals": {
+		Doc:     "Declares local values, referenced as `local.NAME`.",
+		Snippet: "locals {\n\t$0\n}",
+	},
+}
+
+var topLevelAttributes = []attributeSchema{
+	{"exo", "Version of the manifest format."},
+}
+
+var metaBlock = &blockSchema{
+	Doc:     "Component metadata that is not part of the spec.",
+	Snippet: "_ {\n\t$0\n}",
+	Attributes: []attributeSchema{
+		{"depends_on", "Names of components that must be created before this one."},
+		{"profiles", "Profiles in which this component is active. Components without profiles are always active."},
+		{"labels", "Docker labels of the container, network or volume, added to its spec."},
+	},
+}
+
+var componentBlock = &blockSchema{
+	Doc:     "A component with an explicit type and encoded spec.",
+	Snippet: "component \"${1:name}\" {\n\ttype = \"$2\"\n\tspec = $0\n}",
+	Attributes: []attributeSchema{
+		{"type", "The component type, such as process or container."},
+		{"spec", "The component spec, encoded as a string."},
+		{"depends_on", "Names of components that must be created before this one."},
+		{"profiles", "Profiles in which this component is active. Components without profiles are always active."},
+	},
+}
+
+var componentBlocks = map[string]*blockSchema{
+	"process": {
+		Doc:        "A Unix process, supervised by exo.",
+		Snippet:    "process \"${1:name}\" {\n\tprogram = \"$2\"\n}",
+		Attributes: structAttributes(reflect.TypeOf(process.Spec{}), "json", processDocs, nil),
+	},
+	"container": {
+		Doc:        "A Docker container, specified in the same way as a Docker Compose service.",
+		Snippet:    "container \"${1:name}\" {\n\timage = \"$2\"\n}",
+		Attributes: structAttributes(reflect.TypeOf(compose.Service{}), "yaml", containerDocs, composeDoc("services")),
+	},
+	"volume": {
+		Doc:        "A Docker volume, specified in the same way as a Docker Compose volume.",
+		Snippet:    "volume \"${1:name}\" {\n\t$0\n}",
+		Attributes: structAttributes(reflect.TypeOf(compose.Volume{}), "yaml", nil, composeDoc("volumes")),
+	},
+	"network": {
+		Doc:        "A Docker network, specified in the same way as a Docker Compose network.",
+		Snippet:    "network \"${1:name}\" {\n\t$0\n}",
+		Attributes: structAttributes(reflect.TypeOf(compose.Network{}), "yaml", nil, composeDoc("networks")),
+	},
+}
+
+var processDocs = map[string]string{
+	"program":                    "Path or name of the executable. Names are resolved using the `PATH` of the process environment.",
+	"arguments":                  "Command line arguments passed to the program.",
+	"directory":                  "Working directory of the process, relative to the workspace root.",
+	"environment":                "Environment variables, in addition to those of the workspace.",
+	"shutdownGracePeriodSeconds": "Seconds to wait after sending SIGTERM before the process is killed.",
+}
+
+var containerDocs = map[string]string{
+	"image":       "Image to start the container from.",
+	"build":       "Build configuration used to create the image, either a context path or an object.",
+	"command":     "Overrides the default command of the image.",
+	"entrypoint":  "Overrides the default entrypoint of the image.",
+	"environment": "Environment variables set in the container.",
+	"ports":       "Ports to publish, as `HOST:CONTAINER` or objects.",
+	"volumes":     "Mounts of host paths or named volumes, as `SOURCE:TARGET[:MODE]` or objects.",
+	"networks":    "Networks the container is attached to.",
+	"restart":     "Restart policy: no, always, on-failure or unless-stopped.",
+	"healthcheck": "Command used to check that the container is healthy.",
+}
+
+// composeDoc returns a function documenting the attributes of a section of
+// the compose specification.
+func composeDoc(section string) func(name string) string {
+	return func(name string) string {
+		return fmt.Sprintf("The `%s` option of Docker Compose %s. See https://github.com/compose-spec/compose-spec/blob/master/spec.md", name, section)
+	}
+}
+
+// structAttributes derives attributes from the field tags of a spec type.
+func structAttributes(typ reflect.Type, tagKey string, docs map[string]string, defaultDoc func(string) string) []attributeSchema {
+	var attrs []attributeSchema
+	for i := 0; i < typ.NumField(); i++ {
+		name := strings.Split(typ.Field(i).Tag.Get(tagKey), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		doc := docs[name]
+		if doc == "" && defaultDoc != nil {
+			doc = defaultDoc(name)
+		}
+		attrs = append(attrs, attributeSchema{
+			Name: name,
+			Doc:  doc,
+		})
+	}
+	return attrs
+}
@@ -0,0 +1,300 @@
+// Package lsp implements a Language Server Protocol server for exo manifests.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/deref/exo/internal/about"
+	"github.com/deref/exo/internal/manifest"
+	"github.com/deref/exo/internal/manifest/exohcl/hclgen"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+type Server struct {
+	// Variables used when loading manifests to compute diagnostics.
+	Variables map[string]string
+
+	conn      *conn
+	documents map[string]*document
+	shutdown  bool
+}
+
+// Serve handles requests read from r, writing responses to w, until the
+// client sends an exit notification or r is closed.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+	s.documents = make(map[string]*document)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		bs, err := s.conn.read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var msg message
+		if err := json.Unmarshal(bs, &msg); err != nil {
+			if err := s.conn.reply(nil, nil, &responseError{
+				Code:    codeParseError,
+				Message: err.Error(),
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		result, err := s.handle(&msg)
+		if msg.ID == nil {
+			// Notifications have no response.
+			continue
+		}
+		if err := s.conn.reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(msg *message) (interface{}, error) {
+	if s.shutdown && msg.Method != "exit" {
+		return nil, &responseError{
+			Code:    codeInvalidRequest,
+			Message: "server is shut down",
+		}
+	}
+	switch msg.Method {
+	case "initialize":
+		return s.initialize()
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		item := params.TextDocument
+		doc := newDocument(item.URI, item.Version, item.Text)
+		s.documents[item.URI] = doc
+		return nil, s.publishDiagnostics(doc)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		for _, change := range params.ContentChanges {
+			doc.applyChange(change)
+		}
+		doc.Version = params.TextDocument.Version
+		return nil, s.publishDiagnostics(doc)
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		doc, offset, err := s.position(msg, &params)
+		if err != nil {
+			return nil, err
+		}
+		return CompletionList{
+			Items: completions([]byte(doc.Text), offset),
+		}, nil
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		doc, offset, err := s.position(msg, &params)
+		if err != nil {
+			return nil, err
+		}
+		text, rng, ok := hover([]byte(doc.Text), offset)
+		if !ok {
+			return nil, nil
+		}
+		lspRange := doc.hclRange(rng)
+		return &Hover{
+			Contents: MarkupContent{
+				Kind:  "markdown",
+				Value: text,
+			},
+			Range: &lspRange,
+		}, nil
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		doc, offset, err := s.position(msg, &params)
+		if err != nil {
+			return nil, err
+		}
+		rng, ok := definition([]byte(doc.Text), doc.Path, offset)
+		if !ok {
+			return nil, nil
+		}
+		return []Location{{
+			URI:   doc.URI,
+			Range: doc.hclRange(rng),
+		}}, nil
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if err := decodeParams(msg, &params); err != nil {
+			return nil, err
+		}
+		doc, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return formatDocument(doc), nil
+
+	default:
+		return nil, &responseError{
+			Code:    codeMethodNotFound,
+			Message: fmt.Sprintf("method not found: %q", msg.Method),
+		}
+	}
+}
+
+func decodeParams(msg *message, params interface{}) error {
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		return &responseError{
+			Code:    codeInvalidParams,
+			Message: err.Error(),
+		}
+	}
+	return nil
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	doc := s.documents[uri]
+	if doc == nil {
+		return nil, &responseError{
+			Code:    codeInvalidParams,
+			Message: fmt.Sprintf("document not open: %q", uri),
+		}
+	}
+	return doc, nil
+}
+
+func (s *Server) position(msg *message, params *TextDocumentPositionParams) (*document, int, error) {
+	if err := decodeParams(msg, params); err != nil {
+		return nil, 0, err
+	}
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, 0, err
+	}
+	return doc, doc.offset(params.Position), nil
+}
+
+func (s *Server) initialize() (*InitializeResult, error) {
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           TextDocumentSyncKindFull,
+			CompletionProvider:         &CompletionOptions{},
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: &ServerInfo{
+			Name:    "exo",
+			Version: about.Version,
+		},
+	}, nil
+}
+
+// publishDiagnostics loads the document as a manifest and sends the
+// resulting diagnostics to the client. Diagnostics in other files, such as
+// those of modules, are reported at the start of the document.
+func (s *Server) publishDiagnostics(doc *document) error {
+	loader := &manifest.Loader{
+		WorkspaceName: "unnamed",
+		Filename:      doc.Path,
+		Bytes:         []byte(doc.Text),
+		Variables:     s.Variables,
+	}
+	diagnostics := []Diagnostic{}
+	if err := loader.LoadEnvironment(filepath.Dir(doc.Path)); err != nil {
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: SeverityWarning,
+			Source:   "exo",
+			Message:  err.Error(),
+		})
+	}
+	m, err := loader.Load()
+	var diags hcl.Diagnostics
+	if m != nil {
+		diags = m.Diagnostics()
+	} else if err != nil {
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: SeverityError,
+			Source:   "exo",
+			Message:  err.Error(),
+		})
+	}
+	for _, diag := range diags {
+		diagnostics = append(diagnostics, convertDiagnostic(doc, diag))
+	}
+	return s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         doc.URI,
+		Version:     doc.Version,
+		Diagnostics: diagnostics,
+	})
+}
+
+func convertDiagnostic(doc *document, diag *hcl.Diagnostic) Diagnostic {
+	res := Diagnostic{
+		Severity: SeverityError,
+		Source:   "exo",
+		Message:  diag.Summary,
+	}
+	if diag.Severity == hcl.DiagWarning {
+		res.Severity = SeverityWarning
+	}
+	if diag.Detail != "" {
+		res.Message += ": " + diag.Detail
+	}
+	if subject := diag.Subject; subject != nil && (subject.Filename == "" || subject.Filename == doc.Path) {
+		res.Range = doc.hclRange(*subject)
+	}
+	return res
+}
+
+// formatDocument returns an edit replacing the entire document with its
+// formatted equivalent, or no edits if the document cannot be parsed.
+func formatDocument(doc *document) []TextEdit {
+	f, diags := hclsyntax.ParseConfig([]byte(doc.Text), doc.Path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return []TextEdit{}
+	}
+	formatted := string(hclgen.FormatFile(f))
+	if formatted == doc.Text {
+		return []TextEdit{}
+	}
+	return []TextEdit{{
+		Range: Range{
+			End: doc.position(len(doc.Text)),
+		},
+		NewText: formatted,
+	}}
+}
@@ -0,0 +1,187 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testManifest = `exo = "0.1"
+
+components {
+  process "api" {
+    program = "./api"
+  }
+
+  container "db" {
+    image = "postgres"
+    _ {
+      depends_on = ["api"]
+    }
+  }
+}
+`
+
+// session runs the server over a sequence of client messages and returns the
+// messages sent by the server.
+func session(t *testing.T, msgs ...interface{}) []map[string]interface{} {
+	t.Helper()
+	var in bytes.Buffer
+	for _, msg := range msgs {
+		bs, err := json.Marshal(msg)
+		require.NoError(t, err)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(bs), bs)
+	}
+	var out bytes.Buffer
+	srv := &Server{}
+	require.NoError(t, srv.Serve(context.Background(), &in, &out))
+
+	c := newConn(&out, nil)
+	var res []map[string]interface{}
+	for {
+		bs, err := c.read()
+		if err != nil {
+			break
+		}
+		var msg map[string]interface{}
+		require.NoError(t, json.Unmarshal(bs, &msg))
+		res = append(res, msg)
+	}
+	return res
+}
+
+func request(id int, method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"method":  method,
+		"params":  params,
+	}
+}
+
+func notify(method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+		"params":  params,
+	}
+}
+
+func positionParams(uri string, line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+		Position:     Position{Line: line, Character: character},
+	}
+}
+
+func responseByID(msgs []map[string]interface{}, id int) map[string]interface{} {
+	for _, msg := range msgs {
+		if msg["id"] == float64(id) {
+			return msg
+		}
+	}
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lsp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	uri := pathToURI(filepath.Join(dir, "exo.hcl"))
+
+	incomplete := strings.Replace(testManifest, `    image = "postgres"`, "    image = \"postgres\"\n    po", 1)
+	msgs := session(t,
+		request(1, "initialize", map[string]interface{}{}),
+		notify("initialized", map[string]interface{}{}),
+		notify("textDocument/didOpen", DidOpenTextDocumentParams{
+			TextDocument: TextDocumentItem{URI: uri, Version: 1, Text: testManifest},
+		}),
+		request(2, "textDocument/hover", positionParams(uri, 4, 5)),
+		request(3, "textDocument/definition", positionParams(uri, 10, 21)),
+		notify("textDocument/didChange", DidChangeTextDocumentParams{
+			TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+			ContentChanges: []TextDocumentContentChangeEvent{{Text: incomplete}},
+		}),
+		request(4, "textDocument/completion", positionParams(uri, 9, 6)),
+		request(5, "shutdown", nil),
+		notify("exit", nil),
+	)
+
+	initialized := responseByID(msgs, 1)
+	require.NotNil(t, initialized)
+	capabilities := initialized["result"].(map[string]interface{})["capabilities"].(map[string]interface{})
+	assert.Equal(t, true, capabilities["hoverProvider"])
+
+	var published []interface{}
+	for _, msg := range msgs {
+		if msg["method"] == "textDocument/publishDiagnostics" {
+			params := msg["params"].(map[string]interface{})
+			published = append(published, params["diagnostics"])
+		}
+	}
+	require.Len(t, published, 2)
+	assert.Empty(t, published[0])
+	assert.NotEmpty(t, published[1])
+
+	hover := responseByID(msgs, 2)["result"].(map[string]interface{})
+	assert.Contains(t, hover["contents"].(map[string]interface{})["value"], "**program** (process attribute)")
+
+	locations := responseByID(msgs, 3)["result"].([]interface{})
+	require.Len(t, locations, 1)
+	assert.Equal(t, map[string]interface{}{
+		"start": map[string]interface{}{"line": float64(3), "character": float64(10)},
+		"end":   map[string]interface{}{"line": float64(3), "character": float64(15)},
+	}, locations[0].(map[string]interface{})["range"])
+
+	labels := []string{}
+	for _, item := range responseByID(msgs, 4)["result"].(map[string]interface{})["items"].([]interface{}) {
+		labels = append(labels, item.(map[string]interface{})["label"].(string))
+	}
+	assert.Contains(t, labels, "image")
+	assert.Contains(t, labels, "ports")
+	assert.Contains(t, labels, "_")
+	assert.NotContains(t, labels, "program")
+
+	assert.Equal(t, map[string]interface{}{"jsonrpc": "2.0", "id": float64(5), "result": nil}, responseByID(msgs, 5))
+}
+
+func TestCompletions(t *testing.T) {
+	labels := func(src string) []string {
+		offset := strings.Index(src, "|")
+		src = src[:offset] + src[offset+1:]
+		res := []string{}
+		for _, item := range completions([]byte(src), offset) {
+			res = append(res, item.Label)
+		}
+		return res
+	}
+	assert.Equal(t, []string{"exo", "components", "locals", "module", "variable"}, labels("|"))
+	assert.Equal(t, []string{"component", "container", "network", "process", "volume"}, labels("components {\n  pro|\n}"))
+	assert.Equal(t, []string{"depends_on", "profiles", "labels"}, labels("components {\n  process \"x\" {\n    _ {\n      |\n    }\n  }\n}"))
+	assert.Empty(t, labels("components {\n  process \"x\" {\n    environment = {\n      |\n    }\n  }\n}"))
+	assert.Empty(t, labels("components {\n  process \"x\" {\n    program = |\n  }\n}"))
+}
+
+func TestFormatting(t *testing.T) {
+	doc := newDocument("file:///exo.hcl", 1, "exo    = \"0.1\"\n")
+	edits := formatDocument(doc)
+	require.Len(t, edits, 1)
+	assert.Equal(t, "exo = \"0.1\"\n", edits[0].NewText)
+	assert.Equal(t, Position{Line: 1}, edits[0].Range.End)
+}
+
+func TestDocumentPositions(t *testing.T) {
+	doc := newDocument("file:///exo.hcl", 1, "a\n\U0001F600b\nc")
+	assert.Equal(t, 4+2, doc.offset(Position{Line: 1, Character: 2}))
+	assert.Equal(t, Position{Line: 1, Character: 2}, doc.position(6))
+	assert.Equal(t, len(doc.Text), doc.offset(Position{Line: 5}))
+}
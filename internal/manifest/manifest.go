@@ -1,16 +1,24 @@
 package manifest
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/deref/exo/internal/manifest/compose"
 	"github.com/deref/exo/internal/manifest/exohcl"
 	"github.com/deref/exo/internal/manifest/procfile"
+	dockercompose "github.com/deref/exo/internal/providers/docker/compose"
 	"github.com/deref/exo/internal/util/errutil"
+	"github.com/deref/exo/internal/util/osutil"
+	"github.com/deref/exo/internal/util/pathutil"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/joho/godotenv"
 )
 
 func GuessFormat(path string) string {
@@ -30,14 +38,178 @@ func GuessFormat(path string) string {
 	}
 }
 
+type candidate struct {
+	Format   string
+	Filename string
+}
+
+var candidates = []candidate{
+	{"exo", "exo.hcl"},
+	{"compose", "compose.yaml"},
+	{"compose", "compose.yml"},
+	{"compose", "docker-compose.yaml"},
+	{"compose", "docker-compose.yml"},
+	{"procfile", "Procfile"},
+	{"procfile", "Procfile.dev"},
+}
+
+// Resolve searches dir for a manifest file of the given format, or of any
+// format if format is empty. Returns an empty path if none is found.
+// As with foreman, the procfile option of a .foreman file in dir takes
+// precedence over the default Procfile names.
+func Resolve(dir, format string) (string, error) {
+	l := &Loader{}
+	return l.resolve(dir, format)
+}
+
+// resolve is like Resolve, but looks for files with the loader's ReadFile.
+func (l *Loader) resolve(dir, format string) (string, error) {
+	for _, candidate := range candidates {
+		if format != "" && format != candidate.Format {
+			continue
+		}
+		if candidate.Format == "procfile" {
+			foremanPath, err := l.resolveForemanProcfile(dir)
+			if err != nil || foremanPath != "" {
+				return foremanPath, err
+			}
+		}
+		candidatePath := filepath.Join(dir, candidate.Filename)
+		exist, err := l.exists(candidatePath)
+		if err != nil {
+			return "", fmt.Errorf("searching for manifest: %w", err)
+		}
+		if exist {
+			return candidatePath, nil
+		}
+	}
+	return "", nil
+}
+
+// resolveForemanProcfile returns the path of the Procfile named by a .foreman
+// file in dir, if any.
+func (l *Loader) resolveForemanProcfile(dir string) (string, error) {
+	opts, err := l.readForemanOptions(dir)
+	if err != nil {
+		return "", err
+	}
+	if opts.Procfile == "" {
+		return "", nil
+	}
+	return filepath.Join(dir, opts.Procfile), nil
+}
+
+// ResolveOverride returns the path of the override file for a compose file,
+// such as docker-compose.override.yml for docker-compose.yml. Returns an
+// empty path if there is no such file.
+func ResolveOverride(filename string) (string, error) {
+	ext := filepath.Ext(filename)
+	stem := strings.TrimSuffix(filename, ext)
+	for _, ext := range []string{ext, ".yml", ".yaml"} {
+		candidatePath := stem + ".override" + ext
+		exist, err := osutil.Exists(candidatePath)
+		if err != nil {
+			return "", fmt.Errorf("searching for override file: %w", err)
+		}
+		if exist {
+			return candidatePath, nil
+		}
+	}
+	return "", nil
+}
+
+// File is the path and contents of a manifest file.
+type File struct {
+	Filename string
+	Bytes    []byte
+}
+
 type Loader struct {
 	WorkspaceName string
 	Format        string
 	Filename      string
 	Bytes         []byte
+	// Variables explicitly assigns values to variables declared by the manifest.
+	Variables map[string]string
+	// Dotenv supplies fallback values for declared variables.
+	Dotenv map[string]string
+	// Environment is exposed to the manifest via the env() function.
+	Environment map[string]string
+	// Overrides are merged in to the manifest, in order. Only supported for
+	// compose manifests. If nil, the override file next to a compose file is
+	// loaded automatically. See ResolveOverride.
+	Overrides []File
+	// RootDir bounds the files that may be included as modules or read by
+	// functions such as file(). Defaults to the directory containing Filename.
+	RootDir string
+	// Formation sets the number of instances of each process type, in the
+	// form "all=1,web=2". Only supported for Procfile manifests. Defaults to
+	// the formation option of a .foreman file next to the Procfile.
+	Formation string
+	// BasePort is the PORT of the first Procfile process. Defaults to the port
+	// option of a .foreman file, or to procfile.BasePort.
+	BasePort int
+	// ReadFile reads the files that the manifest depends on, such as modules,
+	// the files of extended compose services and files read by file(). Files
+	// that are looked for, such as the manifest of a module directory, are
+	// read too, whether or not they exist. Defaults to ioutil.ReadFile.
+	ReadFile func(path string) ([]byte, error)
+
+	// Absolute paths of the manifests being loaded, used to detect modules
+	// that include themselves.
+	loading map[string]bool
+}
+
+// LoadEnvironment populates the loader's Dotenv and Environment from the
+// .env file in dir, if any, and from the current process environment. The
+// daemon instead supplies the workspace's environment, which includes secrets
+// of its vaults.
+func (l *Loader) LoadEnvironment(dir string) error {
+	if err := l.LoadDotenv(dir); err != nil {
+		return err
+	}
+	l.Environment = make(map[string]string)
+	for _, assign := range os.Environ() {
+		parts := strings.SplitN(assign, "=", 2)
+		l.Environment[parts[0]] = parts[1]
+	}
+	for k, v := range l.Dotenv {
+		l.Environment[k] = v
+	}
+	return nil
+}
+
+// LoadDotenv populates the loader's Dotenv from the .env file in dir, if any.
+func (l *Loader) LoadDotenv(dir string) error {
+	envPath := filepath.Join(dir, ".env")
+	exists, err := osutil.Exists(envPath)
+	if err != nil {
+		return fmt.Errorf("searching for env file: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+	l.Dotenv, err = godotenv.Read(envPath)
+	if err != nil {
+		return fmt.Errorf("reading env file: %w", err)
+	}
+	return nil
 }
 
 func (l *Loader) Load() (*exohcl.Manifest, error) {
+	m, err := l.load()
+	if err != nil {
+		return nil, err
+	}
+	diags := m.Diagnostics()
+	if len(diags) > 0 {
+		// Note that this effectively treats all warnings as errors.
+		err = diags
+	}
+	return m, err
+}
+
+func (l *Loader) load() (*exohcl.Manifest, error) {
 	format := l.Format
 	if format == "" {
 		if l.Filename == "" || l.Filename == "/dev/stdin" {
@@ -55,28 +227,270 @@ func (l *Loader) Load() (*exohcl.Manifest, error) {
 	}
 	switch format {
 	case "procfile":
-		converter = &procfile.Converter{}
+		var err error
+		converter, err = l.procfileConverter()
+		if err != nil {
+			return nil, err
+		}
 	case "compose":
+		overrides, err := l.composeOverrides()
+		if err != nil {
+			return nil, err
+		}
 		converter = &compose.Converter{
 			ProjectName: l.WorkspaceName,
+			Filename:    l.Filename,
+			Overrides:   overrides,
+			ReadFile:    l.ReadFile,
 		}
 	case "exo":
 		// No converter needed.
 	default:
 		return nil, fmt.Errorf("unknown manifest format: %q", l.Format)
 	}
+	if format != "compose" && len(l.Overrides) > 0 {
+		return nil, errutil.NewHTTPError(http.StatusBadRequest, "override files are only supported for compose manifests")
+	}
+	if format != "procfile" && (l.Formation != "" || l.BasePort != 0) {
+		return nil, errutil.NewHTTPError(http.StatusBadRequest, "formation and base port are only supported for Procfile manifests")
+	}
+	opts := &exohcl.Options{
+		BaseDir:     l.baseDir(),
+		RootDir:     l.rootDir(),
+		Variables:   l.Variables,
+		Dotenv:      l.Dotenv,
+		Environment: l.Environment,
+		ReadFile:    l.ReadFile,
+		LoadModule:  l.loadModule,
+	}
 	var m *exohcl.Manifest
 	if converter == nil {
-		m = exohcl.Parse(l.Filename, l.Bytes)
+		m = exohcl.Parse(l.Filename, l.Bytes, opts)
 	} else {
 		file, diags := converter.Convert(l.Bytes)
-		m = exohcl.NewManifest(l.Filename, file, diags)
+		m = exohcl.NewManifest(l.Filename, file, diags, opts)
+		// Converted manifests are synthesized from the original source, so
+		// their diagnostics don't know which file they came from.
+		setDiagnosticsFilename(m.Diagnostics(), l.Filename)
 	}
-	var err error
-	diags := m.Diagnostics()
-	if len(diags) > 0 {
-		// Note that this effectively treats all warnings as errors.
-		err = diags
+	return m, nil
+}
+
+// procfileConverter configures a Procfile converter from the loader's options
+// and a .foreman file next to the Procfile, if any.
+func (l *Loader) procfileConverter() (*procfile.Converter, error) {
+	opts := &procfile.ForemanOptions{}
+	dir := l.baseDir()
+	if dir != "" {
+		var err error
+		opts, err = l.readForemanOptions(dir)
+		if err != nil {
+			return nil, errutil.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
+
+	formationString := l.Formation
+	if formationString == "" {
+		formationString = opts.FormationString()
+	}
+	formation, err := procfile.ParseFormation(formationString)
+	if err != nil {
+		return nil, errutil.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	basePort := l.BasePort
+	if basePort == 0 {
+		basePort = opts.Port
+	}
+
+	var environment map[string]string
+	for _, envFile := range opts.EnvFiles() {
+		// The workspace's .env file is already part of every process'
+		// environment.
+		envPath := filepath.Join(dir, envFile)
+		if filepath.Clean(envPath) == filepath.Join(dir, ".env") {
+			continue
+		}
+		bs, err := l.readFile(envPath)
+		if err != nil {
+			return nil, errutil.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("reading env file: %v", err))
+		}
+		env, err := godotenv.Parse(bytes.NewReader(bs))
+		if err != nil {
+			return nil, errutil.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("parsing env file: %v", err))
+		}
+		if environment == nil {
+			environment = make(map[string]string)
+		}
+		for k, v := range env {
+			environment[k] = v
+		}
+	}
+
+	return &procfile.Converter{
+		Formation:   formation,
+		BasePort:    basePort,
+		Environment: environment,
+	}, nil
+}
+
+func (l *Loader) composeOverrides() ([]dockercompose.File, error) {
+	files := l.Overrides
+	if files == nil && l.Filename != "" && l.Filename != "/dev/stdin" {
+		overridePath, err := ResolveOverride(l.Filename)
+		if err != nil {
+			return nil, err
+		}
+		if overridePath != "" {
+			bs, err := l.readFile(overridePath)
+			if err != nil {
+				return nil, fmt.Errorf("reading override file: %w", err)
+			}
+			files = []File{{Filename: overridePath, Bytes: bs}}
+		}
+	}
+	overrides := make([]dockercompose.File, len(files))
+	for i, file := range files {
+		overrides[i] = dockercompose.File{
+			Filename: file.Filename,
+			Bytes:    file.Bytes,
+		}
+	}
+	return overrides, nil
+}
+
+func (l *Loader) readFile(path string) ([]byte, error) {
+	if l.ReadFile != nil {
+		return l.ReadFile(path)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// exists reports whether there is a file at path. Files are read with
+// ReadFile, rather than only checked for, when it is set.
+func (l *Loader) exists(path string) (bool, error) {
+	if l.ReadFile == nil {
+		return osutil.Exists(path)
+	}
+	_, err := l.ReadFile(path)
+	if err == nil {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	// Such as a directory.
+	return osutil.Exists(path)
+}
+
+// readForemanOptions reads the .foreman file in dir, if any.
+func (l *Loader) readForemanOptions(dir string) (*procfile.ForemanOptions, error) {
+	bs, err := l.readFile(filepath.Join(dir, procfile.ForemanOptionsFilename))
+	if os.IsNotExist(err) {
+		return &procfile.ForemanOptions{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", procfile.ForemanOptionsFilename, err)
+	}
+	return procfile.ParseForemanOptions(bs)
+}
+
+func (l *Loader) baseDir() string {
+	if l.Filename == "" || l.Filename == "/dev/stdin" {
+		return l.RootDir
+	}
+	return filepath.Dir(l.Filename)
+}
+
+func (l *Loader) rootDir() string {
+	if l.RootDir != "" {
+		return l.RootDir
+	}
+	return l.baseDir()
+}
+
+// loadModule implements exohcl.ModuleLoader.
+func (l *Loader) loadModule(name string, source string, format string, variables map[string]string) (*exohcl.Manifest, error) {
+	modulePath := source
+	if !filepath.IsAbs(modulePath) {
+		modulePath = filepath.Join(l.baseDir(), modulePath)
+	}
+	modulePath, err := filepath.Abs(modulePath)
+	if err != nil {
+		return nil, err
+	}
+	if rootDir, err := filepath.Abs(l.rootDir()); err != nil {
+		return nil, err
+	} else if !pathutil.HasFilePathPrefix(modulePath, rootDir) {
+		return nil, errors.New("cannot include manifest outside of workspace root")
+	}
+
+	info, err := os.Stat(modulePath)
+	if err != nil {
+		// Look for the module with ReadFile too, so that it may be watched for.
+		_, _ = l.readFile(modulePath)
+		return nil, err
+	}
+	if info.IsDir() {
+		dir := modulePath
+		modulePath, err = l.resolve(dir, format)
+		if err != nil {
+			return nil, err
+		}
+		if modulePath == "" {
+			return nil, fmt.Errorf("could not find manifest file in %q", dir)
+		}
+	}
+
+	loading := make(map[string]bool, len(l.loading)+2)
+	for k := range l.loading {
+		loading[k] = true
+	}
+	if l.Filename != "" {
+		if self, err := filepath.Abs(l.Filename); err == nil {
+			loading[self] = true
+		}
+	}
+	if loading[modulePath] {
+		return nil, fmt.Errorf("%q includes itself", modulePath)
+	}
+	loading[modulePath] = true
+
+	bs, err := l.readFile(modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest file: %w", err)
+	}
+	sub := &Loader{
+		WorkspaceName: exohcl.MangleName(l.WorkspaceName + "-" + name),
+		Format:        format,
+		Filename:      modulePath,
+		Bytes:         bs,
+		Variables:     variables,
+		Environment:   l.Environment,
+		RootDir:       l.rootDir(),
+		ReadFile:      l.ReadFile,
+		loading:       loading,
+	}
+	return sub.load()
+}
+
+func setDiagnosticsFilename(diags hcl.Diagnostics, filename string) {
+	if filename == "" {
+		return
+	}
+	for _, diag := range diags {
+		var subject hcl.Range
+		if diag.Subject == nil {
+			subject = hcl.Range{
+				Start: hcl.InitialPos,
+				End:   hcl.InitialPos,
+			}
+		} else if diag.Subject.Filename == "" {
+			subject = *diag.Subject
+		} else {
+			continue
+		}
+		subject.Filename = filename
+		diag.Subject = &subject
 	}
-	return m, err
 }
@@ -0,0 +1,305 @@
+package manifest_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/deref/exo/internal/manifest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile := func(name string, content string) {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0700))
+		require.NoError(t, ioutil.WriteFile(p, []byte(content), 0600))
+	}
+
+	writeFile("services/api/Procfile", "web: ./server --verbose\n")
+	writeFile("services/db/exo.hcl", `
+exo = "0.1"
+
+variable "tag" {}
+
+components {
+  container "postgres" {
+    image   = "postgres:${var.tag}"
+    volumes = ["./data:/var/lib/postgresql/data", "logs:/var/log"]
+  }
+
+  process "seed" {
+    program   = "seed"
+    directory = "scripts"
+    _ {
+      depends_on = ["postgres"]
+    }
+  }
+}
+`)
+	src := `
+exo = "0.1"
+
+module "api" {
+  source = "services/api"
+}
+
+module "db" {
+  source    = "services/db/exo.hcl"
+  variables = { tag = "13" }
+}
+
+components {
+  process "proxy" {
+    program   = "proxy"
+    arguments = [components["api-web"].spec.program]
+  }
+}
+`
+	filename := filepath.Join(dir, "exo.hcl")
+	writeFile("exo.hcl", src)
+	loader := &manifest.Loader{
+		WorkspaceName: "test",
+		Filename:      filename,
+		Bytes:         []byte(src),
+	}
+	m, err := loader.Load()
+	require.NoError(t, err)
+
+	specs := map[string]string{}
+	dependencies := map[string][]string{}
+	components := m.Components()
+	for i := 0; i < components.Len(); i++ {
+		c := components.Index(i)
+		specs[c.Name()] = c.Spec()
+		dependencies[c.Name()] = c.DependsOn()
+	}
+	assert.Equal(t, map[string]string{
+		"proxy":       `{"arguments":["./server"],"program":"proxy"}`,
+		"api-web":     `{"arguments":["--verbose"],"directory":"./services/api","environment":{"PORT":"5000"},"program":"./server"}`,
+		"db-postgres": "\"image\": \"postgres:13\"\n\"volumes\":\n  - \"./services/db/data:/var/lib/postgresql/data\"\n  - \"logs:/var/log\"\n",
+		"db-seed":     `{"directory":"./services/db/scripts","program":"seed"}`,
+	}, specs)
+	assert.Equal(t, []string{"api-web"}, dependencies["proxy"])
+	assert.Equal(t, []string{"db-postgres"}, dependencies["db-seed"])
+}
+
+func TestLoadModuleErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := `
+exo = "0.1"
+
+module "self" {
+  source = "."
+}
+
+module "outside" {
+  source = ".."
+}
+`
+	filename := filepath.Join(dir, "exo.hcl")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(src), 0600))
+	loader := &manifest.Loader{
+		WorkspaceName: "test",
+		Filename:      filename,
+		Bytes:         []byte(src),
+	}
+	m, err := loader.Load()
+	require.Error(t, err)
+	details := []string{}
+	for _, diag := range m.Diagnostics() {
+		details = append(details, diag.Detail)
+	}
+	assert.Equal(t, []string{
+		fmt.Sprintf(`Could not load module "self": %q includes itself`, filename),
+		`Could not load module "outside": cannot include manifest outside of workspace root`,
+	}, details)
+}
+
+func TestLoadComposeOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := `
+services:
+  web:
+    image: nginx
+`
+	filename := filepath.Join(dir, "docker-compose.yml")
+	require.NoError(t, ioutil.WriteFile(filename, []byte(src), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "docker-compose.override.yml"), []byte(`
+services:
+  web:
+    image: nginx:alpine
+`), 0600))
+
+	load := func(overrides []manifest.File) string {
+		loader := &manifest.Loader{
+			WorkspaceName: "test",
+			Filename:      filename,
+			Bytes:         []byte(src),
+			Overrides:     overrides,
+		}
+		m, err := loader.Load()
+		require.NoError(t, err)
+		for i := 0; i < m.Components().Len(); i++ {
+			if c := m.Components().Index(i); c.Name() == "web" {
+				return c.Spec()
+			}
+		}
+		t.Fatal("web component not found")
+		return ""
+	}
+	assert.Contains(t, load(nil), `"image": "nginx:alpine"`)
+	assert.Contains(t, load([]manifest.File{{
+		Filename: filepath.Join(dir, "prod.yml"),
+		Bytes:    []byte("services:\n  web:\n    image: nginx:1.21\n"),
+	}}), `"image": "nginx:1.21"`)
+}
+
+func TestLoadComposeDeploy(t *testing.T) {
+	src := `
+services:
+  web:
+    image: nginx
+    deploy:
+      replicas: 2
+      placement:
+        constraints: [node.role == manager]
+  worker:
+    image: worker
+    scale: 0
+  db:
+    image: postgres
+    depends_on: [worker]
+  proxy:
+    image: haproxy
+    depends_on: [web]
+    ulimits:
+      nofile: 0x400
+`
+	loader := &manifest.Loader{
+		WorkspaceName: "test",
+		Format:        "compose",
+		Bytes:         []byte(src),
+	}
+	m, err := loader.Load()
+	require.Error(t, err)
+	summaries := []string{}
+	for _, diag := range m.Diagnostics() {
+		summaries = append(summaries, diag.Summary)
+	}
+	assert.Equal(t, []string{
+		"unsupported feature: deploy.placement",
+		`service "db" depends on "worker", which has no replicas, so the dependency is ignored`,
+	}, summaries)
+
+	specs := map[string]string{}
+	dependencies := map[string][]string{}
+	for i := 0; i < m.Components().Len(); i++ {
+		c := m.Components().Index(i)
+		specs[c.Name()] = c.Spec()
+		dependencies[c.Name()] = c.DependsOn()
+	}
+	assert.NotContains(t, specs, "worker")
+	assert.Contains(t, specs["web"], `"container_name": "test_web_1"`)
+	assert.Contains(t, specs["web-2"], `"container_name": "test_web_2"`)
+	assert.NotContains(t, specs["web"], "placement")
+	assert.Equal(t, []string{"default"}, dependencies["db"])
+	assert.Equal(t, []string{"default", "web", "web-2"}, dependencies["proxy"])
+	assert.Contains(t, specs["proxy"], `"nofile": 1024`)
+}
+
+func TestLoadComposeInvalidNumber(t *testing.T) {
+	loader := &manifest.Loader{
+		WorkspaceName: "test",
+		Format:        "compose",
+		Bytes:         []byte("services:\n  web:\n    image: nginx\n    ulimits:\n      nofile: .inf\n"),
+	}
+	m, err := loader.Load()
+	require.Error(t, err)
+	summaries := []string{}
+	for _, diag := range m.Diagnostics() {
+		summaries = append(summaries, diag.Summary)
+	}
+	assert.Equal(t, []string{`unsupported number: ".inf"`}, summaries)
+}
+
+func TestLoadProcfileForeman(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeFile := func(name string, content string) {
+		t.Helper()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+	src := "web: ./server\nworker: ./worker\nclock: ./clock\n"
+	writeFile("Procfile.dev", src)
+	writeFile(".foreman", "formation: all=1,web=2,clock=0\nport: 3000\nenv: .env.dev\n")
+	writeFile(".env.dev", "MODE=dev\n")
+
+	filename, err := manifest.Resolve(dir, "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "Procfile.dev"), filename)
+
+	load := func(loader *manifest.Loader) map[string]string {
+		t.Helper()
+		loader.WorkspaceName = "test"
+		loader.Filename = filename
+		loader.Bytes = []byte(src)
+		m, err := loader.Load()
+		require.NoError(t, err)
+		specs := map[string]string{}
+		for i := 0; i < m.Components().Len(); i++ {
+			c := m.Components().Index(i)
+			specs[c.Name()] = c.Spec()
+		}
+		return specs
+	}
+
+	assert.Equal(t, map[string]string{
+		"web":    `{"arguments":[],"environment":{"MODE":"dev","PORT":"3000"},"program":"./server"}`,
+		"web-2":  `{"arguments":[],"environment":{"MODE":"dev","PORT":"3001"},"program":"./server"}`,
+		"worker": `{"arguments":[],"environment":{"MODE":"dev","PORT":"3100"},"program":"./worker"}`,
+	}, load(&manifest.Loader{}))
+
+	specs := load(&manifest.Loader{Formation: "worker=2", BasePort: 8000})
+	assert.Equal(t, []string{"clock", "web", "worker", "worker-2"}, sortedKeys(specs))
+	assert.Contains(t, specs["clock"], `"PORT":"8200"`)
+	assert.Contains(t, specs["worker-2"], `"PORT":"8101"`)
+
+	// Assigned ports take precedence over env files.
+	writeFile(".env.dev", "MODE=dev\nPORT=9000\n")
+	specs = load(&manifest.Loader{Formation: "web=2"})
+	assert.Equal(t, `{"arguments":[],"environment":{"MODE":"dev","PORT":"3000"},"program":"./server"}`, specs["web"])
+	assert.Equal(t, `{"arguments":[],"environment":{"MODE":"dev","PORT":"3001"},"program":"./server"}`, specs["web-2"])
+
+	writeFile(".foreman", "procfile: Procfile.dev\n")
+	writeFile("Procfile", src)
+	filename, err = manifest.Resolve(dir, "procfile")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "Procfile.dev"), filename)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
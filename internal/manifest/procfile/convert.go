@@ -2,6 +2,7 @@ package procfile
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
 
 	"github.com/deref/exo/internal/manifest/exohcl"
@@ -13,7 +14,20 @@ import (
 const BasePort = 5000
 const PortStep = 100
 
-type Converter struct{}
+type Converter struct {
+	// Formation sets the number of instances of each process type. If nil,
+	// one instance of each is run.
+	Formation Formation
+	// BasePort is the PORT assigned to the first instance of the first process
+	// type. As in foreman, each process type is assigned ports PortStep apart,
+	// in order of appearance, and each instance of a type increments the port
+	// by one. Defaults to BasePort.
+	BasePort int
+	// Environment is merged in to the environment of every process, such as
+	// from the env files of a .foreman file. Its PORT is ignored in favor of
+	// the assigned port.
+	Environment map[string]string
+}
 
 func (c *Converter) Convert(bs []byte) (*hcl.File, hcl.Diagnostics) {
 	procfile, diags := Parse(bytes.NewBuffer(bs))
@@ -23,64 +37,80 @@ func (c *Converter) Convert(bs []byte) (*hcl.File, hcl.Diagnostics) {
 
 	b := exohcl.NewBuilder(bs)
 
-	port := BasePort
-	for _, p := range procfile.Processes {
-		// Assign default PORT, merge in specified environment.
-		environment := map[string]string{
-			"PORT": strconv.Itoa(port),
-		}
-		for name, value := range p.Environment {
-			environment[name] = value
+	basePort := c.BasePort
+	if basePort == 0 {
+		basePort = BasePort
+	}
+	for index, p := range procfile.Processes {
+		for instance := 1; instance <= c.Formation.Concurrency(p.Name); instance++ {
+			port := basePort + index*PortStep + (instance - 1)
+			b.AddComponentBlock(c.convertProcess(p, instance, port, &diags))
 		}
-		port += PortStep
+	}
+	return b.Build(), diags
+}
 
-		// Get component name.
-		name := exohcl.MangleName(p.Name)
-		if name != p.Name {
-			var subject *hcl.Range
-			diags = append(diags, exohcl.NewRenameWarning(p.Name, name, subject))
-		}
+func (c *Converter) convertProcess(p Process, instance int, port int, diags *hcl.Diagnostics) *hclgen.Block {
+	// As in foreman, the assigned PORT takes precedence over env files, so
+	// that instances don't collide, but not over the Procfile itself.
+	environment := make(map[string]string)
+	for name, value := range c.Environment {
+		environment[name] = value
+	}
+	environment["PORT"] = strconv.Itoa(port)
+	for name, value := range p.Environment {
+		environment[name] = value
+	}
 
-		// Build HCL attributes.
-		args := make([]hclsyntax.Expression, len(p.Arguments))
-		for i, arg := range p.Arguments {
-			args[i] = hclgen.NewStringLiteral(arg, p.Range)
-		}
-		attrs := []*hclsyntax.Attribute{
-			{
-				Name:     "program",
-				Expr:     hclgen.NewStringLiteral(p.Program, p.Range),
-				SrcRange: p.Range,
-			},
-			{
-				Name:     "arguments",
-				Expr:     hclgen.NewTuple(args, p.Range),
-				SrcRange: p.CommandRange,
-			},
+	// Get component name. Instances after the first are suffixed with their
+	// instance number.
+	name := exohcl.MangleName(p.Name)
+	if name != p.Name && instance == 1 {
+		var subject *hcl.Range
+		*diags = append(*diags, exohcl.NewRenameWarning(p.Name, name, subject))
+	}
+	if instance > 1 {
+		name = fmt.Sprintf("%s-%d", name, instance)
+	}
+
+	// Build HCL attributes.
+	args := make([]hclsyntax.Expression, len(p.Arguments))
+	for i, arg := range p.Arguments {
+		args[i] = hclgen.NewStringLiteral(arg, p.Range)
+	}
+	attrs := []*hclsyntax.Attribute{
+		{
+			Name:     "program",
+			Expr:     hclgen.NewStringLiteral(p.Program, p.Range),
+			SrcRange: p.Range,
+		},
+		{
+			Name:     "arguments",
+			Expr:     hclgen.NewTuple(args, p.Range),
+			SrcRange: p.CommandRange,
+		},
+	}
+	if len(environment) > 0 {
+		envExpr := &hclsyntax.ObjectConsExpr{
+			SrcRange: p.Range,
 		}
-		if len(environment) > 0 {
-			envExpr := &hclsyntax.ObjectConsExpr{
-				SrcRange: p.Range,
-			}
-			for k, v := range environment {
-				envExpr.Items = append(envExpr.Items, hclsyntax.ObjectConsItem{
-					KeyExpr:   hclgen.NewObjStringKey(k, p.Range),
-					ValueExpr: hclgen.NewStringLiteral(v, p.Range),
-				})
-			}
-			attrs = append(attrs, &hclsyntax.Attribute{
-				Name: "environment",
-				Expr: envExpr,
+		for k, v := range environment {
+			envExpr.Items = append(envExpr.Items, hclsyntax.ObjectConsItem{
+				KeyExpr:   hclgen.NewObjStringKey(k, p.Range),
+				ValueExpr: hclgen.NewStringLiteral(v, p.Range),
 			})
 		}
-
-		b.AddComponentBlock(&hclgen.Block{
-			Type:   "process",
-			Labels: []string{name},
-			Body: &hclgen.Body{
-				Attributes: attrs,
-			},
+		attrs = append(attrs, &hclsyntax.Attribute{
+			Name: "environment",
+			Expr: envExpr,
 		})
 	}
-	return b.Build(), diags
+
+	return &hclgen.Block{
+		Type:   "process",
+		Labels: []string{name},
+		Body: &hclgen.Body{
+			Attributes: attrs,
+		},
+	}
 }
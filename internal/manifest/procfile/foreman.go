@@ -0,0 +1,72 @@
+package procfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ForemanOptionsFilename is the name of the file from which foreman reads
+// defaults for its command line options.
+const ForemanOptionsFilename = ".foreman"
+
+// ForemanOptions are the supported options of a .foreman file.
+type ForemanOptions struct {
+	Formation string `yaml:"formation"`
+	// Concurrency is the name of the formation option in older versions of
+	// foreman.
+	Concurrency string `yaml:"concurrency"`
+	// Port is the base port. See Converter.BasePort.
+	Port int `yaml:"port"`
+	// Env is a comma separated list of env files, relative to the directory of
+	// the .foreman file.
+	Env string `yaml:"env"`
+	// Procfile is the path of the Procfile, relative to the directory of the
+	// .foreman file.
+	Procfile string `yaml:"procfile"`
+}
+
+// ReadForemanOptions reads the .foreman file in dir. Returns empty options if
+// there is no such file.
+func ReadForemanOptions(dir string) (*ForemanOptions, error) {
+	bs, err := ioutil.ReadFile(filepath.Join(dir, ForemanOptionsFilename))
+	if os.IsNotExist(err) {
+		return &ForemanOptions{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", ForemanOptionsFilename, err)
+	}
+	return ParseForemanOptions(bs)
+}
+
+// ParseForemanOptions parses the contents of a .foreman file.
+func ParseForemanOptions(bs []byte) (*ForemanOptions, error) {
+	var opts ForemanOptions
+	if err := yaml.Unmarshal(bs, &opts); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", ForemanOptionsFilename, err)
+	}
+	return &opts, nil
+}
+
+// FormationString returns the formation option, under either of its names.
+func (opts *ForemanOptions) FormationString() string {
+	if opts.Formation != "" {
+		return opts.Formation
+	}
+	return opts.Concurrency
+}
+
+// EnvFiles returns the env files listed by the env option.
+func (opts *ForemanOptions) EnvFiles() []string {
+	var files []string
+	for _, file := range strings.Split(opts.Env, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
@@ -0,0 +1,44 @@
+package procfile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Formation is the number of instances to run of each process type, as with
+// foreman's --formation option. The special name "all" sets the number of
+// instances of the process types that are not named explicitly.
+type Formation map[string]int
+
+// ParseFormation parses a formation of the form "all=1,web=2,worker=0".
+func ParseFormation(s string) (Formation, error) {
+	formation := make(Formation)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("expected NAME=COUNT in formation, got %q", entry)
+		}
+		count, err := strconv.Atoi(parts[1])
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid count for %q in formation: %q", parts[0], parts[1])
+		}
+		formation[parts[0]] = count
+	}
+	return formation, nil
+}
+
+// Concurrency returns the number of instances of the named process type.
+func (f Formation) Concurrency(name string) int {
+	if n, ok := f[name]; ok {
+		return n
+	}
+	if n, ok := f["all"]; ok {
+		return n
+	}
+	return 1
+}
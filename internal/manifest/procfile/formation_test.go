@@ -0,0 +1,24 @@
+package procfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFormation(t *testing.T) {
+	formation, err := ParseFormation("all=2, web=3,worker=0")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, formation.Concurrency("web"))
+	assert.Equal(t, 0, formation.Concurrency("worker"))
+	assert.Equal(t, 2, formation.Concurrency("clock"))
+
+	formation, err = ParseFormation("")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, formation.Concurrency("web"))
+
+	_, err = ParseFormation("web")
+	assert.Error(t, err)
+	_, err = ParseFormation("web=-1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package docker
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/deref/exo/internal/providers/docker/compose"
+)
+
+// Labels of Docker Compose projects.
+const (
+	ComposeProjectLabel         = "com.docker.compose.project"
+	ComposeServiceLabel         = "com.docker.compose.service"
+	ComposeContainerNumberLabel = "com.docker.compose.container-number"
+	ComposeNetworkLabel         = "com.docker.compose.network"
+	ComposeVolumeLabel          = "com.docker.compose.volume"
+	ComposeDependsOnLabel       = "com.docker.compose.depends_on"
+)
+
+// SpecLabels returns the labels of an existing Docker resource that belong in
+// a spec reconstructed from it. Labels that Docker Compose and exo add to the
+// resources they create are omitted, except for those listed in keep.
+func SpecLabels(labels map[string]string, keep ...string) compose.Dictionary {
+	kept := make(map[string]bool, len(keep))
+	for _, key := range keep {
+		kept[key] = true
+	}
+	entries := make([]string, 0, len(labels))
+	for key, value := range labels {
+		if !kept[key] && (strings.HasPrefix(key, "com.docker.compose.") || strings.HasPrefix(key, "io.deref.exo.")) {
+			continue
+		}
+		entries = append(entries, key+"="+value)
+	}
+	sort.Strings(entries)
+	return compose.MakeLiteralDictionary(entries)
+}
+
+// SpecDriverOpts returns the driver options of an existing Docker resource in
+// the form of a spec.
+func SpecDriverOpts(options map[string]string) compose.Dictionary {
+	entries := make([]string, 0, len(options))
+	for key, value := range options {
+		entries = append(entries, key+"="+value)
+	}
+	sort.Strings(entries)
+	return compose.MakeLiteralDictionary(entries)
+}
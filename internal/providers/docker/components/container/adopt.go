@@ -0,0 +1,238 @@
+package container
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/deref/exo/internal/providers/docker/components/image"
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/deref/exo/internal/util/yamlutil"
+	"github.com/docker/docker/api/types"
+)
+
+// Docker names anonymous volumes, such as those created for the VOLUME
+// instructions of an image, with random hex strings.
+var anonymousVolumeRegexp = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Adopt reconstructs the spec and state of a container component from an
+// existing Docker container and its image, so that the container can be
+// managed without being recreated. Settings that the container inherits from
+// its image are omitted from the spec.
+func Adopt(inspection types.ContainerJSON, imageInspection types.ImageInspect) (Spec, State) {
+	cfg := inspection.Config
+	hostCfg := inspection.HostConfig
+	imageCfg := imageInspection.Config
+
+	spec := Spec{
+		Image:         compose.MakeLiteralString(cfg.Image),
+		ContainerName: compose.MakeLiteralString(strings.TrimPrefix(inspection.Name, "/")),
+		Labels:        docker.SpecLabels(cfg.Labels, docker.ComposeProjectLabel, docker.ComposeServiceLabel),
+	}
+
+	var imageEnv, imageCmd, imageEntrypoint []string
+	var imageWorkingDir, imageUser string
+	imageLabels := map[string]string{}
+	if imageCfg != nil {
+		imageEnv = imageCfg.Env
+		imageCmd = imageCfg.Cmd
+		imageEntrypoint = imageCfg.Entrypoint
+		imageWorkingDir = imageCfg.WorkingDir
+		imageUser = imageCfg.User
+		imageLabels = imageCfg.Labels
+	}
+
+	if !stringsEqual(cfg.Entrypoint, imageEntrypoint) {
+		spec.Entrypoint = makeCommand(cfg.Entrypoint)
+	}
+	if !stringsEqual(cfg.Cmd, imageCmd) {
+		spec.Command = makeCommand(cfg.Cmd)
+	}
+	if cfg.WorkingDir != imageWorkingDir {
+		spec.WorkingDir = compose.MakeLiteralString(cfg.WorkingDir)
+	}
+	if cfg.User != imageUser {
+		spec.User = compose.MakeLiteralString(cfg.User)
+	}
+	// Docker defaults the hostname to the short container ID.
+	if cfg.Hostname != "" && !strings.HasPrefix(inspection.ID, cfg.Hostname) {
+		spec.Hostname = compose.MakeLiteralString(cfg.Hostname)
+	}
+	if cfg.Tty {
+		spec.TTY = compose.MakeBool(true)
+	}
+	if cfg.OpenStdin {
+		spec.StdinOpen = compose.MakeBool(true)
+	}
+
+	inherited := make(map[string]bool, len(imageEnv))
+	for _, entry := range imageEnv {
+		inherited[entry] = true
+	}
+	var env []string
+	for _, entry := range cfg.Env {
+		if !inherited[entry] {
+			env = append(env, entry)
+		}
+	}
+	spec.Environment = compose.MakeLiteralDictionary(env)
+
+	// Labels of the image are also reported as labels of the container.
+	for i := 0; i < len(spec.Labels.Items); i++ {
+		item := spec.Labels.Items[i]
+		if value, ok := imageLabels[item.Key]; ok && value == item.Value {
+			spec.Labels.Items = append(spec.Labels.Items[:i], spec.Labels.Items[i+1:]...)
+			i--
+		}
+	}
+	if len(spec.Labels.Items) == 0 {
+		spec.Labels = compose.Dictionary{}
+	}
+
+	if hostCfg != nil {
+		if policy := hostCfg.RestartPolicy.Name; policy != "" && policy != "no" {
+			spec.Restart = compose.MakeLiteralString(policy)
+		}
+		if hostCfg.Privileged {
+			spec.Privileged = compose.MakeBool(true)
+		}
+		spec.CapAdd = makeStrings(hostCfg.CapAdd)
+		spec.CapDrop = makeStrings(hostCfg.CapDrop)
+		spec.ExtraHosts = makeStrings(hostCfg.ExtraHosts)
+
+		var ports []string
+		for port, bindings := range hostCfg.PortBindings {
+			target := port.Port()
+			if port.Proto() != "tcp" {
+				target += "/" + port.Proto()
+			}
+			for _, binding := range bindings {
+				mapping := target
+				if binding.HostPort != "" {
+					mapping = binding.HostPort + ":" + mapping
+				}
+				if binding.HostIP != "" {
+					mapping = binding.HostIP + ":" + mapping
+				}
+				ports = append(ports, mapping)
+			}
+		}
+		sort.Strings(ports)
+		for _, port := range ports {
+			mapping, err := compose.ParsePortMapping(port)
+			if err != nil {
+				continue
+			}
+			spec.Ports = append(spec.Ports, compose.PortMapping{
+				IsShortForm:         true,
+				String:              compose.MakeLiteralString(port),
+				PortMappingLongForm: mapping,
+			})
+		}
+
+		switch mode := string(hostCfg.NetworkMode); {
+		case mode == "host", mode == "none", strings.HasPrefix(mode, "container:"):
+			spec.NetworkMode = compose.MakeLiteralString(mode)
+		}
+	}
+
+	for _, mount := range inspection.Mounts {
+		source := mount.Source
+		switch mount.Type {
+		case "volume":
+			if anonymousVolumeRegexp.MatchString(mount.Name) {
+				continue
+			}
+			source = mount.Name
+		case "bind", "tmpfs":
+		default:
+			continue
+		}
+		volume := compose.VolumeMount{
+			VolumeMountLongForm: compose.VolumeMountLongForm{
+				Type:   compose.MakeLiteralString(string(mount.Type)),
+				Source: compose.MakeLiteralString(source),
+				Target: compose.MakeLiteralString(mount.Destination),
+			},
+		}
+		if !mount.RW {
+			volume.ReadOnly = compose.MakeBool(true)
+		}
+		spec.Volumes = append(spec.Volumes, volume)
+	}
+
+	if spec.NetworkMode.Value == "" && inspection.NetworkSettings != nil {
+		var names []string
+		for name := range inspection.NetworkSettings.Networks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		// Containers are connected to the default bridge network unless
+		// networks are specified.
+		if len(names) == 1 && names[0] == "bridge" {
+			names = nil
+		}
+		for _, name := range names {
+			endpoint := inspection.NetworkSettings.Networks[name]
+			var aliases []string
+			for _, alias := range endpoint.Aliases {
+				// Docker aliases containers by their short ID.
+				if !strings.HasPrefix(inspection.ID, alias) {
+					aliases = append(aliases, alias)
+				}
+			}
+			spec.Networks.Items = append(spec.Networks.Items, compose.ServiceNetwork{
+				Key: name,
+				ServiceNetworkLongForm: compose.ServiceNetworkLongForm{
+					Aliases: makeStrings(aliases),
+				},
+			})
+		}
+		if len(names) > 0 {
+			spec.Networks.Style = compose.MapStyle
+		}
+	}
+
+	var state State
+	state.ContainerID = inspection.ID
+	state.Running = inspection.State != nil && inspection.State.Running
+	state.Image.Spec = yamlutil.MustMarshalString(image.Spec{
+		Platform: spec.Platform.Value,
+		Build:    spec.Build,
+	})
+	if imageCfg != nil {
+		state.Image.setInspection(imageInspection)
+	}
+	state.Image.ID = inspection.Image
+	return spec, state
+}
+
+func makeCommand(parts []string) compose.Command {
+	return compose.Command{
+		Parts: makeStrings(parts),
+	}
+}
+
+func makeStrings(values []string) compose.Strings {
+	if len(values) == 0 {
+		return nil
+	}
+	res := make(compose.Strings, len(values))
+	for i, value := range values {
+		res[i] = compose.MakeLiteralString(value)
+	}
+	return res
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
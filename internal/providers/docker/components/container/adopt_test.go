@@ -0,0 +1,105 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/deref/exo/internal/util/yamlutil"
+	"github.com/docker/docker/api/types"
+	containertypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdopt(t *testing.T) {
+	id := "3f4e5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f"
+	inspection := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    id,
+			Name:  "/myapp_web_1",
+			Image: "sha256:abc",
+			State: &types.ContainerState{
+				Running: true,
+			},
+			HostConfig: &containertypes.HostConfig{
+				RestartPolicy: containertypes.RestartPolicy{Name: "always"},
+				PortBindings: nat.PortMap{
+					"80/tcp": []nat.PortBinding{{HostPort: "8080"}},
+				},
+				NetworkMode: "myapp_default",
+			},
+		},
+		Config: &containertypes.Config{
+			Hostname: id[:12],
+			Image:    "nginx",
+			Env:      []string{"PATH=/usr/bin", "SECRET=pa$$word"},
+			Cmd:      []string{"nginx", "-g", "daemon off;"},
+			Labels: map[string]string{
+				"com.docker.compose.project":     "myapp",
+				"com.docker.compose.service":     "web",
+				"com.docker.compose.config-hash": "123",
+				"maintainer":                     "someone",
+				"tier":                           "frontend",
+			},
+		},
+		Mounts: []types.MountPoint{
+			{Type: "volume", Name: "myapp_data", Destination: "/data", RW: true},
+			{Type: "volume", Name: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", Destination: "/cache", RW: true},
+			{Type: "bind", Source: "/etc/nginx", Destination: "/etc/nginx"},
+		},
+		NetworkSettings: &types.NetworkSettings{
+			Networks: map[string]*network.EndpointSettings{
+				"myapp_default": {Aliases: []string{"web", id[:12]}},
+			},
+		},
+	}
+	imageInspection := types.ImageInspect{
+		ID: "sha256:abc",
+		Os: "linux",
+		Config: &containertypes.Config{
+			Env:    []string{"PATH=/usr/bin"},
+			Cmd:    []string{"nginx", "-g", "daemon off;"},
+			Labels: map[string]string{"maintainer": "someone"},
+		},
+	}
+
+	spec, state := Adopt(inspection, imageInspection)
+	specString := yamlutil.MustMarshalString(spec)
+	assert.Equal(t, `container_name: myapp_web_1
+environment:
+  - SECRET=pa$$$$word
+image: nginx
+labels:
+  - com.docker.compose.project=myapp
+  - com.docker.compose.service=web
+  - tier=frontend
+networks:
+  myapp_default:
+    aliases:
+      - web
+ports:
+  - 8080:80
+restart: always
+volumes:
+  - type: volume
+    source: myapp_data
+    target: /data
+  - type: bind
+    source: /etc/nginx
+    target: /etc/nginx
+    read_only: true
+`, specString)
+
+	var loaded Spec
+	if assert.NoError(t, docker.LoadSpec(specString, &loaded, nil)) {
+		assert.Equal(t, "SECRET=pa$$word", loaded.Environment.Slice()[0])
+		assert.Equal(t, "myapp_default", loaded.Networks.Items[0].Key)
+		assert.Equal(t, uint16(8080), loaded.Ports[0].Published.Min)
+	}
+
+	assert.Equal(t, id, state.ContainerID)
+	assert.True(t, state.Running)
+	assert.Equal(t, "sha256:abc", state.Image.ID)
+	assert.Equal(t, []string{"/bin/sh", "-c"}, state.Image.Shell)
+}
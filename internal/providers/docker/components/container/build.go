@@ -152,6 +152,7 @@ func (c *Container) buildImage(ctx context.Context, spec *image.Spec) error {
 			}
 
 			if event.Stream != "" {
+				task.ReportOutput(ctx, event.Stream)
 				message := strings.TrimSpace(event.Stream)
 				buildTask.ReportMessage(message)
 			}
@@ -160,6 +161,13 @@ func (c *Container) buildImage(ctx context.Context, spec *image.Spec) error {
 				c.State.Image.ID = event.Aux.ID
 			}
 		}
+		// The response body is closed early when the build is canceled.
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("reading docker build log: %w", err)
+		}
 	}
 	if err != nil {
 		return err
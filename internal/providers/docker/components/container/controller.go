@@ -16,3 +16,11 @@ func (c *Container) InitResource() error {
 func (c *Container) MarshalState() (state string, err error) {
 	return jsonutil.MarshalString(c.State)
 }
+
+func (c *Container) ExportState() (state string, err error) {
+	exported := c.State
+	exported.ContainerID = ""
+	exported.Running = false
+	exported.Image.ID = ""
+	return jsonutil.MarshalString(exported)
+}
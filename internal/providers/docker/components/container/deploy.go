@@ -0,0 +1,101 @@
+package container
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/docker/docker/api/types/container"
+)
+
+// applyDeploy maps the parts of a service's `deploy` section that apply to a
+// single local container on to its host configuration. Settings given
+// directly on the service take precedence. See NOTE [DOCKER SWARM FEATURES].
+func applyDeploy(hostCfg *container.HostConfig, spec *Spec) error {
+	deploy := spec.Deploy
+	if deploy == nil {
+		return nil
+	}
+
+	if policy := deploy.RestartPolicy; policy != nil && spec.Restart.Value == "" {
+		var err error
+		if hostCfg.RestartPolicy, err = convertRestartPolicy(*policy); err != nil {
+			return err
+		}
+	}
+
+	if limits := deploy.Resources.Limits; limits != nil {
+		if limits.CPUs.Value != "" {
+			cpus, err := strconv.ParseFloat(limits.CPUs.Value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid cpus limit: %q", limits.CPUs.Value)
+			}
+			hostCfg.NanoCPUs = int64(cpus * 1e9)
+		}
+		if hostCfg.Memory == 0 {
+			hostCfg.Memory = limits.Memory.Int64()
+		}
+		if hostCfg.PidsLimit == nil {
+			hostCfg.PidsLimit = limits.Pids.Int64Ptr()
+		}
+	}
+
+	if reservations := deploy.Resources.Reservations; reservations != nil {
+		if hostCfg.MemoryReservation == 0 {
+			hostCfg.MemoryReservation = reservations.Memory.Int64()
+		}
+		for _, device := range reservations.Devices {
+			request, err := convertDeviceRequest(device)
+			if err != nil {
+				return err
+			}
+			hostCfg.DeviceRequests = append(hostCfg.DeviceRequests, request)
+		}
+	}
+
+	return nil
+}
+
+func convertRestartPolicy(policy compose.RestartPolicy) (container.RestartPolicy, error) {
+	var res container.RestartPolicy
+	switch policy.Condition.Value {
+	case "none":
+		res.Name = "no"
+	case "on-failure":
+		res.Name = "on-failure"
+		if policy.MaxAttempts != nil {
+			res.MaximumRetryCount = policy.MaxAttempts.Int()
+		}
+	case "", "any":
+		res.Name = "always"
+	default:
+		return res, fmt.Errorf("invalid restart policy condition: %q", policy.Condition.Value)
+	}
+	return res, nil
+}
+
+func convertDeviceRequest(device compose.DeviceRequest) (container.DeviceRequest, error) {
+	res := container.DeviceRequest{
+		Driver:    device.Driver.Value,
+		DeviceIDs: device.DeviceIDs.Values(),
+		Options:   device.Options.Map(),
+	}
+	if len(device.Capabilities) > 0 {
+		res.Capabilities = [][]string{device.Capabilities.Values()}
+	}
+	switch device.Count.Value {
+	case "":
+		if len(res.DeviceIDs) == 0 {
+			res.Count = -1
+		}
+	case "all":
+		res.Count = -1
+	default:
+		count, err := strconv.Atoi(device.Count.Value)
+		if err != nil {
+			return res, fmt.Errorf("invalid device count: %q", device.Count.Value)
+		}
+		res.Count = count
+	}
+	return res, nil
+}
@@ -0,0 +1,66 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestApplyDeploy(t *testing.T) {
+	var spec Spec
+	require.NoError(t, yaml.Unmarshal([]byte(`
+mem_limit: 1g
+deploy:
+  resources:
+    limits:
+      cpus: "1.5"
+      memory: 512m
+      pids: 100
+    reservations:
+      memory: 256m
+      devices:
+        - capabilities: [gpu]
+          driver: nvidia
+  restart_policy:
+    condition: on-failure
+    max_attempts: 3
+`), &spec))
+
+	hostCfg := &container.HostConfig{}
+	hostCfg.Memory = spec.MemoryLimit.Int64()
+	require.NoError(t, applyDeploy(hostCfg, &spec))
+
+	assert.Equal(t, container.RestartPolicy{Name: "on-failure", MaximumRetryCount: 3}, hostCfg.RestartPolicy)
+	assert.Equal(t, int64(1500000000), hostCfg.NanoCPUs)
+	assert.Equal(t, int64(1024*1024*1024), hostCfg.Memory)
+	assert.Equal(t, int64(256*1024*1024), hostCfg.MemoryReservation)
+	if assert.NotNil(t, hostCfg.PidsLimit) {
+		assert.Equal(t, int64(100), *hostCfg.PidsLimit)
+	}
+	assert.Equal(t, []container.DeviceRequest{{
+		Driver:       "nvidia",
+		Count:        -1,
+		DeviceIDs:    []string{},
+		Capabilities: [][]string{{"gpu"}},
+		Options:      map[string]string{},
+	}}, hostCfg.DeviceRequests)
+}
+
+func TestApplyDeployRestartPrecedence(t *testing.T) {
+	var spec Spec
+	require.NoError(t, yaml.Unmarshal([]byte(`
+restart: unless-stopped
+deploy:
+  restart_policy:
+    condition: none
+`), &spec))
+
+	hostCfg := &container.HostConfig{
+		RestartPolicy: container.RestartPolicy{Name: spec.Restart.Value},
+	}
+	require.NoError(t, applyDeploy(hostCfg, &spec))
+	assert.Equal(t, "unless-stopped", hostCfg.RestartPolicy.Name)
+}
@@ -0,0 +1,145 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/deref/exo/internal/util/pathutil"
+	"github.com/docker/docker/api/types"
+)
+
+// containerFile is a secret or config to be copied in to a container.
+type containerFile struct {
+	Path    string
+	Content []byte
+	UID     int
+	GID     int
+	Mode    os.FileMode
+}
+
+// makeContainerFiles resolves the contents and destinations of a service's
+// secrets and configs. As in docker-compose, secrets are placed in
+// /run/secrets by default and configs at the root of the file system.
+func makeContainerFiles(workspaceRoot string, env map[string]string, spec *Spec) ([]containerFile, error) {
+	var files []containerFile
+	for _, ref := range spec.Secrets {
+		file, err := makeContainerFile(workspaceRoot, env, "/run/secrets", ref.ServiceFileReferenceLongForm)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %w", ref.Source.Value, err)
+		}
+		files = append(files, file)
+	}
+	for _, ref := range spec.Configs {
+		file, err := makeContainerFile(workspaceRoot, env, "/", ref.ServiceFileReferenceLongForm)
+		if err != nil {
+			return nil, fmt.Errorf("config %q: %w", ref.Source.Value, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+func makeContainerFile(workspaceRoot string, env map[string]string, defaultDir string, ref compose.ServiceFileReferenceLongForm) (containerFile, error) {
+	file := containerFile{
+		Path: ref.Target.Value,
+	}
+	if file.Path == "" {
+		file.Path = ref.Source.Value
+	}
+	if !path.IsAbs(file.Path) {
+		file.Path = path.Join(defaultDir, file.Path)
+	}
+
+	var err error
+	switch {
+	case ref.File.Value != "":
+		filePath := ref.File.Value
+		if !path.IsAbs(filePath) {
+			filePath = path.Join(workspaceRoot, filePath)
+		}
+		if !pathutil.HasPathPrefix(filePath, workspaceRoot) {
+			return file, fmt.Errorf("file %s is not contained within the workspace", filePath)
+		}
+		file.Content, err = ioutil.ReadFile(filePath)
+		if err != nil {
+			return file, fmt.Errorf("reading file: %w", err)
+		}
+	case ref.Environment.Value != "":
+		value, ok := env[ref.Environment.Value]
+		if !ok {
+			return file, fmt.Errorf("environment variable %q is not set", ref.Environment.Value)
+		}
+		file.Content = []byte(value)
+	case ref.Content.Expression != "":
+		file.Content = []byte(ref.Content.Value)
+	default:
+		return file, fmt.Errorf("no file, environment or content specified")
+	}
+
+	if file.UID, err = parseID(ref.UID.Value); err != nil {
+		return file, fmt.Errorf("invalid uid: %w", err)
+	}
+	if file.GID, err = parseID(ref.GID.Value); err != nil {
+		return file, fmt.Errorf("invalid gid: %w", err)
+	}
+	if file.Mode, err = ref.FileMode(0444); err != nil {
+		return file, err
+	}
+	return file, nil
+}
+
+func parseID(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// makeFilesArchive returns a tar archive containing the given files, suitable
+// for extracting at the root of a container's file system.
+func makeFilesArchive(files []containerFile) ([]byte, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	now := time.Now()
+	for _, file := range files {
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     strings.TrimPrefix(file.Path, "/"),
+			Size:     int64(len(file.Content)),
+			Mode:     int64(file.Mode.Perm()),
+			Uid:      file.UID,
+			Gid:      file.GID,
+			ModTime:  now,
+		}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(file.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// copyFiles copies secrets and configs in to the created container.
+func (c *Container) copyFiles(ctx context.Context, files []containerFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	archive, err := makeFilesArchive(files)
+	if err != nil {
+		return fmt.Errorf("archiving files: %w", err)
+	}
+	return c.Docker.CopyToContainer(ctx, c.State.ContainerID, "/", bytes.NewReader(archive), types.CopyToContainerOptions{})
+}
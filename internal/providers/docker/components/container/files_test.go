@@ -0,0 +1,95 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeContainerFiles(t *testing.T) {
+	workspaceRoot, err := ioutil.TempDir("", "container")
+	require.NoError(t, err)
+	defer os.RemoveAll(workspaceRoot)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workspaceRoot, "password.txt"), []byte("hunter2"), 0600))
+
+	env := map[string]string{
+		"API_TOKEN": "abc",
+	}
+	spec := &Spec{
+		Secrets: []compose.ServiceFileReference{
+			{
+				ServiceFileReferenceLongForm: compose.ServiceFileReferenceLongForm{
+					Source: compose.MakeString("db_password"),
+					File:   compose.MakeString("./password.txt"),
+				},
+			},
+			{
+				ServiceFileReferenceLongForm: compose.ServiceFileReferenceLongForm{
+					Source:      compose.MakeString("token"),
+					Target:      compose.MakeString("api/token"),
+					UID:         compose.MakeString("1000"),
+					GID:         compose.MakeString("1001"),
+					Mode:        compose.MakeString("0400"),
+					Environment: compose.MakeString("API_TOKEN"),
+				},
+			},
+		},
+		Configs: []compose.ServiceFileReference{
+			{
+				ServiceFileReferenceLongForm: compose.ServiceFileReferenceLongForm{
+					Source:  compose.MakeString("nginx"),
+					Target:  compose.MakeString("/etc/nginx/nginx.conf"),
+					Content: compose.MakeString("events {}"),
+				},
+			},
+		},
+	}
+	files, err := makeContainerFiles(workspaceRoot, env, spec)
+	require.NoError(t, err)
+	assert.Equal(t, []containerFile{
+		{
+			Path:    "/run/secrets/db_password",
+			Content: []byte("hunter2"),
+			Mode:    0444,
+		},
+		{
+			Path:    "/run/secrets/api/token",
+			Content: []byte("abc"),
+			UID:     1000,
+			GID:     1001,
+			Mode:    0400,
+		},
+		{
+			Path:    "/etc/nginx/nginx.conf",
+			Content: []byte("events {}"),
+			Mode:    0444,
+		},
+	}, files)
+
+	archive, err := makeFilesArchive(files)
+	require.NoError(t, err)
+	tr := tar.NewReader(bytes.NewReader(archive))
+	hdr, err := tr.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "run/secrets/db_password", hdr.Name)
+	assert.Equal(t, int64(0444), hdr.Mode)
+
+	_, err = makeContainerFiles(workspaceRoot, nil, &Spec{
+		Secrets: []compose.ServiceFileReference{
+			{
+				ServiceFileReferenceLongForm: compose.ServiceFileReferenceLongForm{
+					Source:      compose.MakeString("missing"),
+					Environment: compose.MakeString("MISSING"),
+				},
+			},
+		},
+	})
+	assert.EqualError(t, err, `secret "missing": environment variable "MISSING" is not set`)
+}
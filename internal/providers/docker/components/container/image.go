@@ -55,21 +55,26 @@ func (c *Container) ensureImage(ctx context.Context, spec *Spec) error {
 		}
 	}
 
-	c.State.Image.ID = inspection.ID
-	c.State.Image.Command = inspection.Config.Cmd
-	c.State.Image.WorkingDir = inspection.Config.WorkingDir
-	c.State.Image.Entrypoint = inspection.Config.Entrypoint
-	c.State.Image.Shell = inspection.Config.Shell
-	if len(c.State.Image.Shell) == 0 {
+	c.State.Image.setInspection(inspection)
+	return nil
+}
+
+// setInspection records the image configuration that containers inherit.
+func (img *ImageState) setInspection(inspection types.ImageInspect) {
+	img.ID = inspection.ID
+	img.Command = inspection.Config.Cmd
+	img.WorkingDir = inspection.Config.WorkingDir
+	img.Entrypoint = inspection.Config.Entrypoint
+	img.Shell = inspection.Config.Shell
+	if len(img.Shell) == 0 {
 		if inspection.Os == "linux" {
-			c.State.Image.Shell = []string{"/bin/sh", "-c"}
+			img.Shell = []string{"/bin/sh", "-c"}
 		} else {
 			// For Windows — this is untested but it is what docker does.
-			c.State.Image.Shell = []string{"cmd", "/S", "/C"}
+			img.Shell = []string{"cmd", "/S", "/C"}
 		}
 
 	}
-	return nil
 }
 
 type dockerPullStatus struct {
@@ -105,7 +110,19 @@ func (c *Container) pullImage(ctx context.Context, spec *Spec) error {
 			}
 			pullTask.ReportProgress(status.ProgressDetail.Current, status.ProgressDetail.Total)
 			pullTask.ReportMessage(status.Status)
+			// Progress updates are reported as progress, not output.
+			if status.ProgressDetail.Total == 0 {
+				line := status.Status
+				if status.ID != "" {
+					line = status.ID + ": " + line
+				}
+				task.ReportOutput(ctx, line)
+			}
 		}
 	}
+	if err == nil {
+		// The pull stream ends early when the pull is canceled.
+		err = ctx.Err()
+	}
 	return err
 }
@@ -64,6 +64,49 @@ func (c *Container) Initialize(ctx context.Context, input *core.InitializeInput)
 	return &core.InitializeOutput{}, nil
 }
 
+// Update replaces the Docker container with one created from the new spec,
+// starting it only if the old container was running. The image is reused
+// unless the image, build or platform changed. Since the component is
+// unchanged, so are its log streams.
+func (c *Container) Update(ctx context.Context, input *core.UpdateInput) (*core.UpdateOutput, error) {
+	var oldSpec, spec Spec
+	if err := c.LoadSpec(input.OldSpec, &oldSpec); err != nil {
+		return nil, fmt.Errorf("loading old spec: %w", err)
+	}
+	if err := c.LoadSpec(input.Spec, &spec); err != nil {
+		return nil, fmt.Errorf("loading spec: %w", err)
+	}
+
+	imageSpec := yamlutil.MustMarshalString(image.Spec{
+		Platform: spec.Platform.Value,
+		Build:    spec.Build,
+	})
+	if oldSpec.Image.Value != spec.Image.Value || c.State.Image.Spec != imageSpec {
+		c.State.Image = ImageState{}
+	}
+	c.State.Image.Spec = imageSpec
+	if err := c.ensureImage(ctx, &spec); err != nil {
+		return nil, fmt.Errorf("ensuring image: %w", err)
+	}
+
+	running := c.State.Running
+	if _, err := c.Dispose(ctx, &core.DisposeInput{}); err != nil {
+		return nil, fmt.Errorf("removing old container: %w", err)
+	}
+	if err := c.removeExistingContainerByName(ctx, spec.ContainerName.Value); err != nil {
+		return nil, fmt.Errorf("removing existing container %q: %w", spec.ContainerName, err)
+	}
+	if err := c.create(ctx, &spec); err != nil {
+		return nil, fmt.Errorf("creating container: %w", err)
+	}
+	if running {
+		if err := c.start(ctx); err != nil {
+			c.Logger.Infof("starting container %q: %v", c.State.ContainerID, err)
+		}
+	}
+	return &core.UpdateOutput{}, nil
+}
+
 func (c *Container) create(ctx context.Context, spec *Spec) error {
 	var healthCfg *container.HealthConfig
 	if spec.Healthcheck != nil {
@@ -202,7 +245,6 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		LogConfig: logCfg,
 		//NetworkMode     NetworkMode   // Network mode to use for the container
 		PortBindings: make(nat.PortMap),
-		// TODO: Potentially inherit from deploy's restart_policy.
 		RestartPolicy: container.RestartPolicy{
 			Name: spec.Restart.Value,
 		},
@@ -277,6 +319,10 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		Init: spec.Init.Ptr(),
 	}
 
+	if err := applyDeploy(hostCfg, spec); err != nil {
+		return err
+	}
+
 	var err error
 	if hostCfg.IpcMode, err = c.parseIPCMode(spec.IPC.Value); err != nil {
 		return err
@@ -306,6 +352,11 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		}
 	}
 
+	files, err := makeContainerFiles(c.WorkspaceRoot, c.WorkspaceEnvironment, spec)
+	if err != nil {
+		return err
+	}
+
 	// TODO: make the user home directory a parameter of the container.
 	user, err := user.Current()
 	if err != nil {
@@ -402,6 +453,9 @@ func (c *Container) create(ctx context.Context, spec *Spec) error {
 		return err
 	}
 	c.State.ContainerID = createdBody.ID
+	if err := c.copyFiles(ctx, files); err != nil {
+		return fmt.Errorf("copying secrets and configs: %w", err)
+	}
 	var netConnects errgroup.Group
 	for _, network := range remainingNetworks {
 		network := network
@@ -429,17 +483,36 @@ func (c *Container) Refresh(ctx context.Context, input *core.RefreshInput) (*cor
 		})
 	}
 
+	var output core.RefreshOutput
 	if c.State.ContainerID == "" {
 		c.State.Running = false
 	} else {
 		inspection, err := c.Docker.ContainerInspect(ctx, c.State.ContainerID)
+		if docker.IsErrNotFound(err) {
+			output.Drift = append(output.Drift, fmt.Sprintf("container %s no longer exists", shortID(c.State.ContainerID)))
+			c.State.ContainerID = ""
+			c.State.Running = false
+			return &output, nil
+		}
 		if err != nil {
 			return nil, fmt.Errorf("inspecting container: %w", err)
 		}
 
+		if c.State.Image.ID != "" && inspection.Image != c.State.Image.ID {
+			output.Drift = append(output.Drift, fmt.Sprintf("container is running image %s, expected %s", shortID(inspection.Image), shortID(c.State.Image.ID)))
+		}
 		c.State.Running = inspection.State.Running
 	}
-	return &core.RefreshOutput{}, nil
+	return &output, nil
+}
+
+// shortID abbreviates a Docker object ID as the Docker CLI does.
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	return id
 }
 
 func (c *Container) Dispose(ctx context.Context, input *core.DisposeInput) (*core.DisposeOutput, error) {
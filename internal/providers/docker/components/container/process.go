@@ -2,9 +2,11 @@ package container
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	core "github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/util/contextutil"
 	"github.com/docker/docker/api/types"
 )
 
@@ -40,7 +42,16 @@ func (c *Container) stop(ctx context.Context, timeoutSeconds *uint) error {
 		timeout = &duration
 	}
 
-	return c.Docker.ContainerStop(ctx, c.State.ContainerID, timeout)
+	err := c.Docker.ContainerStop(ctx, c.State.ContainerID, timeout)
+	if err != nil && ctx.Err() != nil {
+		// Canceling the request does not interrupt Docker's stop timeout, so
+		// skip the rest of the grace period by killing the container.
+		if err := c.Docker.ContainerKill(contextutil.WithoutCancel(ctx), c.State.ContainerID, "KILL"); err != nil {
+			return fmt.Errorf("killing canceled container stop: %w", err)
+		}
+		return ctx.Err()
+	}
+	return err
 }
 
 func (c *Container) Restart(ctx context.Context, input *core.RestartInput) (*core.RestartOutput, error) {
@@ -0,0 +1,31 @@
+package network
+
+import (
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/docker/docker/api/types"
+)
+
+// Adopt reconstructs the spec and state of a network component from an
+// existing Docker network, so that it can be managed without being recreated.
+func Adopt(inspection types.NetworkResource) (Spec, State) {
+	spec := Spec{
+		Name:       compose.MakeLiteralString(inspection.Name),
+		Driver:     compose.MakeLiteralString(inspection.Driver),
+		DriverOpts: docker.SpecDriverOpts(inspection.Options),
+		Labels:     docker.SpecLabels(inspection.Labels),
+	}
+	if inspection.Attachable {
+		spec.Attachable = compose.MakeBool(true)
+	}
+	if inspection.EnableIPv6 {
+		spec.EnableIPv6 = compose.MakeBool(true)
+	}
+	if inspection.Internal {
+		spec.Internal = compose.MakeBool(true)
+	}
+	state := State{
+		NetworkID: inspection.ID,
+	}
+	return spec, state
+}
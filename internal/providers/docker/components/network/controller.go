@@ -16,3 +16,9 @@ func (n *Network) InitResource() error {
 func (n *Network) MarshalState() (state string, err error) {
 	return jsonutil.MarshalString(n.State)
 }
+
+func (n *Network) ExportState() (state string, err error) {
+	exported := n.State
+	exported.NetworkID = ""
+	return jsonutil.MarshalString(exported)
+}
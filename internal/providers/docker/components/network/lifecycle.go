@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 
 	core "github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/providers/docker/compose"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	docker "github.com/docker/docker/client"
@@ -80,8 +82,46 @@ func (n *Network) Initialize(ctx context.Context, input *core.InitializeInput) (
 	return &core.InitializeOutput{}, nil
 }
 
+// Update leaves the Docker network alone if only its labels changed, since
+// they cannot be changed without re-creating the network. Otherwise, the
+// network is re-created.
+func (n *Network) Update(ctx context.Context, input *core.UpdateInput) (*core.UpdateOutput, error) {
+	var oldSpec, spec Spec
+	if err := n.LoadSpec(input.OldSpec, &oldSpec); err != nil {
+		return nil, fmt.Errorf("loading old spec: %w", err)
+	}
+	if err := n.LoadSpec(input.Spec, &spec); err != nil {
+		return nil, fmt.Errorf("loading spec: %w", err)
+	}
+	oldSpec.Labels, spec.Labels = compose.Dictionary{}, compose.Dictionary{}
+	if reflect.DeepEqual(oldSpec, spec) {
+		return &core.UpdateOutput{}, nil
+	}
+
+	if _, err := n.Dispose(ctx, &core.DisposeInput{}); err != nil {
+		return nil, fmt.Errorf("removing old network: %w", err)
+	}
+	if _, err := n.Initialize(ctx, &core.InitializeInput{Spec: input.Spec}); err != nil {
+		return nil, err
+	}
+	return &core.UpdateOutput{}, nil
+}
+
 func (n *Network) Refresh(ctx context.Context, input *core.RefreshInput) (*core.RefreshOutput, error) {
-	return &core.RefreshOutput{}, nil
+	var output core.RefreshOutput
+	if n.NetworkID == "" {
+		return &output, nil
+	}
+	_, err := n.Docker.NetworkInspect(ctx, n.NetworkID, types.NetworkInspectOptions{})
+	if docker.IsErrNotFound(err) {
+		output.Drift = append(output.Drift, fmt.Sprintf("network %s no longer exists", n.NetworkID))
+		n.NetworkID = ""
+		return &output, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("inspecting network: %w", err)
+	}
+	return &output, nil
 }
 
 func (n *Network) Dispose(ctx context.Context, input *core.DisposeInput) (*core.DisposeOutput, error) {
@@ -0,0 +1,23 @@
+package volume
+
+import (
+	"github.com/deref/exo/internal/providers/docker"
+	"github.com/deref/exo/internal/providers/docker/compose"
+	"github.com/docker/docker/api/types"
+)
+
+// Adopt reconstructs the spec and state of a volume component from an
+// existing Docker volume, so that it can be managed, and its data kept,
+// without being recreated.
+func Adopt(inspection types.Volume) (Spec, State) {
+	spec := Spec{
+		Name:       compose.MakeLiteralString(inspection.Name),
+		Driver:     compose.MakeLiteralString(inspection.Driver),
+		DriverOpts: docker.SpecDriverOpts(inspection.Options),
+		Labels:     docker.SpecLabels(inspection.Labels),
+	}
+	state := State{
+		VolumeName: inspection.Name,
+	}
+	return spec, state
+}
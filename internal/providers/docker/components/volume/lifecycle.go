@@ -3,8 +3,10 @@ package volume
 import (
 	"context"
 	"fmt"
+	"reflect"
 
 	core "github.com/deref/exo/internal/core/api"
+	"github.com/deref/exo/internal/providers/docker/compose"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/volume"
 	dockerclient "github.com/docker/docker/client"
@@ -45,8 +47,45 @@ func (v *Volume) Initialize(ctx context.Context, input *core.InitializeInput) (o
 	return &core.InitializeOutput{}, nil
 }
 
+// Update keeps the Docker volume, and so its data, if only its labels changed.
+// Changing the name, driver or driver options requires creating a new volume,
+// which starts out empty.
+func (v *Volume) Update(ctx context.Context, input *core.UpdateInput) (*core.UpdateOutput, error) {
+	var oldSpec, spec Spec
+	if err := v.LoadSpec(input.OldSpec, &oldSpec); err != nil {
+		return nil, fmt.Errorf("loading old spec: %w", err)
+	}
+	if err := v.LoadSpec(input.Spec, &spec); err != nil {
+		return nil, fmt.Errorf("loading spec: %w", err)
+	}
+	oldSpec.Labels, spec.Labels = compose.Dictionary{}, compose.Dictionary{}
+	if reflect.DeepEqual(oldSpec, spec) {
+		return &core.UpdateOutput{}, nil
+	}
+
+	if _, err := v.Dispose(ctx, &core.DisposeInput{}); err != nil {
+		return nil, fmt.Errorf("removing old volume: %w", err)
+	}
+	if _, err := v.Initialize(ctx, &core.InitializeInput{Spec: input.Spec}); err != nil {
+		return nil, err
+	}
+	return &core.UpdateOutput{}, nil
+}
+
 func (v *Volume) Refresh(ctx context.Context, input *core.RefreshInput) (*core.RefreshOutput, error) {
-	return &core.RefreshOutput{}, nil
+	var output core.RefreshOutput
+	if v.VolumeName == "" {
+		return &output, nil
+	}
+	existing, err := v.findExistingVolume(ctx, v.VolumeName)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting volume: %w", err)
+	}
+	if existing == nil {
+		output.Drift = append(output.Drift, fmt.Sprintf("volume %s no longer exists", v.VolumeName))
+		v.VolumeName = ""
+	}
+	return &output, nil
 }
 
 func (v *Volume) Dispose(ctx context.Context, input *core.DisposeInput) (*core.DisposeOutput, error) {
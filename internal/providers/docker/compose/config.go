@@ -3,9 +3,11 @@ package compose
 type Config struct {
 	Key string `yaml:"-"`
 
-	File     String `yaml:"file,omitempty"`
-	External Bool   `yaml:"external,omitempty"`
-	Name     String `yaml:"name,omitempty"`
+	File        String `yaml:"file,omitempty"`
+	Environment String `yaml:"environment,omitempty"`
+	External    Bool   `yaml:"external,omitempty"`
+	Name        String `yaml:"name,omitempty"`
+	Content     String `yaml:"content,omitempty"`
 }
 
 func (cfg *Config) Interpolate(env Environment) error {
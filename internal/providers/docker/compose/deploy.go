@@ -0,0 +1,76 @@
+package compose
+
+// Deploy is the deployment configuration of a service. Only replicas,
+// resources and restart_policy are meaningful outside of Swarm. See NOTE
+// [DOCKER SWARM FEATURES].
+type Deploy struct {
+	EndpointMode   String          `yaml:"endpoint_mode,omitempty"`
+	Labels         Dictionary      `yaml:"labels,omitempty"`
+	Mode           String          `yaml:"mode,omitempty"`
+	Placement      *Ignored        `yaml:"placement,omitempty"`
+	Replicas       *Int            `yaml:"replicas,omitempty"`
+	Resources      DeployResources `yaml:"resources,omitempty"`
+	RestartPolicy  *RestartPolicy  `yaml:"restart_policy,omitempty"`
+	RollbackConfig *Ignored        `yaml:"rollback_config,omitempty"`
+	UpdateConfig   *Ignored        `yaml:"update_config,omitempty"`
+}
+
+func (d *Deploy) Interpolate(env Environment) error {
+	return interpolateStruct(d, env)
+}
+
+type DeployResources struct {
+	Limits       *ResourceLimits       `yaml:"limits,omitempty"`
+	Reservations *ResourceReservations `yaml:"reservations,omitempty"`
+}
+
+func (r *DeployResources) Interpolate(env Environment) error {
+	return interpolateStruct(r, env)
+}
+
+type ResourceLimits struct {
+	// CPUs is a fractional number of CPUs, such as "0.5".
+	CPUs   String `yaml:"cpus,omitempty"`
+	Memory Bytes  `yaml:"memory,omitempty"`
+	Pids   *Int   `yaml:"pids,omitempty"`
+}
+
+func (r *ResourceLimits) Interpolate(env Environment) error {
+	return interpolateStruct(r, env)
+}
+
+type ResourceReservations struct {
+	// CPUs reservations are only enforced by Swarm.
+	CPUs    String          `yaml:"cpus,omitempty"`
+	Memory  Bytes           `yaml:"memory,omitempty"`
+	Devices []DeviceRequest `yaml:"devices,omitempty"`
+}
+
+func (r *ResourceReservations) Interpolate(env Environment) error {
+	return interpolateStruct(r, env)
+}
+
+type DeviceRequest struct {
+	Capabilities Strings    `yaml:"capabilities,omitempty"`
+	Count        String     `yaml:"count,omitempty"`
+	DeviceIDs    Strings    `yaml:"device_ids,omitempty"`
+	Driver       String     `yaml:"driver,omitempty"`
+	Options      Dictionary `yaml:"options,omitempty"`
+}
+
+func (dr *DeviceRequest) Interpolate(env Environment) error {
+	return interpolateStruct(dr, env)
+}
+
+type RestartPolicy struct {
+	// One of "none", "on-failure" or "any".
+	Condition   String `yaml:"condition,omitempty"`
+	MaxAttempts *Int   `yaml:"max_attempts,omitempty"`
+	// Delay and Window are only enforced by Swarm.
+	Delay  *Duration `yaml:"delay,omitempty"`
+	Window *Duration `yaml:"window,omitempty"`
+}
+
+func (rp *RestartPolicy) Interpolate(env Environment) error {
+	return interpolateStruct(rp, env)
+}
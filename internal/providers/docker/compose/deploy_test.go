@@ -0,0 +1,35 @@
+package compose
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestDeployYAML(t *testing.T) {
+	assertInterpolated(t, map[string]string{"replicas": "2"}, `
+replicas: ${replicas}
+resources:
+  limits:
+    cpus: "0.5"
+    pids: 100
+restart_policy:
+  condition: on-failure
+  max_attempts: 3
+`, Deploy{
+		Replicas: &Int{
+			String: MakeString("${replicas}").WithValue("2"),
+			Value:  2,
+		},
+		Resources: DeployResources{
+			Limits: &ResourceLimits{
+				CPUs: String{Tag: "!!str", Style: yaml.DoubleQuotedStyle, Expression: "0.5", Value: "0.5"},
+				Pids: NewInt(100),
+			},
+		},
+		RestartPolicy: &RestartPolicy{
+			Condition:   MakeString("on-failure"),
+			MaxAttempts: NewInt(3),
+		},
+	})
+}
@@ -156,6 +156,33 @@ func (item *DictionaryItem) Interpolate(env Environment) error {
 	return nil
 }
 
+// MakeLiteralDictionary returns a seq style Dictionary of entries of the form
+// "key=value", escaped so that they are not subject to interpolation.
+func MakeLiteralDictionary(entries []string) Dictionary {
+	if len(entries) == 0 {
+		return Dictionary{}
+	}
+	dict := Dictionary{
+		Style: SeqStyle,
+		Items: make([]DictionaryItem, len(entries)),
+	}
+	for i, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		item := DictionaryItem{
+			Style:  SeqStyle,
+			String: MakeLiteralString(entry),
+			Key:    parts[0],
+		}
+		if len(parts) > 1 {
+			item.Value = parts[1]
+		} else {
+			item.NoValue = true
+		}
+		dict.Items[i] = item
+	}
+	return dict
+}
+
 func (dict Dictionary) Slice() []string {
 	res := make([]string, len(dict.Items))
 	for i, item := range dict.Items {
@@ -0,0 +1,317 @@
+package compose
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the unparsed contents of a compose file.
+type File struct {
+	// Used for error messages and to resolve the `file` of `extends`. May be
+	// empty, in which case extended files are resolved relative to the
+	// current directory.
+	Filename string
+	Bytes    []byte
+}
+
+// Load parses one or more compose files, merging each file in to the result
+// of the previous files in the same way as `docker-compose -f a.yml -f b.yml`.
+// Services that extend other services have their `extends` resolved.
+//
+// As in docker-compose, relative paths are not rewritten when merging, so
+// are relative to the directory of the first file. Relative paths in
+// services extended from files in other directories are rewritten to be
+// relative to the extending file.
+func Load(files ...File) (*Project, error) {
+	return LoadWith(ioutil.ReadFile, files...)
+}
+
+// LoadWith is like Load, but reads the files of extended services with
+// readFile, such as to record which files a project depends on.
+func LoadWith(readFile func(path string) ([]byte, error), files ...File) (*Project, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no compose files")
+	}
+	var merged *yaml.Node
+	for _, file := range files {
+		doc, err := parseNode(file.Bytes)
+		if err != nil {
+			return nil, fileError(file.Filename, err)
+		}
+		if err := resolveExtends(doc, file.Filename, readFile); err != nil {
+			return nil, fileError(file.Filename, err)
+		}
+		if merged == nil {
+			merged = doc
+		} else {
+			merged = mergeProject(merged, doc)
+		}
+	}
+	var project Project
+	if err := merged.Decode(&project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func parseNode(bs []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(bs)).Decode(&doc); err != nil {
+		return nil, err
+	}
+	node := resolveNode(&doc)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, errors.New("expected a mapping at the top level")
+	}
+	return node, nil
+}
+
+func fileError(filename string, err error) error {
+	if filename == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", filename, err)
+}
+
+// extendsResolver resolves the `extends` of services, loading extended files
+// on demand.
+type extendsResolver struct {
+	readFile func(path string) ([]byte, error)
+	// Parsed files, keyed by absolute path.
+	files map[string]*yaml.Node
+	// Services with their extends resolved, keyed by "path#service".
+	resolved map[string]*yaml.Node
+	// Services currently being resolved, for cycle detection.
+	resolving []string
+}
+
+func resolveExtends(doc *yaml.Node, filename string, readFile func(path string) ([]byte, error)) error {
+	services := mappingValue(doc, "services")
+	if services == nil || services.Kind != yaml.MappingNode {
+		return nil
+	}
+	path := filename
+	if path != "" {
+		var err error
+		path, err = filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+	}
+	r := &extendsResolver{
+		readFile: readFile,
+		files: map[string]*yaml.Node{
+			path: doc,
+		},
+		resolved: make(map[string]*yaml.Node),
+	}
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		resolved, err := r.resolveService(path, services.Content[i].Value)
+		if err != nil {
+			return err
+		}
+		services.Content[i+1] = resolved
+	}
+	return nil
+}
+
+func (r *extendsResolver) resolveService(path string, name string) (*yaml.Node, error) {
+	key := path + "#" + name
+	if resolved, ok := r.resolved[key]; ok {
+		return resolved, nil
+	}
+	for i, resolving := range r.resolving {
+		if resolving == key {
+			var cycle []string
+			for _, k := range append(r.resolving[i:len(r.resolving):len(r.resolving)], key) {
+				cycle = append(cycle, strings.TrimPrefix(k, path+"#"))
+			}
+			return nil, fmt.Errorf("service %q extends itself: %s", name, strings.Join(cycle, " -> "))
+		}
+	}
+	r.resolving = append(r.resolving, key)
+	defer func() {
+		r.resolving = r.resolving[:len(r.resolving)-1]
+	}()
+
+	doc, err := r.loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var service *yaml.Node
+	if services := mappingValue(doc, "services"); services != nil {
+		service = mappingValue(services, name)
+	}
+	if service == nil {
+		return nil, fmt.Errorf("no such service: %q", name)
+	}
+	if service.Kind != yaml.MappingNode {
+		r.resolved[key] = service
+		return service, nil
+	}
+
+	extends := mappingValue(service, "extends")
+	if extends == nil {
+		r.resolved[key] = service
+		return service, nil
+	}
+	var baseName, basePath string
+	switch extends.Kind {
+	case yaml.ScalarNode:
+		baseName = extends.Value
+		basePath = path
+	case yaml.MappingNode:
+		if node := mappingValue(extends, "service"); node != nil {
+			baseName = node.Value
+		}
+		basePath = path
+		if node := mappingValue(extends, "file"); node != nil && node.Value != "" {
+			basePath = node.Value
+			if !filepath.IsAbs(basePath) {
+				basePath = filepath.Join(filepath.Dir(path), basePath)
+			}
+		}
+	}
+	if baseName == "" {
+		return nil, fmt.Errorf("service %q: extends requires a service name", name)
+	}
+
+	base, err := r.resolveService(basePath, baseName)
+	if err != nil {
+		return nil, fmt.Errorf("service %q: %w", name, err)
+	}
+	base = copyNode(base)
+	if dir, err := filepath.Rel(filepath.Dir(path), filepath.Dir(basePath)); err == nil && dir != "." {
+		RelocateService(base, dir)
+	}
+	// These are never inherited, since they refer to other services which may
+	// not exist in the extending file.
+	deleteMappingKeys(base, "depends_on", "links", "volumes_from", "extends")
+
+	override := copyNode(service)
+	deleteMappingKeys(override, "extends")
+	resolved := mergeService(base, override)
+	r.resolved[key] = resolved
+	return resolved, nil
+}
+
+func (r *extendsResolver) loadFile(path string) (*yaml.Node, error) {
+	if doc, ok := r.files[path]; ok {
+		return doc, nil
+	}
+	bs, err := r.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseNode(bs)
+	if err != nil {
+		return nil, fileError(path, err)
+	}
+	r.files[path] = doc
+	return doc, nil
+}
+
+func deleteMappingKeys(node *yaml.Node, keys ...string) {
+	content := node.Content[:0]
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		remove := false
+		for _, key := range keys {
+			if node.Content[i].Value == key {
+				remove = true
+				break
+			}
+		}
+		if !remove {
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+	}
+	node.Content = content
+}
+
+// RelocateService rewrites the relative paths in a service definition that
+// was declared in the directory dir, such that they are instead relative to
+// the directory that dir is itself relative to. Only build contexts, env
+// files, bind mount sources and the files of secrets and configs are
+// affected.
+func RelocateService(service *yaml.Node, dir string) {
+	service = resolveNode(service)
+	if service == nil || service.Kind != yaml.MappingNode {
+		return
+	}
+
+	if build := mappingValue(service, "build"); build != nil {
+		switch build.Kind {
+		case yaml.ScalarNode:
+			build.Value = RelocatePath(build.Value, dir)
+		case yaml.MappingNode:
+			if context := mappingValue(build, "context"); context != nil {
+				context.Value = RelocatePath(context.Value, dir)
+			}
+		}
+	}
+
+	if envFile := mappingValue(service, "env_file"); envFile != nil {
+		switch envFile.Kind {
+		case yaml.ScalarNode:
+			envFile.Value = RelocatePath(envFile.Value, dir)
+		case yaml.SequenceNode:
+			for _, item := range envFile.Content {
+				item.Value = RelocatePath(item.Value, dir)
+			}
+		}
+	}
+
+	if volumes := mappingValue(service, "volumes"); volumes != nil && volumes.Kind == yaml.SequenceNode {
+		for _, volume := range volumes.Content {
+			switch volume.Kind {
+			case yaml.ScalarNode:
+				// Short syntax: SOURCE:TARGET[:MODE]. Named volumes and absolute
+				// paths are left as-is.
+				if strings.HasPrefix(volume.Value, ".") {
+					parts := strings.SplitN(volume.Value, ":", 2)
+					parts[0] = RelocatePath(parts[0], dir)
+					volume.Value = strings.Join(parts, ":")
+				}
+			case yaml.MappingNode:
+				if source := mappingValue(volume, "source"); source != nil && strings.HasPrefix(source.Value, ".") {
+					source.Value = RelocatePath(source.Value, dir)
+				}
+			}
+		}
+	}
+
+	for _, key := range []string{"secrets", "configs"} {
+		refs := mappingValue(service, key)
+		if refs == nil || refs.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, ref := range refs.Content {
+			if ref.Kind != yaml.MappingNode {
+				continue
+			}
+			if file := mappingValue(ref, "file"); file != nil {
+				file.Value = RelocatePath(file.Value, dir)
+			}
+		}
+	}
+}
+
+// RelocatePath resolves p relative to dir. Absolute and home-relative paths
+// are returned unchanged. Results always begin with "." so that they are
+// not mistaken for named volumes.
+func RelocatePath(p string, dir string) string {
+	if p == "" || filepath.IsAbs(p) || strings.HasPrefix(p, "~") {
+		return p
+	}
+	res := filepath.ToSlash(filepath.Join(dir, p))
+	if filepath.IsAbs(res) || strings.HasPrefix(res, ".") {
+		return res
+	}
+	return "./" + res
+}
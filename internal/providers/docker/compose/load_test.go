@@ -0,0 +1,161 @@
+package compose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deref/exo/internal/util/yamlutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoadMerge(t *testing.T) {
+	base := File{
+		Filename: "docker-compose.yml",
+		Bytes: []byte(`
+services:
+  web:
+    image: web:1
+    command: serve
+    environment:
+      A: 1
+      B: 2
+    ports:
+      - "80:80"
+    volumes:
+      - ./src:/app
+      - data:/data
+    networks:
+      - front
+  db:
+    image: postgres
+volumes:
+  data: {}
+`),
+	}
+	override := File{
+		Filename: "docker-compose.override.yml",
+		Bytes: []byte(`
+services:
+  web:
+    command: debug
+    environment:
+      - B=3
+      - C
+    ports:
+      - "9229:9229"
+    volumes:
+      - ./other:/app
+    networks:
+      back:
+        aliases: [api]
+volumes:
+  data:
+    driver: local
+`),
+	}
+	project, err := Load(base, override)
+	require.NoError(t, err)
+	assertProjectYAML(t, `
+services:
+  web:
+    command: debug
+    environment:
+      - A=1
+      - B=3
+      - C
+    image: web:1
+    networks:
+      front: {}
+      back:
+        aliases:
+          - api
+    ports:
+      - "80:80"
+      - "9229:9229"
+    volumes:
+      - ./other:/app
+      - data:/data
+  db:
+    image: postgres
+volumes:
+  data:
+    driver: local
+`, project)
+}
+
+func TestLoadExtends(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compose")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "common"), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "common", "services.yml"), []byte(`
+services:
+  app:
+    build: .
+    environment:
+      LOG_LEVEL: info
+    depends_on:
+      - cache
+`), 0600))
+
+	project, err := Load(File{
+		Filename: filepath.Join(dir, "docker-compose.yml"),
+		Bytes: []byte(`
+services:
+  web:
+    extends:
+      file: common/services.yml
+      service: app
+    environment:
+      PORT: "80"
+  worker:
+    extends: web
+    command: work
+`),
+	})
+	require.NoError(t, err)
+	assertProjectYAML(t, `
+services:
+  web:
+    build: ./common
+    environment:
+      LOG_LEVEL: info
+      PORT: "80"
+  worker:
+    build: ./common
+    command: work
+    environment:
+      LOG_LEVEL: info
+      PORT: "80"
+`, project)
+
+	_, err = Load(File{
+		Bytes: []byte(`
+services:
+  a:
+    extends: b
+  b:
+    extends: a
+`),
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "a -> b -> a")
+	}
+}
+
+func assertProjectYAML(t *testing.T, expected string, project *Project) {
+	t.Helper()
+	var expectedProject Project
+	require.NoError(t, yaml.Unmarshal([]byte(strings.TrimSpace(expected)), &expectedProject))
+	expectedString, err := yamlutil.MarshalString(expectedProject)
+	require.NoError(t, err)
+	actualString, err := yamlutil.MarshalString(project)
+	require.NoError(t, err)
+	assert.Equal(t, expectedString, actualString)
+}
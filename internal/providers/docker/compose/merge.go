@@ -0,0 +1,315 @@
+package compose
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Merging follows the rules that docker-compose uses when combining multiple
+// compose files and when resolving `extends`. See
+// <https://docs.docker.com/compose/extends/#adding-and-overriding-configuration>.
+//
+// Merging operates on uninterpolated YAML nodes, so that the result can be
+// decoded exactly as if it had been read from a single file. Neither input
+// node is modified.
+
+// mergeProject merges the top-level sections of two compose files.
+func mergeProject(base, override *yaml.Node) *yaml.Node {
+	return mergeMapping(base, override, func(key string, base, override *yaml.Node) *yaml.Node {
+		switch key {
+		case "services":
+			return mergeMapping(base, override, func(_ string, base, override *yaml.Node) *yaml.Node {
+				return mergeService(base, override)
+			})
+		case "networks", "volumes", "configs", "secrets":
+			return mergeMapping(base, override, func(_ string, base, override *yaml.Node) *yaml.Node {
+				return deepMerge(base, override)
+			})
+		default:
+			return copyNode(override)
+		}
+	})
+}
+
+// mergeService merges the definitions of a single service.
+func mergeService(base, override *yaml.Node) *yaml.Node {
+	return mergeMapping(base, override, func(key string, base, override *yaml.Node) *yaml.Node {
+		switch key {
+		case "environment", "labels":
+			return mergeDictionary(base, override)
+		case "build":
+			return mergeBuild(base, override)
+		case "networks", "depends_on":
+			return mergeNamedSet(base, override)
+		case "volumes", "devices":
+			return mergeSequenceByKey(base, override, mountTarget)
+		case "secrets", "configs":
+			return mergeSequenceByKey(base, override, fileReferenceSource)
+		case "ports", "expose", "external_links", "dns", "dns_search", "tmpfs", "cap_add", "cap_drop":
+			return mergeSequenceByKey(base, override, scalarValue)
+		default:
+			return deepMerge(base, override)
+		}
+	})
+}
+
+// mergeMapping returns a mapping with the entries of both base and override.
+// Entries present in both are combined with mergeValue. If either node is not
+// a mapping, the override is used as-is.
+func mergeMapping(base, override *yaml.Node, mergeValue func(key string, base, override *yaml.Node) *yaml.Node) *yaml.Node {
+	base = resolveNode(base)
+	override = resolveNode(override)
+	if base == nil || base.Kind != yaml.MappingNode || override.Kind != yaml.MappingNode {
+		return copyNode(override)
+	}
+	res := &yaml.Node{
+		Kind:   yaml.MappingNode,
+		Tag:    override.Tag,
+		Style:  base.Style,
+		Line:   base.Line,
+		Column: base.Column,
+	}
+	overrides := mappingIndex(override)
+	merged := make(map[string]bool, len(overrides))
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		key, value := base.Content[i], base.Content[i+1]
+		if j, ok := overrides[key.Value]; ok {
+			value = mergeValue(key.Value, value, override.Content[j+1])
+			merged[key.Value] = true
+		} else {
+			value = copyNode(value)
+		}
+		res.Content = append(res.Content, copyNode(key), value)
+	}
+	for i := 0; i+1 < len(override.Content); i += 2 {
+		key, value := override.Content[i], override.Content[i+1]
+		if merged[key.Value] {
+			continue
+		}
+		res.Content = append(res.Content, copyNode(key), copyNode(value))
+	}
+	return res
+}
+
+// deepMerge recursively merges mappings. Any other override replaces the base.
+func deepMerge(base, override *yaml.Node) *yaml.Node {
+	return mergeMapping(base, override, func(_ string, base, override *yaml.Node) *yaml.Node {
+		return deepMerge(base, override)
+	})
+}
+
+// mergeDictionary merges "KEY=VALUE" sequences or mappings, such as
+// environment and labels. If the styles differ, the result is a sequence.
+func mergeDictionary(base, override *yaml.Node) *yaml.Node {
+	base = resolveNode(base)
+	override = resolveNode(override)
+	if base.Kind == yaml.MappingNode && override.Kind == yaml.MappingNode {
+		return mergeMapping(base, override, func(_ string, _, override *yaml.Node) *yaml.Node {
+			return copyNode(override)
+		})
+	}
+	return mergeSequenceByKey(dictionarySequence(base), dictionarySequence(override), func(node *yaml.Node) string {
+		return strings.SplitN(node.Value, "=", 2)[0]
+	})
+}
+
+func dictionarySequence(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return node
+	}
+	res := &yaml.Node{
+		Kind:   yaml.SequenceNode,
+		Tag:    "!!seq",
+		Line:   node.Line,
+		Column: node.Column,
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], resolveNode(node.Content[i+1])
+		item := key.Value
+		if value.Tag != "!!null" {
+			item += "=" + value.Value
+		}
+		res.Content = append(res.Content, &yaml.Node{
+			Kind:   yaml.ScalarNode,
+			Tag:    "!!str",
+			Value:  item,
+			Line:   key.Line,
+			Column: key.Column,
+		})
+	}
+	return res
+}
+
+// mergeBuild merges build configurations, either of which may be given in the
+// short form as just the context path.
+func mergeBuild(base, override *yaml.Node) *yaml.Node {
+	return mergeMapping(buildLongForm(base), buildLongForm(override), func(key string, base, override *yaml.Node) *yaml.Node {
+		if key == "args" || key == "labels" {
+			return mergeDictionary(base, override)
+		}
+		return deepMerge(base, override)
+	})
+}
+
+func buildLongForm(node *yaml.Node) *yaml.Node {
+	node = resolveNode(node)
+	if node.Kind != yaml.ScalarNode {
+		return node
+	}
+	return &yaml.Node{
+		Kind:   yaml.MappingNode,
+		Tag:    "!!map",
+		Line:   node.Line,
+		Column: node.Column,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "context"},
+			node,
+		},
+	}
+}
+
+// mergeNamedSet merges sequences of names or mappings keyed by name, such as
+// service networks and dependencies. If the styles differ, the result is a
+// mapping.
+func mergeNamedSet(base, override *yaml.Node) *yaml.Node {
+	base = resolveNode(base)
+	override = resolveNode(override)
+	if base.Kind == yaml.SequenceNode && override.Kind == yaml.SequenceNode {
+		return mergeSequenceByKey(base, override, scalarValue)
+	}
+	return mergeMapping(namedSetMapping(base), namedSetMapping(override), func(_ string, base, override *yaml.Node) *yaml.Node {
+		return deepMerge(base, override)
+	})
+}
+
+func namedSetMapping(node *yaml.Node) *yaml.Node {
+	if node.Kind != yaml.SequenceNode {
+		return node
+	}
+	res := &yaml.Node{
+		Kind:   yaml.MappingNode,
+		Tag:    "!!map",
+		Line:   node.Line,
+		Column: node.Column,
+	}
+	for _, item := range node.Content {
+		res.Content = append(res.Content, item, &yaml.Node{
+			Kind: yaml.ScalarNode,
+			Tag:  "!!null",
+		})
+	}
+	return res
+}
+
+// mergeSequenceByKey appends the items of override to those of base. Items
+// of base with the same key as an item of override are replaced in place.
+func mergeSequenceByKey(base, override *yaml.Node, key func(*yaml.Node) string) *yaml.Node {
+	base = resolveNode(base)
+	override = resolveNode(override)
+	if base.Kind != yaml.SequenceNode || override.Kind != yaml.SequenceNode {
+		return copyNode(override)
+	}
+	res := &yaml.Node{
+		Kind:   yaml.SequenceNode,
+		Tag:    override.Tag,
+		Style:  base.Style,
+		Line:   base.Line,
+		Column: base.Column,
+	}
+	index := make(map[string]int, len(base.Content))
+	for _, item := range base.Content {
+		index[key(resolveNode(item))] = len(res.Content)
+		res.Content = append(res.Content, copyNode(item))
+	}
+	for _, item := range override.Content {
+		k := key(resolveNode(item))
+		if i, ok := index[k]; ok {
+			res.Content[i] = copyNode(item)
+		} else {
+			index[k] = len(res.Content)
+			res.Content = append(res.Content, copyNode(item))
+		}
+	}
+	return res
+}
+
+func scalarValue(node *yaml.Node) string {
+	return node.Value
+}
+
+// mountTarget returns the container path of a volume or device mapping, in
+// either short or long syntax.
+func mountTarget(node *yaml.Node) string {
+	if node.Kind == yaml.MappingNode {
+		if target := mappingValue(node, "target"); target != nil {
+			return target.Value
+		}
+		return ""
+	}
+	parts := strings.Split(node.Value, ":")
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return parts[1]
+}
+
+// fileReferenceSource returns the name of the secret or config referenced in
+// either short or long syntax.
+func fileReferenceSource(node *yaml.Node) string {
+	if node.Kind == yaml.MappingNode {
+		if source := mappingValue(node, "source"); source != nil {
+			return source.Value
+		}
+		return ""
+	}
+	return node.Value
+}
+
+func mappingIndex(node *yaml.Node) map[string]int {
+	index := make(map[string]int, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		index[node.Content[i].Value] = i
+	}
+	return index
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return resolveNode(node.Content[i+1])
+		}
+	}
+	return nil
+}
+
+// resolveNode unwraps documents and aliases.
+func resolveNode(node *yaml.Node) *yaml.Node {
+	for node != nil {
+		switch {
+		case node.Kind == yaml.DocumentNode && len(node.Content) == 1:
+			node = node.Content[0]
+		case node.Kind == yaml.AliasNode:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+	return nil
+}
+
+func copyNode(node *yaml.Node) *yaml.Node {
+	node = resolveNode(node)
+	if node == nil {
+		return nil
+	}
+	res := *node
+	res.Anchor = ""
+	if node.Content != nil {
+		res.Content = make([]*yaml.Node, len(node.Content))
+		for i, child := range node.Content {
+			res.Content[i] = copyNode(child)
+		}
+	}
+	return &res
+}
@@ -119,6 +119,9 @@ func (pm *PortMapping) Interpolate(env Environment) error {
 
 func (pm PortMapping) MarshalYAML() (interface{}, error) {
 	if pm.IsShortForm {
+		if pm.String.Expression != "" {
+			return pm.String, nil
+		}
 		return pm.Target, nil
 	}
 	return pm.PortMappingLongForm, nil
@@ -0,0 +1,76 @@
+package compose
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ServiceFileReference grants a service access to a secret or config. Secrets
+// and configs share the same syntax: either just the name of a top-level
+// secret or config, or the long form.
+type ServiceFileReference struct {
+	ShortForm String
+	ServiceFileReferenceLongForm
+}
+
+type ServiceFileReferenceLongForm struct {
+	Source String `yaml:"source,omitempty"`
+	Target String `yaml:"target,omitempty"`
+	UID    String `yaml:"uid,omitempty"`
+	GID    String `yaml:"gid,omitempty"`
+	Mode   String `yaml:"mode,omitempty"`
+
+	// The following are exo extensions that specify the contents of the file
+	// directly, as an alternative to referencing a top-level secret or config.
+	// The compose converter populates these from the top-level definitions.
+	File        String `yaml:"file,omitempty"`
+	Environment String `yaml:"environment,omitempty"`
+	Content     String `yaml:"content,omitempty"`
+}
+
+func (ref *ServiceFileReference) UnmarshalYAML(node *yaml.Node) error {
+	var err error
+	if node.Tag == "!!str" {
+		err = node.Decode(&ref.ShortForm)
+	} else {
+		err = node.Decode(&ref.ServiceFileReferenceLongForm)
+	}
+	_ = ref.Interpolate(ErrEnvironment)
+	return err
+}
+
+func (ref ServiceFileReference) MarshalYAML() (interface{}, error) {
+	if ref.ShortForm.Expression != "" {
+		return ref.ShortForm.Expression, nil
+	}
+	return ref.ServiceFileReferenceLongForm, nil
+}
+
+func (ref *ServiceFileReference) Interpolate(env Environment) error {
+	if ref.ShortForm.Expression != "" {
+		err := ref.ShortForm.Interpolate(env)
+		ref.Source = ref.ShortForm
+		return err
+	}
+	return ref.ServiceFileReferenceLongForm.Interpolate(env)
+}
+
+func (ref *ServiceFileReferenceLongForm) Interpolate(env Environment) error {
+	return interpolateStruct(ref, env)
+}
+
+// FileMode parses the mode, which is conventionally given in octal. Returns
+// def if no mode is specified.
+func (ref *ServiceFileReferenceLongForm) FileMode(def os.FileMode) (os.FileMode, error) {
+	if ref.Mode.Value == "" {
+		return def, nil
+	}
+	mode, err := strconv.ParseUint(ref.Mode.Value, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mode: %q", ref.Mode.Value)
+	}
+	return os.FileMode(mode), nil
+}
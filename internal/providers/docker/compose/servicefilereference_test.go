@@ -0,0 +1,42 @@
+package compose
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestServiceFileReferenceYAML(t *testing.T) {
+	testYAML(t, "short", `db_password`, ServiceFileReference{
+		ShortForm: MakeString("db_password"),
+		ServiceFileReferenceLongForm: ServiceFileReferenceLongForm{
+			Source: MakeString("db_password"),
+		},
+	})
+	testYAML(t, "long", `
+source: db_password
+target: password
+uid: "103"
+gid: "103"
+mode: 0440
+`, ServiceFileReference{
+		ServiceFileReferenceLongForm: ServiceFileReferenceLongForm{
+			Source: MakeString("db_password"),
+			Target: MakeString("password"),
+			UID:    String{Tag: "!!str", Style: yaml.DoubleQuotedStyle, Expression: "103", Value: "103"},
+			GID:    String{Tag: "!!str", Style: yaml.DoubleQuotedStyle, Expression: "103", Value: "103"},
+			Mode:   String{Tag: "!!int", Expression: "0440", Value: "0440"},
+		},
+	})
+
+	var ref ServiceFileReferenceLongForm
+	mode, err := ref.FileMode(0444)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0444), mode)
+	ref.Mode = MakeString("0440")
+	mode, err = ref.FileMode(0444)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0440), mode)
+}
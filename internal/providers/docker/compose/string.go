@@ -2,6 +2,7 @@ package compose
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/deref/exo/internal/providers/docker/compose/template"
 	"gopkg.in/yaml.v3"
@@ -35,6 +36,16 @@ func MakeString(s string) String {
 	}
 }
 
+// MakeLiteralString returns a String whose value is s, escaping s so that it
+// is not subject to interpolation.
+func MakeLiteralString(s string) String {
+	return String{
+		Tag:        "!!str",
+		Expression: strings.ReplaceAll(s, "$", "$$"),
+		Value:      s,
+	}
+}
+
 func (s String) WithValue(v string) String {
 	s.Value = v
 	return s
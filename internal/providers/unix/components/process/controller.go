@@ -18,3 +18,9 @@ func (p *Process) InitResource() error {
 func (p *Process) MarshalState() (state string, err error) {
 	return jsonutil.MarshalString(p.State)
 }
+
+func (p *Process) ExportState() (state string, err error) {
+	exported := p.State
+	exported.reset()
+	return jsonutil.MarshalString(exported)
+}
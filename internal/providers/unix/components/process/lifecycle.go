@@ -32,6 +32,34 @@ func (p *Process) Initialize(ctx context.Context, input *core.InitializeInput) (
 	return &core.InitializeOutput{}, nil
 }
 
+// Update restarts a running process with the new spec. A stopped process is
+// left stopped. Since the component is unchanged, so are its log streams.
+func (p *Process) Update(ctx context.Context, input *core.UpdateInput) (*core.UpdateOutput, error) {
+	var spec Spec
+	if err := jsonutil.UnmarshalString(input.Spec, &spec); err != nil {
+		return nil, fmt.Errorf("unmarshalling spec: %w", err)
+	}
+
+	p.refresh()
+	running := !p.zeroPids()
+	if err := p.stop(ctx, nil); err != nil {
+		return nil, err
+	}
+
+	p.State.Directory = spec.Directory
+	p.State.Program = spec.Program
+	p.State.Arguments = spec.Arguments
+	p.State.Environment = spec.Environment
+	p.State.ShutdownGracePeriodSeconds = spec.ShutdownGracePeriodSeconds
+
+	if running {
+		if err := p.start(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &core.UpdateOutput{}, nil
+}
+
 func readLine(r io.Reader) (string, error) {
 	b := bufio.NewReaderSize(r, 4096)
 	line, isPrefix, err := b.ReadLine()
@@ -58,19 +86,26 @@ func (p *Process) Refresh(ctx context.Context, input *core.RefreshInput) (*core.
 	p.State.Environment = spec.Environment
 	p.State.ShutdownGracePeriodSeconds = spec.ShutdownGracePeriodSeconds
 
-	p.refresh()
-	return &core.RefreshOutput{}, nil
+	var output core.RefreshOutput
+	if p.refresh() {
+		output.Drift = append(output.Drift, "process is not running")
+	}
+	return &output, nil
 }
 
-func (p *Process) refresh() {
+// refresh resets the state of a process that is no longer running. Reports
+// whether the process died, rather than having been stopped.
+func (p *Process) refresh() bool {
 	if osutil.IsValidPid(p.SupervisorPid) && osutil.IsValidPid(p.Pid) {
-		return
+		return false
 	}
+	died := !p.zeroPids()
 	p.State.reset()
+	return died
 }
 
 func (p *Process) Dispose(ctx context.Context, input *core.DisposeInput) (*core.DisposeOutput, error) {
-	if err := p.stop(nil); err != nil {
+	if err := p.stop(ctx, nil); err != nil {
 		return nil, err
 	}
 	return &core.DisposeOutput{}, nil
@@ -0,0 +1,41 @@
+package process
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	core "github.com/deref/exo/internal/core/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshDrift(t *testing.T) {
+	ctx := context.Background()
+	spec := `{"program":"sleep","arguments":["60"]}`
+
+	cmd := exec.Command("sleep", "60")
+	require.NoError(t, cmd.Start())
+	pid := cmd.Process.Pid
+
+	p := &Process{}
+	p.Pgid = pid
+	p.SupervisorPid = pid
+	p.Pid = pid
+	output, err := p.Refresh(ctx, &core.RefreshInput{Spec: spec})
+	require.NoError(t, err)
+	assert.Empty(t, output.Drift)
+	assert.Equal(t, pid, p.Pid)
+
+	require.NoError(t, cmd.Process.Kill())
+	_ = cmd.Wait()
+	output, err = p.Refresh(ctx, &core.RefreshInput{Spec: spec})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"process is not running"}, output.Drift)
+	assert.Equal(t, 0, p.Pid)
+
+	// Stopped processes have no pids, and are not drift.
+	output, err = p.Refresh(ctx, &core.RefreshInput{Spec: spec})
+	require.NoError(t, err)
+	assert.Empty(t, output.Drift)
+}
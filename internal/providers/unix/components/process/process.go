@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"syscall"
 	"time"
@@ -44,10 +45,14 @@ func (p *Process) start(ctx context.Context) error {
 	whichQ := which.Query{
 		Program: p.Program,
 	}
-	whichQ.WorkingDirectory = p.Directory
-	if whichQ.WorkingDirectory == "" {
-		whichQ.WorkingDirectory = p.WorkspaceRoot
+	workingDirectory := p.WorkspaceRoot
+	if p.Directory != "" {
+		workingDirectory = p.Directory
+		if !filepath.IsAbs(workingDirectory) {
+			workingDirectory = filepath.Join(p.WorkspaceRoot, workingDirectory)
+		}
 	}
+	whichQ.WorkingDirectory = workingDirectory
 	whichQ.PathVariable = p.Environment["PATH"]
 	if whichQ.PathVariable == "" {
 		// TODO: Daemon path from config.
@@ -77,7 +82,7 @@ func (p *Process) start(ctx context.Context) error {
 	// Pipe JSON config to supervise on stdin.
 	configJSON := supervise.MustEncodeConfig(&supervise.Config{
 		ComponentID:      p.ComponentID,
-		WorkingDirectory: p.WorkspaceRoot,
+		WorkingDirectory: workingDirectory,
 		SyslogPort:       p.SyslogPort,
 		Environment:      envMap,
 		Program:          program,
@@ -144,7 +149,7 @@ func (p *Process) start(ctx context.Context) error {
 }
 
 func (p *Process) Stop(ctx context.Context, input *core.StopInput) (*core.StopOutput, error) {
-	if err := p.stop(input.TimeoutSeconds); err != nil {
+	if err := p.stop(ctx, input.TimeoutSeconds); err != nil {
 		return nil, err
 	}
 	return &core.StopOutput{}, nil
@@ -152,7 +157,7 @@ func (p *Process) Stop(ctx context.Context, input *core.StopInput) (*core.StopOu
 
 const DefaultShutdownGracePeriod = 5 * time.Second
 
-func (p *Process) stop(timeoutSeconds *uint) error {
+func (p *Process) stop(ctx context.Context, timeoutSeconds *uint) error {
 	if p.zeroPids() {
 		return nil
 	}
@@ -169,7 +174,9 @@ func (p *Process) stop(timeoutSeconds *uint) error {
 		timeout = time.Duration(*timeoutSeconds) * time.Second
 	}
 
-	if err := osutil.TerminateGroupWithTimeout(p.Pgid, timeout); err != nil {
+	// If the job is canceled, the process is killed without waiting out the
+	// rest of its grace period.
+	if err := osutil.TerminateGroupWithContext(ctx, p.Pgid, timeout); err != nil {
 		p.Logger.Infof("terminating process: %w", err)
 	}
 
@@ -178,7 +185,7 @@ func (p *Process) stop(timeoutSeconds *uint) error {
 }
 
 func (p *Process) Restart(ctx context.Context, input *core.RestartInput) (*core.RestartOutput, error) {
-	if err := p.stop(input.TimeoutSeconds); err != nil {
+	if err := p.stop(ctx, input.TimeoutSeconds); err != nil {
 		return nil, err
 	}
 	err := p.start(ctx)
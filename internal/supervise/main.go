@@ -79,7 +79,7 @@ func Main() {
 
 	// Register for signals.  Do this before starting the child to
 	// guarantee we see any exist of a child process.
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM, syscall.SIGCHLD)
 
 	// Start child process.
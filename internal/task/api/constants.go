@@ -4,3 +4,5 @@ const StatusPending = "pending"
 const StatusRunning = "running"
 const StatusSuccess = "success"
 const StatusFailure = "failure"
+const StatusSkipped = "skipped"
+const StatusCanceled = "canceled"
@@ -10,9 +10,15 @@ import (
 )
 
 type TaskStore interface {
+	// Returns matching tasks, ordered by creation time.
 	DescribeTasks(context.Context, *DescribeTasksInput) (*DescribeTasksOutput, error)
 	CreateTask(context.Context, *CreateTaskInput) (*CreateTaskOutput, error)
 	UpdateTask(context.Context, *UpdateTaskInput) (*UpdateTaskOutput, error)
+	// Appends lines to the output of a task, such as build logs or error details.
+	AddTaskOutput(context.Context, *AddTaskOutputInput) (*AddTaskOutputOutput, error)
+	// Returns lines of output written by some tasks, in the order they were written.
+	GetTaskOutput(context.Context, *GetTaskOutputInput) (*GetTaskOutputOutput, error)
+	// Removes jobs, along with all of their tasks and their output, that were last updated before a given time.
 	EvictTasks(context.Context, *EvictTasksInput) (*EvictTasksOutput, error)
 }
 
@@ -20,6 +26,18 @@ type DescribeTasksInput struct {
 
 	// If supplied, filters tasks by job.
 	JobIDs []string `json:"jobIds"`
+	// If supplied, filters tasks by workspace.
+	WorkspaceID string `json:"workspaceId"`
+	// If supplied, filters tasks by status.
+	Statuses []string `json:"statuses"`
+	// If supplied, only tasks created at or after this time are returned.
+	CreatedAfter string `json:"createdAfter"`
+	// If supplied, only tasks created before this time are returned.
+	CreatedBefore string `json:"createdBefore"`
+	// If true, only root tasks are returned.
+	JobsOnly bool `json:"jobsOnly"`
+	// If supplied, only this many of the most recently created matching tasks are returned.
+	Limit *int `json:"limit"`
 }
 
 type DescribeTasksOutput struct {
@@ -28,7 +46,9 @@ type DescribeTasksOutput struct {
 
 type CreateTaskInput struct {
 	ParentID *string `json:"parentId"`
-	Name     string  `json:"name"`
+	// Workspace that the job operates on, if any. Ignored for child tasks, which belong to the workspace of their job.
+	WorkspaceID string `json:"workspaceId"`
+	Name        string `json:"name"`
 }
 
 type CreateTaskOutput struct {
@@ -48,7 +68,32 @@ type UpdateTaskInput struct {
 type UpdateTaskOutput struct {
 }
 
+type AddTaskOutputInput struct {
+	TaskID    string   `json:"taskId"`
+	Timestamp string   `json:"timestamp"`
+	Lines     []string `json:"lines"`
+}
+
+type AddTaskOutputOutput struct {
+}
+
+type GetTaskOutputInput struct {
+	TaskIDs []string `json:"taskIds"`
+	// Output after this cursor is returned. If empty, output is returned from the start.
+	Cursor string `json:"cursor"`
+	// Maximum number of lines to return.
+	Next *int `json:"next"`
+}
+
+type GetTaskOutputOutput struct {
+	Items      []TaskOutputLine `json:"items"`
+	NextCursor string           `json:"nextCursor"`
+}
+
 type EvictTasksInput struct {
+
+	// Defaults to an hour ago.
+	Before string `json:"before"`
 }
 
 type EvictTasksOutput struct {
@@ -64,6 +109,12 @@ func BuildTaskStoreMux(b *josh.MuxBuilder, factory func(req *http.Request) TaskS
 	b.AddMethod("update-task", func(req *http.Request) interface{} {
 		return factory(req).UpdateTask
 	})
+	b.AddMethod("add-task-output", func(req *http.Request) interface{} {
+		return factory(req).AddTaskOutput
+	})
+	b.AddMethod("get-task-output", func(req *http.Request) interface{} {
+		return factory(req).GetTaskOutput
+	})
 	b.AddMethod("evict-tasks", func(req *http.Request) interface{} {
 		return factory(req).EvictTasks
 	})
@@ -72,16 +123,24 @@ func BuildTaskStoreMux(b *josh.MuxBuilder, factory func(req *http.Request) TaskS
 type TaskDescription struct {
 	ID string `json:"id"`
 	// ID of root task in this tree.
-	JobID    string        `json:"jobId"`
-	ParentID *string       `json:"parentId"`
-	Name     string        `json:"name"`
-	Status   string        `json:"status"`
-	Message  string        `json:"message"`
-	Created  string        `json:"created"`
-	Updated  string        `json:"updated"`
-	Started  *string       `json:"started"`
-	Finished *string       `json:"finished"`
-	Progress *TaskProgress `json:"progress"`
+	JobID       string        `json:"jobId"`
+	ParentID    *string       `json:"parentId"`
+	WorkspaceID string        `json:"workspaceId"`
+	Name        string        `json:"name"`
+	Status      string        `json:"status"`
+	Message     string        `json:"message"`
+	Created     string        `json:"created"`
+	Updated     string        `json:"updated"`
+	Started     *string       `json:"started"`
+	Finished    *string       `json:"finished"`
+	Progress    *TaskProgress `json:"progress"`
+}
+
+type TaskOutputLine struct {
+	ID        string `json:"id"`
+	TaskID    string `json:"taskId"`
+	Timestamp string `json:"timestamp"`
+	Message   string `json:"message"`
 }
 
 type TaskProgress struct {
@@ -1,14 +1,38 @@
 interface "task-store" {
 
   method "describe-tasks" {
+    doc = "Returns matching tasks, ordered by creation time."
+
     input "job-ids" "[]string" {
       doc = "If supplied, filters tasks by job."
     }
+    input "workspace-id" "string" {
+      doc = "If supplied, filters tasks by workspace."
+    }
+    input "statuses" "[]string" {
+      doc = "If supplied, filters tasks by status."
+    }
+    input "created-after" "string" {
+      doc = "If supplied, only tasks created at or after this time are returned."
+    }
+    input "created-before" "string" {
+      doc = "If supplied, only tasks created before this time are returned."
+    }
+    input "jobs-only" "bool" {
+      doc = "If true, only root tasks are returned."
+    }
+    input "limit" "*int" {
+      doc = "If supplied, only this many of the most recently created matching tasks are returned."
+    }
+
     output "tasks" "[]TaskDescription" {}
   }
 
   method "create-task" {
     input "parent-id" "*string" {}
+    input "workspace-id" "string" {
+      doc = "Workspace that the job operates on, if any. Ignored for child tasks, which belong to the workspace of their job."
+    }
     input "name" "string" {}
 
     output "id" "string" {}
@@ -24,7 +48,36 @@ interface "task-store" {
     input "progress" "*TaskProgress" {}
   }
 
-  method "evict-tasks" {}
+  method "add-task-output" {
+    doc = "Appends lines to the output of a task, such as build logs or error details."
+
+    input "task-id" "string" {}
+    input "timestamp" "string" {}
+    input "lines" "[]string" {}
+  }
+
+  method "get-task-output" {
+    doc = "Returns lines of output written by some tasks, in the order they were written."
+
+    input "task-ids" "[]string" {}
+    input "cursor" "string" {
+      doc = "Output after this cursor is returned. If empty, output is returned from the start."
+    }
+    input "next" "*int" {
+      doc = "Maximum number of lines to return."
+    }
+
+    output "items" "[]TaskOutputLine" {}
+    output "next-cursor" "string" {}
+  }
+
+  method "evict-tasks" {
+    doc = "Removes jobs, along with all of their tasks and their output, that were last updated before a given time."
+
+    input "before" "string" {
+      doc = "Defaults to an hour ago."
+    }
+  }
 
 }
 
@@ -34,6 +87,7 @@ struct "task-description" {
     doc = "ID of root task in this tree."
   }
   field "parent-id" "*string" {}
+  field "workspace-id" "string" {}
   field "name" "string" {}
   field "status" "string" {}
   field "message" "string" {}
@@ -44,6 +98,13 @@ struct "task-description" {
   field "progress" "*TaskProgress" {}
 }
 
+struct "task-output-line" {
+  field "id" "string" {}
+  field "task-id" "string" {}
+  field "timestamp" "string" {}
+  field "message" "string" {}
+}
+
 struct "task-progress" {
   field "current" "int" {}
   field "total" "int" {}
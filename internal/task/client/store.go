@@ -36,6 +36,16 @@ func (c *TaskStore) UpdateTask(ctx context.Context, input *api.UpdateTaskInput)
 	return
 }
 
+func (c *TaskStore) AddTaskOutput(ctx context.Context, input *api.AddTaskOutputInput) (output *api.AddTaskOutputOutput, err error) {
+	err = c.client.Invoke(ctx, "add-task-output", input, &output)
+	return
+}
+
+func (c *TaskStore) GetTaskOutput(ctx context.Context, input *api.GetTaskOutputInput) (output *api.GetTaskOutputOutput, err error) {
+	err = c.client.Invoke(ctx, "get-task-output", input, &output)
+	return
+}
+
 func (c *TaskStore) EvictTasks(ctx context.Context, input *api.EvictTasksInput) (output *api.EvictTasksOutput, err error) {
 	err = c.client.Invoke(ctx, "evict-tasks", input, &output)
 	return
@@ -14,3 +14,10 @@ func CurrentTask(ctx context.Context) *Task {
 	task, _ := ctx.Value(taskKey).(*Task)
 	return task
 }
+
+// ReportOutput appends lines of output to the current task, if there is one.
+func ReportOutput(ctx context.Context, output string) {
+	if task := CurrentTask(ctx); task != nil {
+		task.ReportOutput(output)
+	}
+}
@@ -3,6 +3,8 @@ package task
 import (
 	"context"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/deref/exo/internal/chrono"
 	"github.com/deref/exo/internal/task/api"
@@ -14,18 +16,25 @@ import (
 type TaskTracker struct {
 	Store  api.TaskStore
 	Logger logging.Logger
+
+	mx sync.Mutex
+	// Root tasks of unfinished jobs, by job ID.
+	jobs map[string]*Task
 }
 
 type Task struct {
 	context.Context
 	cancel       func()
 	tt           *TaskTracker
+	root         *Task
 	jobID        string
 	id           string
 	status       string
 	eg           errgroup.Group
 	err          error
 	reportingErr error
+	// Set on root tasks by CancelJob.
+	canceled int32
 }
 
 // ID of this task.  Might be empty string if task tracking failed.
@@ -42,13 +51,14 @@ func (t *Task) JobID() string {
 // StartTask method for convenience.
 func (tt *TaskTracker) CreateTask(ctx context.Context, name string) *Task {
 	var parent *Task
-	return tt.createTask(ctx, parent, name)
+	return tt.createTask(ctx, parent, "", name)
 }
 
-func (tt *TaskTracker) createTask(ctx context.Context, parent *Task, name string) *Task {
+func (tt *TaskTracker) createTask(ctx context.Context, parent *Task, workspaceID string, name string) *Task {
 	ctx, cancel := context.WithCancel(ctx)
 	input := &api.CreateTaskInput{
-		Name: name,
+		WorkspaceID: workspaceID,
+		Name:        name,
 	}
 	if parent != nil {
 		input.ParentID = &parent.id
@@ -58,25 +68,74 @@ func (tt *TaskTracker) createTask(ctx context.Context, parent *Task, name string
 		tt:     tt,
 		status: api.StatusPending,
 	}
+	if parent == nil {
+		t.root = t
+	} else {
+		t.root = parent.root
+	}
 	t.Context = ContextWithTask(ctx, t)
-	output, err := tt.Store.CreateTask(ctx, input)
+	// Record the task even if its job has been canceled.
+	output, err := tt.Store.CreateTask(contextutil.WithoutCancel(ctx), input)
 	if err != nil {
 		t.reportingErr = err
 	} else {
 		t.id = output.ID
 		t.jobID = output.JobID
 	}
+	if parent == nil && t.jobID != "" {
+		tt.mx.Lock()
+		if tt.jobs == nil {
+			tt.jobs = make(map[string]*Task)
+		}
+		tt.jobs[t.jobID] = t
+		tt.mx.Unlock()
+	}
 	return t
 }
 
+// CancelJob cancels the context of an unfinished job, and so of all of its
+// tasks. Tasks that finish after being canceled are reported with the
+// canceled status. Returns false if there is no such unfinished job.
+func (tt *TaskTracker) CancelJob(jobID string) bool {
+	tt.mx.Lock()
+	root := tt.jobs[jobID]
+	tt.mx.Unlock()
+	if root == nil {
+		return false
+	}
+	atomic.StoreInt32(&root.canceled, 1)
+	root.cancel()
+	return true
+}
+
+func (tt *TaskTracker) forgetJob(t *Task) {
+	if t.root != t || t.jobID == "" {
+		return
+	}
+	tt.mx.Lock()
+	delete(tt.jobs, t.jobID)
+	tt.mx.Unlock()
+}
+
+// Canceled reports whether this task's job was canceled with CancelJob.
+func (t *Task) Canceled() bool {
+	return atomic.LoadInt32(&t.root.canceled) == 1
+}
+
 // Creates and starts a task. See `Task.Start()` for usage instructions.
 func (tt *TaskTracker) StartTask(ctx context.Context, name string) *Task {
+	return tt.StartWorkspaceTask(ctx, "", name)
+}
+
+// StartWorkspaceTask is like StartTask, but records that the task, and so its
+// subtasks, operate on the given workspace.
+func (tt *TaskTracker) StartWorkspaceTask(ctx context.Context, workspaceID string, name string) *Task {
 	var parent *Task
-	return tt.startTask(contextutil.WithoutCancel(ctx), parent, name)
+	return tt.startTask(contextutil.WithoutCancel(ctx), parent, workspaceID, name)
 }
 
-func (tt *TaskTracker) startTask(ctx context.Context, parent *Task, name string) *Task {
-	task := tt.createTask(ctx, parent, name)
+func (tt *TaskTracker) startTask(ctx context.Context, parent *Task, workspaceID string, name string) *Task {
+	task := tt.createTask(ctx, parent, workspaceID, name)
 	task.Start()
 	return task
 }
@@ -105,6 +164,7 @@ func (t *Task) Finish() error {
 		return t.err
 	}
 	defer cancel()
+	defer t.tt.forgetJob(t)
 	t.cancel = nil
 	var err error
 	if t.err != nil {
@@ -118,6 +178,17 @@ func (t *Task) Finish() error {
 	if err != nil {
 		status = api.StatusFailure
 		message = err.Error()
+		// The message is truncated to its first line, so keep the whole error.
+		t.ReportOutput(message)
+	}
+	// Tasks that fail once their job is canceled, as well as the job itself,
+	// are reported as canceled rather than failed.
+	if t.Canceled() && (err != nil || t.root == t) {
+		status = api.StatusCanceled
+		message = "canceled"
+		if err == nil {
+			err = context.Canceled
+		}
 	}
 	finished := chrono.NowString(t)
 	t.updateTask(status, message, finished, 0, 0)
@@ -127,6 +198,19 @@ func (t *Task) Finish() error {
 	return err
 }
 
+// Skip reports a pending task as finished without having run, such as when
+// an earlier step of its job failed. It is safe to call Finish() afterwards.
+func (t *Task) Skip(message string) {
+	cancel := t.cancel
+	if cancel == nil {
+		return
+	}
+	defer cancel()
+	defer t.tt.forgetJob(t)
+	t.cancel = nil
+	t.updateTask(api.StatusSkipped, message, chrono.NowString(t), 0, 0)
+}
+
 func (t *Task) updateTask(status string, message string, finished string, current, total int) {
 	if t.id == "" {
 		return
@@ -150,7 +234,8 @@ func (t *Task) updateTask(status string, message string, finished string, curren
 			Total:   total,
 		}
 	}
-	if _, err := t.tt.Store.UpdateTask(t, &input); err != nil {
+	// Report the outcome of tasks even after they have been canceled.
+	if _, err := t.tt.Store.UpdateTask(contextutil.WithoutCancel(t), &input); err != nil {
 		if t.reportingErr == nil {
 			t.reportingErr = err
 		}
@@ -165,16 +250,31 @@ func (t *Task) ReportProgress(current, total int) {
 	t.updateTask("", "", "", current, total)
 }
 
+// ReportOutput appends each line of output to this task's output.
+func (t *Task) ReportOutput(output string) {
+	if t.id == "" {
+		return
+	}
+	ctx := contextutil.WithoutCancel(t)
+	if _, err := t.tt.Store.AddTaskOutput(ctx, &api.AddTaskOutputInput{
+		TaskID:    t.id,
+		Timestamp: chrono.NowString(ctx),
+		Lines:     strings.Split(strings.TrimRight(output, "\n"), "\n"),
+	}); err != nil && t.reportingErr == nil {
+		t.reportingErr = err
+	}
+}
+
 func (t *Task) Wait() error {
 	return t.eg.Wait()
 }
 
 func (t *Task) CreateChild(name string) *Task {
-	return t.tt.createTask(t, t, name)
+	return t.tt.createTask(t, t, "", name)
 }
 
 func (t *Task) StartChild(name string) *Task {
-	return t.tt.startTask(t, t, name)
+	return t.tt.startTask(t, t, "", name)
 }
 
 func (t *Task) RunChild(name string, f func(task *Task) error) error {
@@ -0,0 +1,79 @@
+package task_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/deref/exo/internal/task"
+	"github.com/deref/exo/internal/task/api"
+	taskserver "github.com/deref/exo/internal/task/server"
+	"github.com/deref/exo/internal/util/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelJob(t *testing.T) {
+	ctx := context.Background()
+	store := taskserver.NewTaskStore()
+	tt := &task.TaskTracker{
+		Store:  store,
+		Logger: logging.Default(),
+	}
+
+	job := tt.StartTask(ctx, "job")
+	started := make(chan struct{})
+	job.Go("child", func(t *task.Task) error {
+		close(started)
+		<-t.Done()
+		return errors.New("interrupted")
+	})
+	<-started
+
+	assert.False(t, tt.CancelJob("no-such-job"))
+	assert.True(t, tt.CancelJob(job.JobID()))
+	assert.Error(t, job.Finish())
+	assert.False(t, tt.CancelJob(job.JobID()), "finished jobs cannot be canceled")
+
+	output, err := store.DescribeTasks(ctx, &api.DescribeTasksInput{
+		JobIDs: []string{job.JobID()},
+	})
+	require.NoError(t, err)
+	statuses := make(map[string]string)
+	for _, task := range output.Tasks {
+		statuses[task.Name] = task.Status
+	}
+	assert.Equal(t, map[string]string{
+		"job":   api.StatusCanceled,
+		"child": api.StatusCanceled,
+	}, statuses)
+}
+
+func TestReportOutput(t *testing.T) {
+	ctx := context.Background()
+	store := taskserver.NewTaskStore()
+	tt := &task.TaskTracker{
+		Store:  store,
+		Logger: logging.Default(),
+	}
+
+	job := tt.StartTask(ctx, "job")
+	var childID string
+	_ = job.RunChild("child", func(t *task.Task) error {
+		childID = t.ID()
+		task.ReportOutput(t, "step 1\nstep 2\n")
+		return errors.New("failed\nwith details")
+	})
+	require.NoError(t, job.Finish())
+
+	output, err := store.GetTaskOutput(ctx, &api.GetTaskOutputInput{
+		TaskIDs: []string{job.ID(), childID},
+	})
+	require.NoError(t, err)
+	var lines []string
+	for _, line := range output.Items {
+		assert.Equal(t, childID, line.TaskID)
+		lines = append(lines, line.Message)
+	}
+	assert.Equal(t, []string{"step 1", "step 2", "failed", "with details"}, lines)
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"net/http"
 	"sort"
-	"strings"
+	"strconv"
 	"sync"
 	"time"
 
@@ -17,6 +17,10 @@ import (
 type TaskStore struct {
 	mx    sync.Mutex
 	tasks map[string]*api.TaskDescription
+	// Output of all tasks, in the order it was written. Line IDs are sequence
+	// numbers.
+	output       []api.TaskOutputLine
+	outputLineID int
 }
 
 func NewTaskStore() *TaskStore {
@@ -37,18 +41,64 @@ func (sto *TaskStore) DescribeTasks(ctx context.Context, input *api.DescribeTask
 		}
 	}
 
+	var statuses map[string]bool
+	if input.Statuses != nil {
+		statuses = make(map[string]bool, len(input.Statuses))
+		for _, status := range input.Statuses {
+			statuses[status] = true
+		}
+	}
+
+	var createdAfter, createdBefore time.Time
+	if input.CreatedAfter != "" {
+		var err error
+		createdAfter, err = chrono.ParseIsoNano(input.CreatedAfter)
+		if err != nil {
+			return nil, errutil.HTTPErrorf(http.StatusBadRequest, "invalid created-after: %w", err)
+		}
+	}
+	if input.CreatedBefore != "" {
+		var err error
+		createdBefore, err = chrono.ParseIsoNano(input.CreatedBefore)
+		if err != nil {
+			return nil, errutil.HTTPErrorf(http.StatusBadRequest, "invalid created-before: %w", err)
+		}
+	}
+
 	var descriptions []api.TaskDescription
 	for _, task := range sto.tasks {
-		if jobIDs == nil || jobIDs[task.JobID] {
-			descriptions = append(descriptions, *task)
+		if jobIDs != nil && !jobIDs[task.JobID] {
+			continue
+		}
+		if input.WorkspaceID != "" && task.WorkspaceID != input.WorkspaceID {
+			continue
+		}
+		if statuses != nil && !statuses[task.Status] {
+			continue
 		}
+		if input.JobsOnly && task.ParentID != nil {
+			continue
+		}
+		created, _ := chrono.ParseIsoNano(task.Created)
+		if !createdAfter.IsZero() && created.Before(createdAfter) {
+			continue
+		}
+		if !createdBefore.IsZero() && !created.Before(createdBefore) {
+			continue
+		}
+		descriptions = append(descriptions, *task)
 	}
 	sort.Slice(descriptions, func(i, j int) bool {
-		return strings.Compare(
-			descriptions[i].Created+":"+descriptions[i].ID,
-			descriptions[j].Created+":"+descriptions[j].ID,
-		) < 0
+		a, _ := chrono.ParseIsoNano(descriptions[i].Created)
+		b, _ := chrono.ParseIsoNano(descriptions[j].Created)
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return descriptions[i].ID < descriptions[j].ID
 	})
+	if input.Limit != nil && len(descriptions) > *input.Limit {
+		descriptions = descriptions[len(descriptions)-*input.Limit:]
+	}
 
 	return &api.DescribeTasksOutput{
 		Tasks: descriptions,
@@ -61,12 +111,13 @@ func (sto *TaskStore) CreateTask(ctx context.Context, input *api.CreateTaskInput
 
 	id := gensym.RandomBase32()
 	desc := &api.TaskDescription{
-		ID:       id,
-		ParentID: input.ParentID,
-		Name:     input.Name,
-		Status:   api.StatusPending,
-		Created:  chrono.NowString(ctx),
-		Updated:  chrono.NowString(ctx),
+		ID:          id,
+		ParentID:    input.ParentID,
+		WorkspaceID: input.WorkspaceID,
+		Name:        input.Name,
+		Status:      api.StatusPending,
+		Created:     chrono.NowString(ctx),
+		Updated:     chrono.NowString(ctx),
 	}
 	if desc.ParentID == nil {
 		desc.JobID = id
@@ -76,6 +127,7 @@ func (sto *TaskStore) CreateTask(ctx context.Context, input *api.CreateTaskInput
 			return nil, errutil.HTTPErrorf(http.StatusNotFound, "no such parent task: %q", *desc.ParentID)
 		}
 		desc.JobID = parent.JobID
+		desc.WorkspaceID = parent.WorkspaceID
 	}
 	sto.tasks[id] = desc
 
@@ -115,28 +167,96 @@ func (sto *TaskStore) UpdateTask(ctx context.Context, input *api.UpdateTaskInput
 	return &api.UpdateTaskOutput{}, nil
 }
 
+func (sto *TaskStore) AddTaskOutput(ctx context.Context, input *api.AddTaskOutputInput) (*api.AddTaskOutputOutput, error) {
+	sto.mx.Lock()
+	defer sto.mx.Unlock()
+
+	for _, message := range input.Lines {
+		sto.outputLineID++
+		sto.output = append(sto.output, api.TaskOutputLine{
+			ID:        strconv.Itoa(sto.outputLineID),
+			TaskID:    input.TaskID,
+			Timestamp: input.Timestamp,
+			Message:   message,
+		})
+	}
+	return &api.AddTaskOutputOutput{}, nil
+}
+
+func (sto *TaskStore) GetTaskOutput(ctx context.Context, input *api.GetTaskOutputInput) (*api.GetTaskOutputOutput, error) {
+	sto.mx.Lock()
+	defer sto.mx.Unlock()
+
+	after := 0
+	if input.Cursor != "" {
+		var err error
+		after, err = strconv.Atoi(input.Cursor)
+		if err != nil {
+			return nil, errutil.HTTPErrorf(http.StatusBadRequest, "invalid cursor: %q", input.Cursor)
+		}
+	}
+	limit := 500
+	if input.Next != nil {
+		limit = *input.Next
+	}
+	taskIDs := make(map[string]bool, len(input.TaskIDs))
+	for _, id := range input.TaskIDs {
+		taskIDs[id] = true
+	}
+
+	output := &api.GetTaskOutputOutput{
+		Items:      []api.TaskOutputLine{},
+		NextCursor: input.Cursor,
+	}
+	for _, line := range sto.output {
+		if len(output.Items) >= limit {
+			break
+		}
+		id, _ := strconv.Atoi(line.ID)
+		if id <= after || !taskIDs[line.TaskID] {
+			continue
+		}
+		output.Items = append(output.Items, line)
+		output.NextCursor = line.ID
+	}
+	return output, nil
+}
+
 func (sto *TaskStore) EvictTasks(ctx context.Context, input *api.EvictTasksInput) (output *api.EvictTasksOutput, err error) {
 	sto.mx.Lock()
 	defer sto.mx.Unlock()
 
-	expire := chrono.IsoNano(chrono.Now(ctx).Add(-1 * time.Hour))
+	expire := chrono.Now(ctx).Add(-1 * time.Hour)
+	if input.Before != "" {
+		expire, err = chrono.ParseIsoNano(input.Before)
+		if err != nil {
+			return nil, errutil.HTTPErrorf(http.StatusBadRequest, "invalid before: %w", err)
+		}
+	}
 
 	// Gather most recent update times for each job.
-	jobs := make(map[string]string)
+	jobs := make(map[string]time.Time)
 	for _, task := range sto.tasks {
-		updated := jobs[task.JobID]
-		if strings.Compare(task.Updated, updated) > 0 {
-			jobs[task.JobID] = task.Updated
+		updated, _ := chrono.ParseIsoNano(task.Updated)
+		if updated.After(jobs[task.JobID]) {
+			jobs[task.JobID] = updated
 		}
 	}
 
-	// Remove any tasks for jobs last updated bevior the expiration time.
+	// Remove any tasks for jobs last updated before the expiration time, along
+	// with their output.
 	for id, task := range sto.tasks {
-		updated := jobs[task.JobID]
-		if strings.Compare(updated, expire) < 0 {
-			delete(jobs, id)
+		if jobs[task.JobID].Before(expire) {
+			delete(sto.tasks, id)
+		}
+	}
+	retained := sto.output[:0]
+	for _, line := range sto.output {
+		if _, ok := sto.tasks[line.TaskID]; ok {
+			retained = append(retained, line)
 		}
 	}
+	sto.output = retained
 
 	return &api.EvictTasksOutput{}, nil
 }
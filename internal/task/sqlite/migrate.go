@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/deref/exo/internal/util/sqliteutil"
+)
+
+// See sqliteutil.Migrate.
+var migrations = []sqliteutil.Migration{
+	{
+		Name: "task-0001-create-tables",
+		Statements: []string{
+			// Times are stored both as strings, as reported, and as Unix
+			// nanoseconds, for comparisons.
+			`CREATE TABLE task (
+				id TEXT NOT NULL PRIMARY KEY,
+				job_id TEXT NOT NULL,
+				parent_id TEXT,
+				workspace_id TEXT NOT NULL,
+				name TEXT NOT NULL,
+				status TEXT NOT NULL,
+				message TEXT NOT NULL,
+				created TEXT NOT NULL,
+				created_at INTEGER NOT NULL,
+				updated TEXT NOT NULL,
+				updated_at INTEGER NOT NULL,
+				started TEXT,
+				finished TEXT,
+				progress_current INTEGER,
+				progress_total INTEGER
+			)`,
+			`CREATE INDEX task_job ON task ( job_id )`,
+			`CREATE INDEX task_created ON task ( created_at )`,
+			`CREATE INDEX task_workspace_created ON task ( workspace_id, created_at )`,
+		},
+	},
+	{
+		// Task output is kept apart from the event table, so that it shares the
+		// retention of its tasks, rather than that of the event store.
+		Name: "task-0002-create-task-output",
+		Statements: []string{
+			`CREATE TABLE task_output (
+				id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+				task_id TEXT NOT NULL,
+				timestamp TEXT NOT NULL,
+				message TEXT NOT NULL
+			)`,
+			`CREATE INDEX task_output_task ON task_output ( task_id, id )`,
+		},
+	},
+}
+
+func (sto *Store) Migrate(ctx context.Context) error {
+	return sqliteutil.Migrate(ctx, sto.DB, migrations)
+}
@@ -0,0 +1,378 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deref/exo/internal/chrono"
+	"github.com/deref/exo/internal/gensym"
+	"github.com/deref/exo/internal/task/api"
+	"github.com/deref/exo/internal/util/errutil"
+	"github.com/deref/exo/internal/util/mathutil"
+	"github.com/deref/exo/internal/util/sqliteutil"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Store persists tasks, so that the history of jobs survives daemon restarts.
+type Store struct {
+	DB *sqlx.DB
+}
+
+var _ api.TaskStore = (*Store)(nil)
+
+type taskRow struct {
+	ID              string         `db:"id"`
+	JobID           string         `db:"job_id"`
+	ParentID        sql.NullString `db:"parent_id"`
+	WorkspaceID     string         `db:"workspace_id"`
+	Name            string         `db:"name"`
+	Status          string         `db:"status"`
+	Message         string         `db:"message"`
+	Created         string         `db:"created"`
+	Updated         string         `db:"updated"`
+	Started         sql.NullString `db:"started"`
+	Finished        sql.NullString `db:"finished"`
+	ProgressCurrent sql.NullInt64  `db:"progress_current"`
+	ProgressTotal   sql.NullInt64  `db:"progress_total"`
+}
+
+const taskColumns = `id, job_id, parent_id, workspace_id, name, status, message, created, updated, started, finished, progress_current, progress_total`
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func (row taskRow) getDescription() api.TaskDescription {
+	desc := api.TaskDescription{
+		ID:          row.ID,
+		JobID:       row.JobID,
+		ParentID:    nullStringPtr(row.ParentID),
+		WorkspaceID: row.WorkspaceID,
+		Name:        row.Name,
+		Status:      row.Status,
+		Message:     row.Message,
+		Created:     row.Created,
+		Updated:     row.Updated,
+		Started:     nullStringPtr(row.Started),
+		Finished:    nullStringPtr(row.Finished),
+	}
+	if row.ProgressTotal.Valid {
+		desc.Progress = &api.TaskProgress{
+			Current: int(row.ProgressCurrent.Int64),
+			Total:   int(row.ProgressTotal.Int64),
+		}
+	}
+	return desc
+}
+
+func parseTime(name, iso string) (int64, error) {
+	nano, err := chrono.ParseIsoToNano(iso)
+	if err != nil {
+		return 0, errutil.HTTPErrorf(http.StatusBadRequest, "invalid %s: %w", name, err)
+	}
+	return nano, nil
+}
+
+func (sto *Store) DescribeTasks(ctx context.Context, input *api.DescribeTasksInput) (*api.DescribeTasksOutput, error) {
+	var conditions []string
+	var args []interface{}
+	if input.JobIDs != nil {
+		if len(input.JobIDs) == 0 {
+			return &api.DescribeTasksOutput{}, nil
+		}
+		conditions = append(conditions, "job_id IN (?)")
+		args = append(args, input.JobIDs)
+	}
+	if input.WorkspaceID != "" {
+		conditions = append(conditions, "workspace_id = ?")
+		args = append(args, input.WorkspaceID)
+	}
+	if input.Statuses != nil {
+		if len(input.Statuses) == 0 {
+			return &api.DescribeTasksOutput{}, nil
+		}
+		conditions = append(conditions, "status IN (?)")
+		args = append(args, input.Statuses)
+	}
+	if input.CreatedAfter != "" {
+		after, err := parseTime("created-after", input.CreatedAfter)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, after)
+	}
+	if input.CreatedBefore != "" {
+		before, err := parseTime("created-before", input.CreatedBefore)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, before)
+	}
+	if input.JobsOnly {
+		conditions = append(conditions, "parent_id IS NULL")
+	}
+
+	// Select the most recent tasks, then reverse them.
+	query := `SELECT ` + taskColumns + ` FROM task`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+	if input.Limit != nil {
+		query += ` LIMIT ?`
+		args = append(args, *input.Limit)
+	}
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("building query: %w", err)
+	}
+
+	var rows []taskRow
+	if err := sto.DB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+	output := &api.DescribeTasksOutput{
+		Tasks: make([]api.TaskDescription, len(rows)),
+	}
+	for i, row := range rows {
+		output.Tasks[len(rows)-1-i] = row.getDescription()
+	}
+	return output, nil
+}
+
+func (sto *Store) CreateTask(ctx context.Context, input *api.CreateTaskInput) (*api.CreateTaskOutput, error) {
+	id := gensym.RandomBase32()
+	now := chrono.Now(ctx)
+	row := taskRow{
+		ID:          id,
+		JobID:       id,
+		WorkspaceID: input.WorkspaceID,
+		Name:        input.Name,
+		Status:      api.StatusPending,
+		Created:     chrono.IsoNano(now),
+		Updated:     chrono.IsoNano(now),
+	}
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		if input.ParentID != nil {
+			var parent taskRow
+			if err := tx.GetContext(ctx, &parent, `
+				SELECT `+taskColumns+`
+				FROM task
+				WHERE id = ?
+			`, *input.ParentID); err == sql.ErrNoRows {
+				return errutil.HTTPErrorf(http.StatusNotFound, "no such parent task: %q", *input.ParentID)
+			} else if err != nil {
+				return fmt.Errorf("querying parent: %w", err)
+			}
+			row.ParentID = sql.NullString{String: parent.ID, Valid: true}
+			row.JobID = parent.JobID
+			row.WorkspaceID = parent.WorkspaceID
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO task ( id, job_id, parent_id, workspace_id, name, status, message, created, created_at, updated, updated_at )
+			VALUES ( ?, ?, ?, ?, ?, ?, '', ?, ?, ?, ? )
+		`, row.ID, row.JobID, row.ParentID, row.WorkspaceID, row.Name, row.Status,
+			row.Created, now.UnixNano(), row.Updated, now.UnixNano()); err != nil {
+			return fmt.Errorf("inserting: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.CreateTaskOutput{
+		ID:    id,
+		JobID: row.JobID,
+	}, nil
+}
+
+func (sto *Store) UpdateTask(ctx context.Context, input *api.UpdateTaskInput) (*api.UpdateTaskOutput, error) {
+	now := chrono.Now(ctx)
+	sets := []string{"updated = ?", "updated_at = ?"}
+	args := []interface{}{chrono.IsoNano(now), now.UnixNano()}
+	if input.Status != nil {
+		sets = append(sets, "status = ?")
+		args = append(args, *input.Status)
+	}
+	if input.Message != nil {
+		sets = append(sets, "message = ?")
+		args = append(args, *input.Message)
+	}
+	if input.Started != nil {
+		sets = append(sets, "started = ?")
+		args = append(args, *input.Started)
+	}
+	if input.Finished != nil {
+		sets = append(sets, "finished = ?")
+		args = append(args, *input.Finished)
+	}
+	if input.Progress != nil {
+		sets = append(sets, "progress_current = ?", "progress_total = ?")
+		args = append(args, input.Progress.Current, input.Progress.Total)
+	}
+	args = append(args, input.ID)
+
+	result, err := sto.DB.ExecContext(ctx, `UPDATE task SET `+strings.Join(sets, ", ")+` WHERE id = ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("updating: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return nil, errutil.HTTPErrorf(http.StatusNotFound, "no such task: %q", input.ID)
+	}
+	return &api.UpdateTaskOutput{}, nil
+}
+
+func (sto *Store) AddTaskOutput(ctx context.Context, input *api.AddTaskOutputInput) (*api.AddTaskOutputOutput, error) {
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		for _, line := range input.Lines {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO task_output ( task_id, timestamp, message )
+				VALUES ( ?, ?, ? )
+			`, input.TaskID, input.Timestamp, line); err != nil {
+				return fmt.Errorf("inserting: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.AddTaskOutputOutput{}, nil
+}
+
+const (
+	defaultOutputLimit = 500
+	maxOutputLimit     = 10000
+)
+
+func (sto *Store) GetTaskOutput(ctx context.Context, input *api.GetTaskOutputInput) (*api.GetTaskOutputOutput, error) {
+	output := &api.GetTaskOutputOutput{
+		Items:      []api.TaskOutputLine{},
+		NextCursor: input.Cursor,
+	}
+	if len(input.TaskIDs) == 0 {
+		return output, nil
+	}
+	var after int64
+	if input.Cursor != "" {
+		var err error
+		after, err = strconv.ParseInt(input.Cursor, 10, 64)
+		if err != nil {
+			return nil, errutil.HTTPErrorf(http.StatusBadRequest, "invalid cursor: %q", input.Cursor)
+		}
+	}
+	limit := defaultOutputLimit
+	if input.Next != nil {
+		limit = mathutil.IntClamp(*input.Next, 0, maxOutputLimit)
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT id, task_id, timestamp, message
+		FROM task_output
+		WHERE task_id IN (?) AND id > ?
+		ORDER BY id ASC
+		LIMIT ?
+	`, input.TaskIDs, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("building query: %w", err)
+	}
+	var rows []struct {
+		ID        int64  `db:"id"`
+		TaskID    string `db:"task_id"`
+		Timestamp string `db:"timestamp"`
+		Message   string `db:"message"`
+	}
+	if err := sto.DB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, fmt.Errorf("querying: %w", err)
+	}
+	for _, row := range rows {
+		output.Items = append(output.Items, api.TaskOutputLine{
+			ID:        strconv.FormatInt(row.ID, 10),
+			TaskID:    row.TaskID,
+			Timestamp: row.Timestamp,
+			Message:   row.Message,
+		})
+		output.NextCursor = strconv.FormatInt(row.ID, 10)
+	}
+	return output, nil
+}
+
+func (sto *Store) EvictTasks(ctx context.Context, input *api.EvictTasksInput) (*api.EvictTasksOutput, error) {
+	before := chrono.Now(ctx).Add(-1 * time.Hour).UnixNano()
+	if input.Before != "" {
+		var err error
+		before, err = parseTime("before", input.Before)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := sqliteutil.Transact(ctx, sto.DB, func(tx *sqlx.Tx) error {
+		var evicted []string
+		if err := tx.SelectContext(ctx, &evicted, `
+			SELECT job_id
+			FROM task
+			GROUP BY job_id
+			HAVING MAX(updated_at) < ?
+		`, before); err != nil {
+			return fmt.Errorf("querying: %w", err)
+		}
+		if len(evicted) == 0 {
+			return nil
+		}
+		query, args, err := sqlx.In(`
+			DELETE FROM task_output
+			WHERE task_id IN (
+				SELECT id
+				FROM task
+				WHERE job_id IN (?)
+			)
+		`, evicted)
+		if err != nil {
+			return fmt.Errorf("building query: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("deleting output: %w", err)
+		}
+		query, args, err = sqlx.In(`
+			DELETE FROM task
+			WHERE job_id IN (?)
+		`, evicted)
+		if err != nil {
+			return fmt.Errorf("building query: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("deleting: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &api.EvictTasksOutput{}, nil
+}
+
+// InterruptTasks marks tasks that were left unfinished, such as by the daemon
+// exiting, as failed.
+func (sto *Store) InterruptTasks(ctx context.Context) error {
+	now := chrono.Now(ctx)
+	if _, err := sto.DB.ExecContext(ctx, `
+		UPDATE task
+		SET status = ?, message = ?, finished = ?, updated = ?, updated_at = ?
+		WHERE status IN ( ?, ? )
+	`, api.StatusFailure, "interrupted", chrono.IsoNano(now), chrono.IsoNano(now), now.UnixNano(),
+		api.StatusPending, api.StatusRunning); err != nil {
+		return fmt.Errorf("updating: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/deref/exo/internal/chrono"
+	"github.com/deref/exo/internal/task/api"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *Store {
+	db, err := sqlx.Open("sqlite3", filepath.Join(t.TempDir(), "exo.sqlite3")+"?_txlock=exclusive")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	sto := &Store{DB: db}
+	require.NoError(t, sto.Migrate(context.Background()))
+	return sto
+}
+
+func TestTasks(t *testing.T) {
+	ctx := context.Background()
+	sto := newTestStore(t)
+
+	create := func(parentID *string, workspaceID, name string) string {
+		output, err := sto.CreateTask(ctx, &api.CreateTaskInput{
+			ParentID:    parentID,
+			WorkspaceID: workspaceID,
+			Name:        name,
+		})
+		require.NoError(t, err)
+		return output.ID
+	}
+	build := create(nil, "ws", "building")
+	step := create(&build, "", "web")
+	create(nil, "", "upgrading")
+
+	failure := api.StatusFailure
+	message := "exit status 1"
+	_, err := sto.UpdateTask(ctx, &api.UpdateTaskInput{
+		ID:       step,
+		Status:   &failure,
+		Message:  &message,
+		Progress: &api.TaskProgress{Current: 1, Total: 2},
+	})
+	require.NoError(t, err)
+	_, err = sto.UpdateTask(ctx, &api.UpdateTaskInput{ID: "missing", Status: &failure})
+	assert.Error(t, err)
+
+	names := func(input *api.DescribeTasksInput) []string {
+		output, err := sto.DescribeTasks(ctx, input)
+		require.NoError(t, err)
+		var names []string
+		for _, task := range output.Tasks {
+			names = append(names, task.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"building", "web", "upgrading"}, names(&api.DescribeTasksInput{}))
+	assert.Equal(t, []string{"building", "web"}, names(&api.DescribeTasksInput{JobIDs: []string{build}}))
+	assert.Equal(t, []string{"building", "web"}, names(&api.DescribeTasksInput{WorkspaceID: "ws"}))
+	assert.Equal(t, []string{"web"}, names(&api.DescribeTasksInput{Statuses: []string{api.StatusFailure}}))
+	assert.Equal(t, []string{"building", "upgrading"}, names(&api.DescribeTasksInput{JobsOnly: true}))
+	limit := 1
+	assert.Equal(t, []string{"upgrading"}, names(&api.DescribeTasksInput{JobsOnly: true, Limit: &limit}))
+	assert.Empty(t, names(&api.DescribeTasksInput{CreatedAfter: chrono.NowString(ctx)}))
+	assert.Empty(t, names(&api.DescribeTasksInput{CreatedBefore: "2021-01-01T00:00:00Z"}))
+
+	output, err := sto.DescribeTasks(ctx, &api.DescribeTasksInput{JobIDs: []string{build}})
+	require.NoError(t, err)
+	if assert.Len(t, output.Tasks, 2) {
+		task := output.Tasks[1]
+		assert.Equal(t, build, task.JobID)
+		assert.Equal(t, &build, task.ParentID)
+		assert.Equal(t, "ws", task.WorkspaceID)
+		assert.Equal(t, message, task.Message)
+		assert.Equal(t, &api.TaskProgress{Current: 1, Total: 2}, task.Progress)
+	}
+
+	require.NoError(t, sto.InterruptTasks(ctx))
+	assert.Equal(t, []string{"building", "web", "upgrading"}, names(&api.DescribeTasksInput{
+		Statuses: []string{api.StatusFailure},
+	}))
+
+	_, err = sto.EvictTasks(ctx, &api.EvictTasksInput{Before: "2021-01-01T00:00:00Z"})
+	require.NoError(t, err)
+	assert.Len(t, names(&api.DescribeTasksInput{}), 3)
+	_, err = sto.EvictTasks(ctx, &api.EvictTasksInput{Before: chrono.NowString(ctx)})
+	require.NoError(t, err)
+	assert.Empty(t, names(&api.DescribeTasksInput{}))
+}
+
+func TestTaskOutput(t *testing.T) {
+	ctx := context.Background()
+	sto := newTestStore(t)
+
+	job, err := sto.CreateTask(ctx, &api.CreateTaskInput{Name: "building"})
+	require.NoError(t, err)
+	step, err := sto.CreateTask(ctx, &api.CreateTaskInput{ParentID: &job.ID, Name: "web"})
+	require.NoError(t, err)
+	for _, input := range []*api.AddTaskOutputInput{
+		{TaskID: step.ID, Lines: []string{"step 1", "step 2"}},
+		{TaskID: job.ID, Lines: []string{"done"}},
+	} {
+		input.Timestamp = chrono.NowString(ctx)
+		_, err := sto.AddTaskOutput(ctx, input)
+		require.NoError(t, err)
+	}
+
+	messages := func(input *api.GetTaskOutputInput) ([]string, string) {
+		output, err := sto.GetTaskOutput(ctx, input)
+		require.NoError(t, err)
+		var messages []string
+		for _, line := range output.Items {
+			messages = append(messages, line.Message)
+		}
+		return messages, output.NextCursor
+	}
+	all, _ := messages(&api.GetTaskOutputInput{TaskIDs: []string{job.ID, step.ID}})
+	assert.Equal(t, []string{"step 1", "step 2", "done"}, all)
+	only, _ := messages(&api.GetTaskOutputInput{TaskIDs: []string{step.ID}})
+	assert.Equal(t, []string{"step 1", "step 2"}, only)
+	limit := 1
+	first, cursor := messages(&api.GetTaskOutputInput{TaskIDs: []string{job.ID, step.ID}, Next: &limit})
+	assert.Equal(t, []string{"step 1"}, first)
+	rest, _ := messages(&api.GetTaskOutputInput{TaskIDs: []string{job.ID, step.ID}, Cursor: cursor})
+	assert.Equal(t, []string{"step 2", "done"}, rest)
+
+	// Output is evicted along with its tasks.
+	_, err = sto.EvictTasks(ctx, &api.EvictTasksInput{Before: chrono.NowString(ctx)})
+	require.NoError(t, err)
+	var remaining int
+	require.NoError(t, sto.DB.GetContext(ctx, &remaining, `SELECT COUNT(*) FROM task_output`))
+	assert.Equal(t, 0, remaining)
+}
@@ -1,6 +1,7 @@
 package osutil
 
 import (
+	"context"
 	"os"
 	"syscall"
 	"time"
@@ -46,6 +47,12 @@ func WaitProcess(pid int) (*os.ProcessState, error) {
 }
 
 func TerminateProcessWithTimeout(pid int, timeout time.Duration) error {
+	return TerminateProcessWithContext(context.Background(), pid, timeout)
+}
+
+// TerminateProcessWithContext is like TerminateProcessWithTimeout, but also
+// kills the process without waiting out the timeout if ctx is done.
+func TerminateProcessWithContext(ctx context.Context, pid int, timeout time.Duration) error {
 	_ = SignalProcess(pid, syscall.SIGTERM)
 
 	done := make(chan struct{})
@@ -58,6 +65,9 @@ func TerminateProcessWithTimeout(pid int, timeout time.Duration) error {
 	select {
 	case <-timer.C:
 		return KillProcess(pid)
+	case <-ctx.Done():
+		timer.Stop()
+		return KillProcess(pid)
 	case <-done:
 		timer.Stop()
 		return nil
@@ -67,3 +77,7 @@ func TerminateProcessWithTimeout(pid int, timeout time.Duration) error {
 func TerminateGroupWithTimeout(pgid int, timeout time.Duration) error {
 	return TerminateProcessWithTimeout(-pgid, timeout)
 }
+
+func TerminateGroupWithContext(ctx context.Context, pgid int, timeout time.Duration) error {
+	return TerminateProcessWithContext(ctx, -pgid, timeout)
+}
@@ -0,0 +1,65 @@
+// Package sqliteutil provides schema migrations and transactions for the
+// stores that share the daemon's SQLite database.
+package sqliteutil
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Migration struct {
+	// Unique among all stores sharing a database, which record applied
+	// migrations in the same table. Prefixed by store, such as "state-0001-...".
+	Name       string
+	Statements []string
+}
+
+// Migrate applies migrations in order, each at most once. Once released, a
+// migration must not be changed; add another instead.
+func Migrate(ctx context.Context, db *sqlx.DB, migrations []Migration) error {
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS migration (
+			name TEXT NOT NULL PRIMARY KEY
+		)`); err != nil {
+		return fmt.Errorf("creating migration table: %w", err)
+	}
+	for _, m := range migrations {
+		if err := Transact(ctx, db, func(tx *sqlx.Tx) error {
+			var applied int
+			if err := tx.GetContext(ctx, &applied, `
+				SELECT COUNT(*) FROM migration WHERE name = ?
+			`, m.Name); err != nil {
+				return err
+			}
+			if applied > 0 {
+				return nil
+			}
+			for _, statement := range m.Statements {
+				if _, err := tx.ExecContext(ctx, statement); err != nil {
+					return err
+				}
+			}
+			_, err := tx.ExecContext(ctx, `INSERT INTO migration ( name ) VALUES ( ? )`, m.Name)
+			return err
+		}); err != nil {
+			return fmt.Errorf("applying migration %q: %w", m.Name, err)
+		}
+	}
+	return nil
+}
+
+// Transact runs f in a transaction, which is committed if f succeeds and
+// rolled back otherwise.
+func Transact(ctx context.Context, db *sqlx.DB, f func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	if err := f(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
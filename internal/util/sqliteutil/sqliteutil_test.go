@@ -0,0 +1,68 @@
+package sqliteutil
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlx.Open("sqlite3", filepath.Join(t.TempDir(), "exo.sqlite3")+"?_txlock=exclusive")
+	require.NoError(t, err)
+	defer db.Close()
+
+	first := []Migration{
+		{Name: "a-0001", Statements: []string{`CREATE TABLE a ( id INTEGER )`}},
+	}
+	second := []Migration{
+		{Name: "b-0001", Statements: []string{`CREATE TABLE b ( id INTEGER )`}},
+	}
+	// Migrations are applied once, even when stores sharing a database are
+	// migrated repeatedly.
+	for i := 0; i < 2; i++ {
+		require.NoError(t, Migrate(ctx, db, first))
+		require.NoError(t, Migrate(ctx, db, second))
+	}
+	var names []string
+	require.NoError(t, db.SelectContext(ctx, &names, `SELECT name FROM migration ORDER BY name`))
+	assert.Equal(t, []string{"a-0001", "b-0001"}, names)
+
+	// Failed migrations are rolled back and not recorded.
+	err = Migrate(ctx, db, []Migration{
+		{Name: "c-0001", Statements: []string{`CREATE TABLE c ( id INTEGER )`, `INVALID`}},
+	})
+	assert.Error(t, err)
+	var count int
+	require.NoError(t, db.GetContext(ctx, &count, `SELECT COUNT(*) FROM sqlite_master WHERE name = 'c'`))
+	assert.Equal(t, 0, count)
+}
+
+func TestTransact(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlx.Open("sqlite3", filepath.Join(t.TempDir(), "exo.sqlite3")+"?_txlock=exclusive")
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.ExecContext(ctx, `CREATE TABLE t ( id INTEGER )`)
+	require.NoError(t, err)
+
+	failure := errors.New("failure")
+	assert.Equal(t, failure, Transact(ctx, db, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO t ( id ) VALUES ( 1 )`)
+		require.NoError(t, err)
+		return failure
+	}))
+	require.NoError(t, Transact(ctx, db, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO t ( id ) VALUES ( 2 )`)
+		return err
+	}))
+	var ids []int
+	require.NoError(t, db.SelectContext(ctx, &ids, `SELECT id FROM t`))
+	assert.Equal(t, []int{2}, ids)
+}
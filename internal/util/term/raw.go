@@ -46,7 +46,7 @@ func (raw *RawMode) Suspend() error {
 	if err := raw.Exit(); err != nil {
 		return fmt.Errorf("exiting raw mode: %w", err)
 	}
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGCONT)
 	if err := syscall.Kill(0, syscall.SIGSTOP); err != nil {
 		return fmt.Errorf("signally process to stop: %w", err)